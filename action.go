@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	gittype "version-generator/gitType"
+
+	"github.com/alecthomas/kong"
+)
+
+// ActionCmd runs the generator as a GitHub Action: flags come from INPUT_*
+// variables and the version is written to GITHUB_OUTPUT
+type ActionCmd struct {
+	VersionFlags `kong:"embed"`
+	OutputFlags  `kong:"embed"`
+
+	FetchHistory string `kong:"enum='auto,never',default='auto',help='Fetch the full history and tags when the checkout is shallow (auto) or never'"`
+}
+
+// actionInputs resolves flags from the INPUT_<NAME> variables GitHub sets for
+// action inputs, e.g. INPUT_MAX-TAG-DISTANCE for --max-tag-distance. Empty
+// inputs are left unset so the flag keeps its default.
+type actionInputs struct{}
+
+// Validate accepts every input; undeclared ones are never set by GitHub
+func (actionInputs) Validate(*kong.Application) error {
+	return nil
+}
+
+// Resolve returns the input named after a flag, in GitHub's upper-case form
+// with hyphens kept or replaced by underscores
+func (actionInputs) Resolve(_ *kong.Context, _ *kong.Path, flag *kong.Flag) (any, error) {
+	// The config file and profile are chosen before inputs are read
+	if flag.Name == "config" || flag.Name == "profile" {
+		return nil, nil
+	}
+	name := strings.ToUpper(flag.Name)
+	for _, env := range []string{"INPUT_" + name, "INPUT_" + strings.ReplaceAll(name, "-", "_")} {
+		if value := strings.TrimSpace(os.Getenv(env)); value != "" {
+			return value, nil
+		}
+	}
+	return nil, nil
+}
+
+// runAction fetches missing history, generates the version and publishes it
+// as step outputs
+func runAction(cli *CLI) {
+	outputPath := os.Getenv("GITHUB_OUTPUT")
+	if outputPath == "" {
+		fatalf(ErrorUsage, "action must run inside GitHub Actions: GITHUB_OUTPUT is not set")
+	}
+
+	// actions/checkout leaves a one-commit clone without tags unless fetch-depth is 0
+	if cli.Action.FetchHistory == "auto" {
+		fetchShallowHistory(cli)
+	}
+
+	gitHandler, versionInfo := generateVersion(cli)
+	emitVersion(cli, gitHandler, versionInfo)
+
+	outputs := []struct{ name, value string }{
+		{"version", versionInfo.Version},
+		{"last-tag", versionInfo.LastTag},
+		{"commits-since", strconv.Itoa(versionInfo.CommitsSince)},
+		{"branch", versionInfo.Branch},
+		{"commit", versionInfo.Commit},
+		{"short-hash", versionInfo.ShortHash},
+		{"channel", versionInfo.Channel},
+	}
+	file, err := os.OpenFile(outputPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		fatalf(ErrorOutput, "Failed to open GITHUB_OUTPUT: %v", err)
+	}
+	defer file.Close()
+	for _, output := range outputs {
+		if _, err := fmt.Fprintf(file, "%s=%s\n", output.name, output.value); err != nil {
+			fatalf(ErrorOutput, "Failed to write GITHUB_OUTPUT: %v", err)
+		}
+	}
+}
+
+// fetchShallowHistory fetches the full history and all tags of a shallow clone
+func fetchShallowHistory(cli *CLI) {
+	gitHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", gitOptionsFor(cli))
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+	}
+	fetched, err := gitHandler.FetchHistory()
+	if err != nil {
+		fatalf(ErrorGit, "Failed to fetch the history of the shallow clone (set fetch-depth: 0 on actions/checkout instead): %v", err)
+	}
+	if fetched {
+		fmt.Fprintln(os.Stderr, "Fetched the full history and tags of the shallow clone")
+	}
+}
+
+// trustWorkspace lets git read the workspace, which is owned by the runner
+// rather than the container user, without changing any config file
+func trustWorkspace() {
+	count, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+	os.Setenv(fmt.Sprintf("GIT_CONFIG_KEY_%d", count), "safe.directory")
+	os.Setenv(fmt.Sprintf("GIT_CONFIG_VALUE_%d", count), "*")
+	os.Setenv("GIT_CONFIG_COUNT", strconv.Itoa(count+1))
+}
@@ -0,0 +1,46 @@
+package main
+
+import "os"
+
+// projectOutput is the output --auto-output selects for an ecosystem
+type projectOutput struct {
+	marker    string               // File in the current directory identifying the ecosystem
+	selected  func(cli *CLI) *bool // Flag selecting the output
+	configure func(cli *CLI)       // Fills in the output settings left unset
+}
+
+// projectOutputs are the ecosystems --auto-output recognizes, in the order of
+// the output flags
+var projectOutputs = []projectOutput{
+	// version.go, in the package of the Go files next to it
+	{marker: "go.mod", selected: func(cli *CLI) *bool { return &cli.Go }, configure: func(*CLI) {}},
+	// version.h for the sources to include
+	{marker: "CMakeLists.txt", selected: func(cli *CLI) *bool { return &cli.Cpp }, configure: func(*CLI) {}},
+	// appVersion of the Helm chart, merged into Chart.yaml
+	{marker: "Chart.yaml", selected: func(cli *CLI) *bool { return &cli.Yaml }, configure: func(cli *CLI) {
+		if cli.YamlPath == "" {
+			cli.YamlPath = "Chart.yaml"
+		}
+		if cli.YamlKey == "" {
+			cli.YamlKey = "appVersion"
+		}
+		cli.YamlMerge = true
+	}},
+	// version.json, which JavaScript can import
+	{marker: "package.json", selected: func(cli *CLI) *bool { return &cli.JSONFile }, configure: func(*CLI) {}},
+}
+
+// applyAutoOutput selects the outputs of the ecosystems whose project files
+// are in the current directory. An output already selected on the command
+// line or in the config keeps its settings.
+func applyAutoOutput(cli *CLI) {
+	for _, project := range projectOutputs {
+		if _, err := os.Stat(project.marker); err != nil {
+			continue
+		}
+		if selected := project.selected(cli); !*selected {
+			*selected = true
+			project.configure(cli)
+		}
+	}
+}
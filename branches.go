@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	gittype "version-generator/gitType"
+)
+
+// BranchesCmd compares the versions the branches of the repository would produce
+type BranchesCmd struct {
+	VersionFlags `kong:"embed"`
+
+	Remote bool `kong:"help='List the remote-tracking branches instead of the local ones'"`
+	JSON   bool `kong:"name='json',help='Print the report as JSON'"`
+}
+
+// branchVersion is one row of the branches report
+type branchVersion struct {
+	Branch       string `json:"branch"`
+	Current      bool   `json:"current"`
+	Version      string `json:"version"`
+	Tag          string `json:"tag"`
+	CommitsSince int    `json:"commits_since"`
+	ShortHash    string `json:"short_hash"`
+}
+
+// runBranches versions the tip of every local (or remote-tracking) branch
+// with the current flags and prints them as a table or JSON
+func runBranches(cli *CLI) {
+	gitHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", gitOptionsFor(cli))
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+	}
+	branches, err := gitHandler.GetBranches(cli.Branches.Remote)
+	if err != nil {
+		fatalf(ErrorGit, "Failed to list branches: %v", err)
+	}
+	current, err := gitHandler.GetCurrentBranch()
+	if err != nil {
+		fatalf(ErrorGit, "Failed to get current branch: %v", err)
+	}
+
+	report := make([]branchVersion, 0, len(branches))
+	for _, branch := range branches {
+		options := gitOptionsFor(cli)
+		options.Revision, options.Branch = branch.Ref, branch.Name
+		if cli.Branches.Remote {
+			// Version origin/feature as the feature branch it tracks
+			_, options.Branch, _ = strings.Cut(branch.Name, "/")
+		}
+		branchHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", options)
+		if err != nil {
+			fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+		}
+		versionInfo, err := versionInfoFor(cli, branchHandler)
+		if err != nil {
+			fatalf(ErrorGit, "Failed to generate the version of %s: %v", branch.Name, err)
+		}
+		report = append(report, branchVersion{
+			Branch:       branch.Name,
+			Current:      !cli.Branches.Remote && branch.Name == current,
+			Version:      versionInfo.Version,
+			Tag:          versionInfo.LastTag,
+			CommitsSince: versionInfo.CommitsSince,
+			ShortHash:    versionInfo.ShortHash,
+		})
+	}
+
+	if cli.Branches.JSON {
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fatalf(ErrorInternal, "Failed to encode branch versions: %v", err)
+		}
+		fmt.Println(string(encoded))
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "  BRANCH\tVERSION\tTAG\tCOMMITS\tHASH")
+	for _, row := range report {
+		marker := " "
+		if row.Current {
+			marker = "*"
+		}
+		fmt.Fprintf(writer, "%s %s\t%s\t%s\t%d\t%s\n", marker, row.Branch, row.Version, row.Tag, row.CommitsSince, row.ShortHash)
+	}
+	writer.Flush()
+}
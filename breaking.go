@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	gittype "version-generator/gitType"
+)
+
+// BreakingCmd reports the breaking changes since the last tag
+type BreakingCmd struct {
+	VersionFlags `kong:"embed"`
+
+	Policy string `kong:"type='existingfile',help='Release policy file whose forbid_breaking branches fail the report (default: .version-generator-policy.yaml at the repository root, if present)'"`
+	JSON   bool   `kong:"name='json',help='Print the report as JSON'"`
+}
+
+// breakingChange is a commit marked as breaking with ! or a BREAKING CHANGE footer
+type breakingChange struct {
+	Hash    string   `json:"hash"`
+	Type    string   `json:"type"` // Conventional Commits type, or "other"
+	Scope   string   `json:"scope,omitempty"`
+	Subject string   `json:"subject"`
+	Notes   []string `json:"notes,omitempty"` // BREAKING CHANGE footers
+}
+
+// breakingReport is the breaking --json output
+type breakingReport struct {
+	Tag       string           `json:"tag"`
+	Branch    string           `json:"branch"`
+	Forbidden bool             `json:"forbidden"` // the branch matches forbid_breaking in the policy
+	Changes   []breakingChange `json:"changes"`
+}
+
+// runBreaking prints the breaking changes since the last tag grouped by commit
+// type, and fails when the policy forbids them on the current branch
+func runBreaking(cli *CLI) {
+	gitHandler, versionInfo := generateVersion(cli)
+	policy, loadedFrom, err := loadPolicy(cli.Breaking.Policy, gitHandler)
+	if err != nil {
+		fatalf(ErrorPolicy, "Failed to load policy: %v", err)
+	}
+
+	report := breakingReport{
+		Tag:     versionInfo.LastTag,
+		Branch:  versionInfo.Branch,
+		Changes: breakingChanges(commitsSinceLastTag(gitHandler, versionInfo)),
+	}
+	report.Forbidden = policy != nil && matchesAny(policy.ForbidBreaking, versionInfo.Branch)
+
+	if cli.Breaking.JSON {
+		if report.Changes == nil {
+			report.Changes = []breakingChange{}
+		}
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fatalf(ErrorInternal, "Failed to encode breaking changes: %v", err)
+		}
+		fmt.Println(string(encoded))
+	} else {
+		printBreakingChanges(report)
+	}
+
+	if report.Forbidden && len(report.Changes) > 0 {
+		fatalf(ErrorBreakingChange, "%d breaking change(s) since %s on branch %s, which %s forbids", len(report.Changes), report.Tag, report.Branch, loadedFrom)
+	}
+}
+
+// breakingChanges picks the breaking commits, oldest first
+func breakingChanges(commits []gittype.CommitEntry) []breakingChange {
+	var changes []breakingChange
+	for i := len(commits) - 1; i >= 0; i-- {
+		commit := commits[i]
+		notes := breakingNotes(commit.Body)
+		header, conventional := parseConventional(commit.Subject)
+		if len(notes) == 0 && !header.Breaking {
+			continue
+		}
+		change := breakingChange{
+			Hash:    commit.Hash[:min(len(commit.Hash), 7)],
+			Type:    "other",
+			Subject: commit.Subject,
+			Notes:   notes,
+		}
+		if conventional {
+			change.Type, change.Scope, change.Subject = header.Type, header.Scope, header.Description
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// printBreakingChanges prints the changes grouped by type, in order of the
+// first breaking commit of each type
+func printBreakingChanges(report breakingReport) {
+	if len(report.Changes) == 0 {
+		fmt.Printf("No breaking changes since %s\n", report.Tag)
+		return
+	}
+	fmt.Printf("Breaking changes since %s:\n", report.Tag)
+
+	var types []string
+	groups := make(map[string][]breakingChange)
+	for _, change := range report.Changes {
+		if _, ok := groups[change.Type]; !ok {
+			types = append(types, change.Type)
+		}
+		groups[change.Type] = append(groups[change.Type], change)
+	}
+	for _, changeType := range types {
+		fmt.Printf("\n%s:\n", changeType)
+		for _, change := range groups[changeType] {
+			if change.Scope != "" {
+				fmt.Printf("- %s: %s (%s)\n", change.Scope, change.Subject, change.Hash)
+			} else {
+				fmt.Printf("- %s (%s)\n", change.Subject, change.Hash)
+			}
+			for _, note := range change.Notes {
+				fmt.Printf("  %s\n", note)
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+)
+
+// BumpCmd tags HEAD with the release after the last tag
+type BumpCmd struct {
+	VersionFlags `kong:"embed"`
+	IssueFlags   `kong:"embed"`
+
+	Part       string `kong:"arg,enum='major,minor,patch',help='Version part to raise: major, minor or patch'"`
+	Annotate   bool   `kong:"help='Create an annotated tag with the tag-release changelog as its message instead of a lightweight tag'"`
+	Sign       bool   `kong:"help='Sign the tag with the configured OpenPGP or SSH key (implies --annotate)'"`
+	SigningKey string `kong:"help='Signing key, as for tag-release --signing-key (implies --sign)'"`
+	DryRun     bool   `kong:"help='Print the tag that would be created without creating it'"`
+}
+
+// runBump computes the next release from the last tag reachable from HEAD and
+// creates its tag, subject to the release policy as with tag-release
+func runBump(cli *CLI) {
+	gitHandler, versionInfo := generateVersion(cli)
+
+	previousTag, err := previousReleaseTag(gitHandler, versionInfo)
+	if err != nil {
+		fatalf(ErrorGit, "Failed to find previous tag: %v", err)
+	}
+	next, err := versionSchemes.NextRelease(versionInfo.LastTag, cli.Bump.Part)
+	if err != nil {
+		fatalf(ErrorUsage, "Failed to bump %s: %v", versionInfo.LastTag, err)
+	}
+	tag := namingFor(cli).Tag(next)
+
+	signed := cli.Bump.Sign || cli.Bump.SigningKey != ""
+	release := releaseRequest{Tag: tag, PreviousTag: previousTag, Branch: versionInfo.Branch, Signed: signed}
+	if err := enforcePolicy("", "text", release, gitHandler); err != nil {
+		fatalf(ErrorPolicy, "Failed to bump version: %v", err)
+	}
+
+	if cli.Bump.DryRun {
+		fmt.Println(tag)
+		return
+	}
+
+	if !cli.Bump.Annotate && !signed {
+		if err := gitHandler.CreateLightweightTag(tag); err != nil {
+			fatalf(ErrorGit, "Failed to bump version: %v", err)
+		}
+		fmt.Printf("Created tag %s at %s\n", tag, versionInfo.ShortHash)
+		return
+	}
+
+	message, err := renderTagMessage(tag, "", previousTag, issuePatternFor(cli), gitHandler, versionInfo)
+	if err != nil {
+		fatalf(ErrorUsage, "Failed to render tag message: %v", err)
+	}
+	var signing *gittype.SigningOptions
+	if signed {
+		signing = &gittype.SigningOptions{
+			Key:        cli.Bump.SigningKey,
+			Passphrase: os.Getenv(signingPassphraseEnv),
+		}
+	}
+	if err := gitHandler.CreateTag(tag, message, signing); err != nil {
+		fatalf(ErrorGit, "Failed to bump version: %v", err)
+	}
+	if signing != nil {
+		fmt.Printf("Created signed tag %s at %s\n", tag, versionInfo.ShortHash)
+	} else {
+		fmt.Printf("Created tag %s at %s\n", tag, versionInfo.ShortHash)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	gittype "version-generator/gitType"
+)
+
+// ChangedCmd lists the configured components changed since a revision
+type ChangedCmd struct {
+	Since string `kong:"required,help='Tag, branch or commit to compare HEAD against',placeholder='REF'"`
+	JSON  bool   `kong:"name='json',help='Print every component with its change count as JSON'"`
+}
+
+// componentChange is one entry of the changed --json output
+type componentChange struct {
+	Name    string   `json:"name"`
+	Path    string   `json:"path"`
+	Paths   []string `json:"paths"`
+	Changed bool     `json:"changed"`
+	Commits int      `json:"commits"`
+}
+
+// runChanged prints the components with commits touching them, or any of their
+// dependencies, since the given revision: one name per line, or JSON with --json
+func runChanged(cli *CLI) {
+	rootHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", gitOptionsFor(cli))
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+	}
+	repoRoot, err := rootHandler.GetRepoRoot()
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to find repository root: %v", err)
+	}
+
+	ordered, err := loadComponents(cli, rootHandler)
+	if err != nil {
+		fatalf(ErrorConfig, "Failed to load components: %v", err)
+	}
+	cascaded := cascadedPaths(ordered)
+
+	changes := make([]componentChange, 0, len(ordered))
+	for _, component := range ordered {
+		options := gitOptionsFor(cli)
+		options.Paths = cascaded[component.Name]
+		gitHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, repoRoot, options)
+		if err != nil {
+			fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+		}
+
+		commits, err := gitHandler.GetCommitsSinceRevision(cli.Changed.Since)
+		if err != nil {
+			fatalf(ErrorGit, "Failed to check %s: %v", component.Name, err)
+		}
+		changes = append(changes, componentChange{
+			Name:    component.Name,
+			Path:    component.Path,
+			Paths:   options.Paths,
+			Changed: commits > 0,
+			Commits: commits,
+		})
+	}
+
+	if cli.Changed.JSON {
+		encoded, err := json.MarshalIndent(changes, "", "  ")
+		if err != nil {
+			fatalf(ErrorInternal, "Failed to encode changes: %v", err)
+		}
+		fmt.Println(string(encoded))
+		return
+	}
+	for _, change := range changes {
+		if change.Changed {
+			fmt.Println(change.Name)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+
+	"golang.org/x/mod/module"
+)
+
+// CheckRegistryCmd compares the computed version with the latest one published to a registry
+type CheckRegistryCmd struct {
+	VersionFlags `kong:"embed"`
+
+	Docker      string `kong:"xor='source',required,help='Docker image whose tags to list, e.g. nginx or ghcr.io/org/app',placeholder='IMAGE'"`
+	GoModule    string `kong:"xor='source',required,help='Go module path to look up on the module proxy (GOPROXY)',placeholder='PATH'"`
+	Npm         string `kong:"xor='source',required,help='npm package name, e.g. left-pad or @scope/name',placeholder='NAME'"`
+	RegistryURL string `kong:"name='registry-url',help='Registry base URL (default: Docker Hub, the first GOPROXY entry or registry.npmjs.org)',placeholder='URL'"`
+	JSON        bool   `kong:"name='json',help='Print the result as JSON'"`
+	FailOn      string `kong:"enum='none,duplicate,not-ahead',default='none',help='Exit with status 1 when the version is a duplicate, or not ahead (duplicate or behind)'"`
+}
+
+// Registry comparison results
+const (
+	registryAhead     = "ahead"
+	registryBehind    = "behind"
+	registryDuplicate = "duplicate"
+)
+
+// registryCheck is the result of comparing the computed version with a registry
+type registryCheck struct {
+	Registry  string `json:"registry"`
+	Package   string `json:"package"`
+	URL       string `json:"url"`
+	Version   string `json:"version"`
+	Latest    string `json:"latest,omitempty"`
+	Published int    `json:"published"`
+	Status    string `json:"status"`
+}
+
+// registryTimeout bounds each registry request
+const registryTimeout = 30 * time.Second
+
+// runCheckRegistry looks up the published versions and reports whether this build is ahead of them
+func runCheckRegistry(cli *CLI) {
+	_, versionInfo := generateVersion(cli)
+
+	cmd := cli.CheckRegistry
+	client := &http.Client{Timeout: registryTimeout}
+	check := &registryCheck{Version: versionInfo.Version}
+	var published []string
+	var err error
+	switch {
+	case cmd.Docker != "":
+		check.Registry, check.Package = "docker", cmd.Docker
+		published, check.URL, err = dockerTags(client, cmd.Docker, cmd.RegistryURL)
+	case cmd.GoModule != "":
+		check.Registry, check.Package = "go", cmd.GoModule
+		published, check.URL, err = goProxyVersions(client, cmd.GoModule, cmd.RegistryURL)
+	case cmd.Npm != "":
+		check.Registry, check.Package = "npm", cmd.Npm
+		published, check.URL, err = npmVersions(client, cmd.Npm, cmd.RegistryURL)
+	}
+	if err != nil {
+		fatalf(ErrorRegistry, "Failed to query %s registry: %v", check.Registry, err)
+	}
+
+	if err := compareWithPublished(check, versionInfo, published); err != nil {
+		fatalf(ErrorRegistry, "Failed to compare with published versions: %v", err)
+	}
+
+	if cmd.JSON {
+		encoded, err := json.MarshalIndent(check, "", "  ")
+		if err != nil {
+			fatalf(ErrorInternal, "Failed to encode registry check: %v", err)
+		}
+		fmt.Println(string(encoded))
+	} else {
+		latest := check.Latest
+		if latest == "" {
+			latest = "none"
+		}
+		fmt.Printf("Registry:  %s %s (%s)\n", check.Registry, check.Package, check.URL)
+		fmt.Printf("Published: %d versions, latest %s\n", check.Published, latest)
+		fmt.Printf("Version:   %s\n", check.Version)
+		fmt.Printf("Status:    %s\n", check.Status)
+	}
+
+	if (cmd.FailOn == "duplicate" && check.Status == registryDuplicate) ||
+		(cmd.FailOn == "not-ahead" && check.Status != registryAhead) {
+		os.Exit(1)
+	}
+}
+
+// compareWithPublished fills in the latest published version and the status.
+// Registries ignore build metadata, so a version equal in precedence to a
+// published one is a duplicate. A computed version that is not semantic
+// (CalVer, four-part) is compared through its last tag: commits since the
+// tag put it ahead of that tag.
+func compareWithPublished(check *registryCheck, versionInfo *gittype.VersionInfo, published []string) error {
+	var latest *versionSchemes.SemVer
+	duplicate := false
+	for _, name := range published {
+		if strings.TrimPrefix(name, "v") == strings.TrimPrefix(versionInfo.Version, "v") {
+			duplicate = true
+		}
+		parsed, err := versionSchemes.ParseSemVer(name)
+		if err != nil {
+			continue
+		}
+		check.Published++
+		if latest == nil || parsed.Compare(*latest) > 0 {
+			latest, check.Latest = &parsed, name
+		}
+	}
+
+	switch {
+	case duplicate:
+		check.Status = registryDuplicate
+		return nil
+	case latest == nil:
+		check.Status = registryAhead
+		return nil
+	}
+
+	if current, err := versionSchemes.ParseSemVer(versionInfo.Version); err == nil {
+		check.Status = statusFor(current.Compare(*latest), false)
+		return nil
+	}
+	tag, err := versionSchemes.ParseSemVer(versionInfo.LastTag)
+	if err != nil {
+		return fmt.Errorf("version %s is not a semantic version and has no semantic last tag", versionInfo.Version)
+	}
+	check.Status = statusFor(tag.Compare(*latest), versionInfo.CommitsSince > 0)
+	return nil
+}
+
+// statusFor maps a comparison with the latest published version to a status
+func statusFor(comparison int, newCommits bool) string {
+	switch {
+	case comparison > 0, comparison == 0 && newCommits:
+		return registryAhead
+	case comparison < 0:
+		return registryBehind
+	default:
+		return registryDuplicate
+	}
+}
+
+// dockerHub is the registry API host for images without a registry hostname
+const dockerHub = "https://registry-1.docker.io"
+
+// dockerTags lists the tags of an image through the registry v2 API, following
+// Link pagination and fetching an anonymous pull token when challenged
+func dockerTags(client *http.Client, image, registryURL string) ([]string, string, error) {
+	base, repository := dockerRepository(image)
+	if registryURL != "" {
+		base = registryURL
+	}
+	base = strings.TrimSuffix(base, "/")
+	first := base + "/v2/" + repository + "/tags/list"
+
+	var tags []string
+	token := ""
+	next := first
+	for next != "" {
+		response, err := registryGet(client, next, token)
+		if err != nil {
+			return nil, first, err
+		}
+		if response.StatusCode == http.StatusUnauthorized && token == "" {
+			challenge := response.Header.Get("WWW-Authenticate")
+			response.Body.Close()
+			if token, err = dockerToken(client, challenge, repository); err != nil {
+				return nil, first, err
+			}
+			continue
+		}
+
+		var page struct {
+			Tags []string `json:"tags"`
+		}
+		err = decodeRegistryResponse(response, &page)
+		if err != nil {
+			return nil, first, err
+		}
+		tags = append(tags, page.Tags...)
+
+		next, err = nextPage(next, response.Header.Get("Link"))
+		if err != nil {
+			return nil, first, err
+		}
+	}
+	return tags, first, nil
+}
+
+// checkDockerTagCollision fails when the Docker tag about to be emitted already
+// exists for --docker-image, so a rebuild cannot overwrite a published image.
+// A build exactly on a release tag is expected to republish that tag and is not checked.
+func checkDockerTagCollision(cli *CLI, versionInfo *gittype.VersionInfo) error {
+	if versionInfo.CommitsSince == 0 {
+		return nil
+	}
+	client := &http.Client{Timeout: registryTimeout}
+	tags, tagsURL, err := dockerTags(client, cli.DockerImage, cli.DockerRegistry)
+	if err != nil {
+		return withCode(ErrorRegistry, fmt.Errorf("failed to list tags of %s: %w", cli.DockerImage, err))
+	}
+	if slices.Contains(tags, versionInfo.Version) {
+		return withCode(ErrorTagExists, fmt.Errorf("docker tag %s already exists in %s (%s); refusing to overwrite it", versionInfo.Version, cli.DockerImage, tagsURL))
+	}
+	return nil
+}
+
+// dockerRepository splits an image reference into the registry base URL and
+// repository path. Names without a registry hostname live on Docker Hub, where
+// official images are under library/.
+func dockerRepository(image string) (string, string) {
+	image, _, _ = strings.Cut(image, "@")
+	if slash := strings.LastIndex(image, "/"); strings.LastIndex(image, ":") > slash {
+		image = image[:strings.LastIndex(image, ":")]
+	}
+
+	host, rest, found := strings.Cut(image, "/")
+	if found && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		scheme := "https://"
+		if host == "localhost" || strings.HasPrefix(host, "localhost:") || strings.HasPrefix(host, "127.0.0.1") {
+			scheme = "http://"
+		}
+		return scheme + host, rest
+	}
+	if !found {
+		return dockerHub, "library/" + image
+	}
+	return dockerHub, image
+}
+
+// challengeParam matches the key="value" pairs of a WWW-Authenticate header
+var challengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// dockerToken requests an anonymous pull token from the realm of a Bearer challenge
+func dockerToken(client *http.Client, challenge, repository string) (string, error) {
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return "", fmt.Errorf("registry requires authentication (%s)", challenge)
+	}
+	params := map[string]string{}
+	for _, match := range challengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return "", fmt.Errorf("invalid authentication challenge %q", challenge)
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	scope := params["scope"]
+	if scope == "" {
+		scope = "repository:" + repository + ":pull"
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	response, err := registryGet(client, realm.String(), "")
+	if err != nil {
+		return "", err
+	}
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := decodeRegistryResponse(response, &body); err != nil {
+		return "", fmt.Errorf("failed to get registry token: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// nextPage resolves the rel="next" target of a Link header against the current URL
+func nextPage(current, link string) (string, error) {
+	for _, part := range strings.Split(link, ",") {
+		target, params, _ := strings.Cut(part, ";")
+		if !strings.Contains(params, `rel="next"`) {
+			continue
+		}
+		target = strings.Trim(strings.TrimSpace(target), "<>")
+		base, err := url.Parse(current)
+		if err != nil {
+			return "", err
+		}
+		ref, err := url.Parse(target)
+		if err != nil {
+			return "", fmt.Errorf("invalid Link header %q: %w", link, err)
+		}
+		return base.ResolveReference(ref).String(), nil
+	}
+	return "", nil
+}
+
+// defaultGoProxy is used when GOPROXY names no proxy
+const defaultGoProxy = "https://proxy.golang.org"
+
+// goProxyVersions lists the tagged versions of a module on the module proxy
+func goProxyVersions(client *http.Client, modulePath, proxyURL string) ([]string, string, error) {
+	if proxyURL == "" {
+		proxyURL = defaultGoProxy
+		// GOPROXY entries are separated by commas or pipes; direct and off are not proxies
+		for _, entry := range strings.FieldsFunc(os.Getenv("GOPROXY"), func(r rune) bool { return r == ',' || r == '|' }) {
+			if entry != "direct" && entry != "off" {
+				proxyURL = entry
+				break
+			}
+		}
+	}
+	escaped, err := module.EscapePath(modulePath)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid module path: %w", err)
+	}
+	listURL := strings.TrimSuffix(proxyURL, "/") + "/" + escaped + "/@v/list"
+
+	response, err := registryGet(client, listURL, "")
+	if err != nil {
+		return nil, listURL, err
+	}
+	defer response.Body.Close()
+	// The proxy answers 404 or 410 for modules it has never seen
+	if response.StatusCode == http.StatusNotFound || response.StatusCode == http.StatusGone {
+		return nil, listURL, nil
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, listURL, fmt.Errorf("GET %s: %s", listURL, response.Status)
+	}
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, listURL, fmt.Errorf("failed to read %s: %w", listURL, err)
+	}
+	return strings.Fields(string(body)), listURL, nil
+}
+
+// defaultNpmRegistry is the public npm registry
+const defaultNpmRegistry = "https://registry.npmjs.org"
+
+// npmVersions lists the published versions of an npm package
+func npmVersions(client *http.Client, name, registryURL string) ([]string, string, error) {
+	if registryURL == "" {
+		registryURL = defaultNpmRegistry
+	}
+	// Scoped packages keep the @ but escape the slash
+	packageURL := strings.TrimSuffix(registryURL, "/") + "/" + strings.Replace(name, "/", "%2f", 1)
+
+	response, err := registryGet(client, packageURL, "")
+	if err != nil {
+		return nil, packageURL, err
+	}
+	if response.StatusCode == http.StatusNotFound {
+		response.Body.Close()
+		return nil, packageURL, nil
+	}
+	var document struct {
+		Versions map[string]json.RawMessage `json:"versions"`
+	}
+	if err := decodeRegistryResponse(response, &document); err != nil {
+		return nil, packageURL, err
+	}
+	versions := make([]string, 0, len(document.Versions))
+	for version := range document.Versions {
+		versions = append(versions, version)
+	}
+	return versions, packageURL, nil
+}
+
+// registryGet sends a GET request, with a bearer token when one is given
+func registryGet(client *http.Client, target, token string) (*http.Response, error) {
+	request, err := http.NewRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry URL %q: %w", target, err)
+	}
+	request.Header.Set("User-Agent", "version-generator/"+Version)
+	// npm serves a much smaller document for this media type
+	request.Header.Set("Accept", "application/vnd.npm.install-v1+json, application/json")
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach registry: %w", err)
+	}
+	return response, nil
+}
+
+// decodeRegistryResponse decodes a successful JSON response and closes its body
+func decodeRegistryResponse(response *http.Response, v any) error {
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", response.Request.URL, response.Status)
+	}
+	if err := json.NewDecoder(response.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", response.Request.URL, err)
+	}
+	return nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"slices"
+
+	filetype "version-generator/fileType"
+	gittype "version-generator/gitType"
+)
+
+// componentVersion is one entry of the --components summary
+type componentVersion struct {
+	Name         string            `json:"name"`
+	Path         string            `json:"path"`
+	DependsOn    []string          `json:"depends_on,omitempty"`
+	Paths        []string          `json:"paths"`
+	Tag          string            `json:"tag"`
+	Version      string            `json:"version"`
+	CommitsSince int               `json:"commits_since"`
+	File         string            `json:"file,omitempty"`  // The first of Files
+	Files        []string          `json:"files,omitempty"` // Every output file, in flag order
+	Warnings     []gittype.Warning `json:"warnings,omitempty"`
+}
+
+// runComponents versions every component declared in the config and prints a
+// JSON summary, or streams it as JSON lines. A component counts commits touching its own path or the path
+// of any component it depends on, directly or transitively, so a change to a
+// shared library bumps every service built from it.
+func runComponents(cli *CLI) {
+	rootHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", gitOptionsFor(cli))
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+	}
+	repoRoot, err := rootHandler.GetRepoRoot()
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to find repository root: %v", err)
+	}
+
+	ordered, err := loadComponents(cli, rootHandler)
+	if err != nil {
+		fatalf(ErrorConfig, "Failed to load components: %v", err)
+	}
+
+	outputs := selectOutputs(cli)
+	writer := newOutputWriter(cli)
+	cascaded := cascadedPaths(ordered)
+	summary := newSummaryPrinter(cli)
+	for _, component := range ordered {
+		paths := cascaded[component.Name]
+		options := gitOptionsFor(cli)
+		options.TagPrefix, options.TagMatch = componentTagPrefix(component), ""
+		options.Paths = paths
+		gitHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, repoRoot, options)
+		if err != nil {
+			fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+		}
+		versionInfo, err := versionInfoFor(cli, gitHandler)
+		if err != nil {
+			fatalf(ErrorGit, "Failed to generate version info for %s: %v", component.Name, err)
+		}
+		addCommitDate(cli, gitHandler, versionInfo)
+
+		entry := componentVersion{
+			Name:         component.Name,
+			Path:         component.Path,
+			DependsOn:    component.DependsOn,
+			Paths:        paths,
+			Tag:          options.Tag(versionInfo.LastTag),
+			Version:      versionInfo.Version,
+			CommitsSince: versionInfo.CommitsSince,
+			Warnings:     versionInfo.Warnings,
+		}
+
+		componentDir := filepath.Join(repoRoot, filepath.FromSlash(component.Path))
+		for _, output := range outputs {
+			file := path.Join(component.Path, filepath.ToSlash(output.path))
+			output.path = filepath.Join(componentDir, output.path)
+			switch output.fileType.(type) {
+			case *filetype.GoType:
+				output.fileType = &filetype.GoType{Package: packageName(componentDir), Format: cli.GoFormat}
+			case *filetype.GoExpvarType:
+				output.fileType = &filetype.GoExpvarType{Package: packageName(componentDir)}
+			}
+			if cli.Diff {
+				if err := printDiff(output.fileType, output.path, versionInfo, writeOptionsFor(cli, versionInfo)); err != nil {
+					fatalf(ErrorOutput, "Failed to diff version file %s: %v", output.path, err)
+				}
+			} else {
+				writer.write(gitHandler, versionInfo, output)
+			}
+			if entry.File == "" {
+				entry.File = file
+			}
+			entry.Files = append(entry.Files, file)
+		}
+		if !cli.Diff {
+			summary.add(entry)
+		}
+	}
+	writer.commit()
+
+	if !cli.Diff {
+		summary.finish()
+	}
+}
+
+// orderComponents sorts components so every component follows its dependencies,
+// keeping the declared order otherwise, and rejects unknown names and cycles
+func orderComponents(components []ComponentConfig) ([]ComponentConfig, error) {
+	byName := make(map[string]ComponentConfig, len(components))
+	for _, component := range components {
+		if component.Name == "" || component.Path == "" {
+			return nil, fmt.Errorf("every component needs a name and a path")
+		}
+		if _, exists := byName[component.Name]; exists {
+			return nil, fmt.Errorf("component %q is declared twice", component.Name)
+		}
+		byName[component.Name] = component
+	}
+	for _, component := range components {
+		for _, dependency := range component.DependsOn {
+			if _, ok := byName[dependency]; !ok {
+				return nil, fmt.Errorf("component %q depends on unknown component %q", component.Name, dependency)
+			}
+		}
+	}
+
+	const (
+		visiting = iota + 1
+		done
+	)
+	state := make(map[string]int, len(components))
+	var ordered []ComponentConfig
+	var visit func(name string, chain []string) error
+	visit = func(name string, chain []string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			return fmt.Errorf("dependency cycle: %v", append(chain, name))
+		}
+		state[name] = visiting
+		for _, dependency := range byName[name].DependsOn {
+			if err := visit(dependency, append(chain, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = done
+		ordered = append(ordered, byName[name])
+		return nil
+	}
+
+	for _, component := range components {
+		if err := visit(component.Name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// loadComponents reads the configured components in dependency order
+func loadComponents(cli *CLI, gitHandler gittype.GitHandler) ([]ComponentConfig, error) {
+	config, err := loadConfig(cli.Config, gitHandler)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Components) == 0 {
+		return nil, fmt.Errorf("no components declared in the config")
+	}
+	return orderComponents(config.Components)
+}
+
+// cascadedPaths maps each component to its own path plus the paths of its
+// transitive dependencies. ordered must list dependencies first.
+func cascadedPaths(ordered []ComponentConfig) map[string][]string {
+	cascaded := make(map[string][]string, len(ordered))
+	for _, component := range ordered {
+		paths := []string{path.Clean(component.Path)}
+		for _, dependency := range component.DependsOn {
+			paths = appendUnique(paths, cascaded[dependency]...)
+		}
+		cascaded[component.Name] = paths
+	}
+	return cascaded
+}
+
+// componentTagPrefix returns the tag prefix of a component
+func componentTagPrefix(component ComponentConfig) string {
+	if component.TagPrefix != "" {
+		return component.TagPrefix
+	}
+	return component.Name + "/"
+}
+
+// appendUnique appends the values not already present in list
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		if !slices.Contains(list, value) {
+			list = append(list, value)
+		}
+	}
+	return list
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	gittype "version-generator/gitType"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is read from the repository root when --config is not given
+const defaultConfigFile = ".version-generator.yaml"
+
+// Config is the repository configuration file, or the per-user one beneath it
+type Config struct {
+	Components []ComponentConfig         `yaml:"components"`
+	Defaults   map[string]any            `yaml:"defaults"` // Flag values applied to every run
+	Branches   []BranchRule              `yaml:"branches"` // Flag values applied on matching branches
+	Profiles   map[string]map[string]any `yaml:"profiles"` // Named sets of flag values, selected with --profile
+}
+
+// BranchRule applies flag values on the branches matching a glob
+type BranchRule struct {
+	Match  string         `yaml:"match"`   // Branch glob in path.Match syntax, e.g. release/*
+	Values map[string]any `yaml:",inline"` // Flag values, keyed like defaults
+}
+
+// ComponentConfig declares one independently versioned part of a monorepo
+type ComponentConfig struct {
+	Name      string   `yaml:"name"`
+	Path      string   `yaml:"path"`       // Repository-relative directory
+	TagPrefix string   `yaml:"tag_prefix"` // Prefix of the component's tags (default: <name>/)
+	DependsOn []string `yaml:"depends_on"` // Components whose changes also bump this one
+}
+
+// loadConfig reads the configuration file. Without an explicit path the default
+// file at the repository root is used if it exists, otherwise the config is empty.
+func loadConfig(configPath string, gitHandler gittype.GitHandler) (*Config, error) {
+	if configPath == "" {
+		repoRoot, err := gitHandler.GetRepoRoot()
+		if err != nil {
+			return nil, err
+		}
+		configPath = filepath.Join(repoRoot, defaultConfigFile)
+		if _, err := os.Stat(configPath); errors.Is(err, os.ErrNotExist) {
+			return &Config{}, nil
+		}
+	}
+	return readConfig(configPath)
+}
+
+// userConfigPath returns the per-user config file, under $XDG_CONFIG_HOME or ~/.config
+func userConfigPath() (string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		configHome = filepath.Join(home, ".config")
+	}
+	return filepath.Join(configHome, "version-generator", "config.yaml"), nil
+}
+
+// loadUserConfig reads the per-user config file, returning an empty config if there is none
+func loadUserConfig() (*Config, error) {
+	configPath, err := userConfigPath()
+	if err != nil {
+		return &Config{}, nil
+	}
+	if _, err := os.Stat(configPath); errors.Is(err, os.ErrNotExist) {
+		return &Config{}, nil
+	}
+	config, err := readConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Components) > 0 {
+		return nil, fmt.Errorf("config %s: components belong in the repository config", configPath)
+	}
+	return config, nil
+}
+
+// mergeConfig layers a repository config over the user config: repository
+// defaults and profile values win key by key, repository branch rules are tried
+// before the user's, and components come from the repository
+func mergeConfig(user, repo *Config) *Config {
+	merged := &Config{
+		Components: repo.Components,
+		Defaults:   mergeValues(user.Defaults, repo.Defaults),
+		Branches:   append(slices.Clone(repo.Branches), user.Branches...),
+		Profiles:   map[string]map[string]any{},
+	}
+	for name, values := range user.Profiles {
+		merged.Profiles[name] = mergeValues(values, nil)
+	}
+	for name, values := range repo.Profiles {
+		merged.Profiles[name] = mergeValues(merged.Profiles[name], values)
+	}
+	return merged
+}
+
+// mergeValues copies base and applies override on top of it
+func mergeValues(base, override map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		merged[key] = value
+	}
+	return merged
+}
+
+// readConfig reads and strictly decodes one config file
+func readConfig(configPath string) (*Config, error) {
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	var config Config
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config %s: %w", configPath, err)
+	}
+	return &config, nil
+}
+
+// matchBranchRule returns the first rule whose glob matches the current branch,
+// or with --at-tag the branch the tag is versioned on
+func matchBranchRule(rules []BranchRule, cli *CLI, gitHandler gittype.GitHandler) (BranchRule, bool, error) {
+	var branch string
+	var err error
+	if cli.AtTag != "" {
+		branch, err = atTagBranch(gitHandler)
+	} else {
+		branch, err = gitHandler.GetCurrentBranch()
+	}
+	if err != nil {
+		return BranchRule{}, false, fmt.Errorf("failed to get the current branch: %w", err)
+	}
+	for _, rule := range rules {
+		matched, err := path.Match(rule.Match, branch)
+		if err != nil {
+			return BranchRule{}, false, fmt.Errorf("invalid branch rule %q: %w", rule.Match, err)
+		}
+		if matched {
+			return rule, true, nil
+		}
+	}
+	return BranchRule{}, false, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	gittype "version-generator/gitType"
+)
+
+// coAuthorTrailer matches a Co-authored-by trailer line in a commit body
+var coAuthorTrailer = regexp.MustCompile(`(?im)^co-authored-by:[ \t]*(.*?)[ \t]*<([^>\n]+)>[ \t]*$`)
+
+// coAuthors returns the co-authors named in a commit's trailers, leaving out
+// the commit's own author
+func coAuthors(commit gittype.CommitEntry) []gittype.Contributor {
+	var names []gittype.Contributor
+	for _, match := range coAuthorTrailer.FindAllStringSubmatch(commit.Body, -1) {
+		if strings.EqualFold(match[2], commit.Email) {
+			continue
+		}
+		names = append(names, gittype.Contributor{Name: match[1], Email: match[2]})
+	}
+	return names
+}
+
+// coAuthorStats counts the commits each co-author is credited on, most active first
+func coAuthorStats(commits []gittype.CommitEntry) []authorStat {
+	index := make(map[string]int)
+	var stats []authorStat
+	for _, commit := range commits {
+		credited := make(map[string]bool)
+		for _, coAuthor := range coAuthors(commit) {
+			email := strings.ToLower(coAuthor.Email)
+			if credited[email] {
+				continue
+			}
+			credited[email] = true
+			i, ok := index[email]
+			if !ok {
+				i = len(stats)
+				index[email] = i
+				stats = append(stats, authorStat{Name: coAuthor.Name, Email: coAuthor.Email})
+			}
+			stats[i].Commits++
+		}
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Commits > stats[j].Commits
+	})
+	return stats
+}
+
+// contributors lists the authors and co-authors of commits, each once by
+// email, in the order of their first commit
+func contributors(commits []gittype.CommitEntry) []gittype.Contributor {
+	seen := make(map[string]bool)
+	var list []gittype.Contributor
+	add := func(contributor gittype.Contributor) {
+		if email := strings.ToLower(contributor.Email); !seen[email] {
+			seen[email] = true
+			list = append(list, contributor)
+		}
+	}
+	for i := len(commits) - 1; i >= 0; i-- {
+		add(gittype.Contributor{Name: commits[i].Author, Email: commits[i].Email})
+		for _, coAuthor := range coAuthors(commits[i]) {
+			add(coAuthor)
+		}
+	}
+	return list
+}
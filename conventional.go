@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+)
+
+// conventionalHeader matches a Conventional Commits header: type(scope)!: description
+var conventionalHeader = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?: (\S.*)$`)
+
+// footerToken matches the start of a commit message footer, e.g. "Refs: " or "Fixes #"
+var footerToken = regexp.MustCompile(`^[\w-]+(?:: | #)`)
+
+// conventionalCommit is a commit subject parsed as a Conventional Commit
+type conventionalCommit struct {
+	Type        string
+	Scope       string
+	Breaking    bool // marked with ! after the type or scope
+	Description string
+}
+
+// parseConventional parses a commit subject, reporting false when it is not
+// a Conventional Commits header
+func parseConventional(subject string) (conventionalCommit, bool) {
+	match := conventionalHeader.FindStringSubmatch(subject)
+	if match == nil {
+		return conventionalCommit{}, false
+	}
+	return conventionalCommit{
+		Type:        strings.ToLower(match[1]),
+		Scope:       match[2],
+		Breaking:    match[3] == "!",
+		Description: match[4],
+	}, true
+}
+
+// breakingNotes returns the BREAKING CHANGE (or BREAKING-CHANGE) footers of a
+// commit body, each joined into one line. A footer runs until a blank line or
+// the next footer.
+func breakingNotes(body string) []string {
+	var notes []string
+	var note []string
+	flush := func() {
+		if note != nil {
+			notes = append(notes, strings.Join(note, " "))
+			note = nil
+		}
+	}
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "BREAKING CHANGE:"), strings.HasPrefix(line, "BREAKING-CHANGE:"):
+			flush()
+			note = []string{strings.TrimSpace(line[len("BREAKING CHANGE:"):])}
+		case line == "" || footerToken.MatchString(line):
+			flush()
+		case note != nil:
+			note = append(note, line)
+		}
+	}
+	flush()
+	return notes
+}
+
+// unconventionalCommits returns the commits whose subject is not a
+// Conventional Commits header. Merge and revert commits written by git are
+// accepted, as commit linters do.
+func unconventionalCommits(commits []gittype.CommitEntry) []gittype.CommitEntry {
+	var offending []gittype.CommitEntry
+	for _, commit := range commits {
+		if strings.HasPrefix(commit.Subject, "Merge ") || strings.HasPrefix(commit.Subject, `Revert "`) {
+			continue
+		}
+		if _, ok := parseConventional(commit.Subject); !ok {
+			offending = append(offending, commit)
+		}
+	}
+	return offending
+}
+
+// requireConventional checks the commits since the last tag for
+// --require-conventional. Other commits fail generation, or with
+// --on-unconventional=unverified add an -unverified pre-release label and a warning.
+func requireConventional(cli *CLI, gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) (*gittype.Warning, error) {
+	tag, err := previousReleaseTag(gitHandler, versionInfo)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := gitHandler.GetCommitLog(tag)
+	if err != nil {
+		return nil, err
+	}
+	offending := unconventionalCommits(commits)
+	if len(offending) == 0 {
+		return nil, nil
+	}
+
+	var examples []string
+	for _, commit := range offending[:min(len(offending), 3)] {
+		examples = append(examples, fmt.Sprintf("%s %q", commit.Hash[:min(len(commit.Hash), 7)], commit.Subject))
+	}
+	if len(offending) > len(examples) {
+		examples = append(examples, fmt.Sprintf("and %d more", len(offending)-len(examples)))
+	}
+	message := fmt.Sprintf("%d commit(s) since %s do not follow Conventional Commits: %s", len(offending), versionInfo.LastTag, strings.Join(examples, ", "))
+	if cli.OnUnconventional == "error" {
+		return nil, withCode(ErrorUnconventional, errors.New(message))
+	}
+	versionInfo.Version = versionSchemes.AddPrereleaseLabel(versionInfo.Version, "unverified")
+	return &gittype.Warning{Code: gittype.WarningUnconventional, Message: message}, nil
+}
+
+// conventionalBump derives the release the commits lead to: major when any is
+// breaking, by ! or a BREAKING CHANGE footer, minor when any is a feat, and
+// patch otherwise, including for commits that are not Conventional Commits
+func conventionalBump(commits []gittype.CommitEntry) string {
+	bump := versionSchemes.BumpPatch
+	for _, commit := range commits {
+		header, conventional := parseConventional(commit.Subject)
+		if header.Breaking || len(breakingNotes(commit.Body)) > 0 {
+			return versionSchemes.BumpMajor
+		}
+		if conventional && header.Type == "feat" {
+			bump = versionSchemes.BumpMinor
+		}
+	}
+	return bump
+}
+
+// conventionalVersionInfo renders the version for --conventional: the scheme's
+// usual layout with the release the commits since the last tag lead to in
+// place of the tag, e.g. v1.4.0+5 after v1.3.2 and a feat commit. A tagged
+// commit keeps its tag.
+func conventionalVersionInfo(cli *CLI, options versionSchemes.VersioningOptions, gitHandler gittype.GitHandler) (*gittype.VersionInfo, error) {
+	versionInfo, err := gitHandler.GenerateVersionInfo(false)
+	if err != nil {
+		return nil, err
+	}
+	base := versionInfo.LastTag
+	if versionInfo.CommitsSince > 0 {
+		tag, err := previousReleaseTag(gitHandler, versionInfo)
+		if err != nil {
+			return nil, err
+		}
+		commits, err := gitHandler.GetCommitLog(tag)
+		if err != nil {
+			return nil, err
+		}
+		if base, err = versionSchemes.NextRelease(versionInfo.LastTag, conventionalBump(commits)); err != nil {
+			return nil, withCode(ErrorUsage, err)
+		}
+	}
+
+	generator := versionSchemes.NewVersionGenerator()
+	if cli.DockerTag {
+		versionInfo.Version = generator.GenerateLegacy(base, versionInfo.CommitsSince, versionInfo.ShortHash, versionInfo.Branch, true)
+	} else {
+		versionInfo.Version = generator.GenerateVersion(base, versionInfo.CommitsSince, versionInfo.ShortHash, versionInfo.Branch, options)
+	}
+	return versionInfo, nil
+}
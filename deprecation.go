@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	gittype "version-generator/gitType"
+
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+// deprecation describes a flag kept for compatibility and the flag that replaces it
+type deprecation struct {
+	Flag        string // Deprecated flag name
+	Replacement string // Flag to use instead
+	Value       string // Value of the replacement flag that matches the deprecated flag
+}
+
+// WarningDeprecatedFlag is the code of the warning printed for a deprecated flag in use
+const WarningDeprecatedFlag = "deprecated-flag"
+
+// deprecations lists the deprecated boolean flags and their replacements
+var deprecations = []deprecation{
+	{Flag: "semver", Replacement: "scheme", Value: "semver"},
+	{Flag: "cal-ver", Replacement: "scheme", Value: "calver"},
+	{Flag: "simple", Replacement: "scheme", Value: "simple"},
+}
+
+// MigrateConfigCmd rewrites deprecated flags in a config file or command line
+type MigrateConfigCmd struct {
+	User    bool   `kong:"help='Migrate the per-user config instead of the repository config'"`
+	DryRun  bool   `kong:"help='Print the migrated config instead of writing it'"`
+	Command string `kong:"help='Print this command line with its deprecated flags replaced instead of migrating a config file',placeholder='ARGS'"`
+}
+
+// warnDeprecated prints one warning for each deprecated flag in use, whether
+// it came from the command line, the environment or a config file
+func warnDeprecated(ctx *kong.Context) {
+	for _, flag := range ctx.Flags() {
+		for _, d := range deprecations {
+			if flag.Name == d.Flag && !flag.Target.IsZero() {
+				printWarning(gittype.Warning{
+					Code: WarningDeprecatedFlag,
+					Message: fmt.Sprintf("--%s is deprecated and will be removed; use --%s %s (migrate-config updates config files)",
+						d.Flag, d.Replacement, d.Value),
+				})
+			}
+		}
+	}
+}
+
+// runMigrateConfig migrates a command line or the selected config file
+func runMigrateConfig(cli *CLI) {
+	cmd := cli.MigrateConfig
+	if cmd.Command != "" {
+		fmt.Println(migrateCommandLine(cmd.Command))
+		return
+	}
+
+	configPath, err := migrateConfigPath(cli)
+	if err != nil {
+		fatalf(ErrorConfig, "Failed to locate config file: %v", err)
+	}
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		fatalf(ErrorConfig, "Failed to read config: %v", err)
+	}
+	migrated, changes, err := migrateConfig(content)
+	if err != nil {
+		fatalf(ErrorConfig, "Failed to migrate config %s: %v", configPath, err)
+	}
+
+	if cmd.DryRun {
+		fmt.Print(string(migrated))
+		return
+	}
+	if len(changes) == 0 {
+		fmt.Printf("%s: nothing to migrate\n", configPath)
+		return
+	}
+	if err := os.WriteFile(configPath, migrated, 0644); err != nil {
+		fatalf(ErrorConfig, "Failed to write config: %v", err)
+	}
+	for _, change := range changes {
+		fmt.Printf("%s: %s\n", configPath, change)
+	}
+}
+
+// migrateConfigPath returns --config, the per-user config with --user, or the repository config
+func migrateConfigPath(cli *CLI) (string, error) {
+	if cli.MigrateConfig.User {
+		return userConfigPath()
+	}
+	if cli.Config != "" {
+		return cli.Config, nil
+	}
+	gitHandler, err := gittype.GetGitHandler(cli.InBuiltGit, ".")
+	if err != nil {
+		return "", err
+	}
+	repoRoot, err := gitHandler.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	configPath := filepath.Join(repoRoot, defaultConfigFile)
+	if _, err := os.Stat(configPath); errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("no %s at the repository root", defaultConfigFile)
+	}
+	return configPath, nil
+}
+
+// migrateConfig replaces deprecated keys in the defaults, branch rules and profiles of a
+// config file, keeping its comments and layout, and describes each change
+func migrateConfig(content []byte) ([]byte, []string, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, nil, err
+	}
+	if len(document.Content) == 0 {
+		return content, nil, nil
+	}
+
+	var changes []string
+	root := document.Content[0]
+	if defaults := mappingValue(root, "defaults"); defaults != nil {
+		changes = append(changes, migrateFlagValues(defaults, "defaults")...)
+	}
+	if branches := mappingValue(root, "branches"); branches != nil && branches.Kind == yaml.SequenceNode {
+		for _, rule := range branches.Content {
+			name := "branch rule"
+			if match := mappingValue(rule, "match"); match != nil {
+				name += " " + match.Value
+			}
+			changes = append(changes, migrateFlagValues(rule, name)...)
+		}
+	}
+	if profiles := mappingValue(root, "profiles"); profiles != nil && profiles.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			name := "profile " + profiles.Content[i].Value
+			changes = append(changes, migrateFlagValues(profiles.Content[i+1], name)...)
+		}
+	}
+	if len(changes) == 0 {
+		return content, nil, nil
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, nil, err
+	}
+	return buffer.Bytes(), changes, nil
+}
+
+// migrateFlagValues rewrites the deprecated keys of one mapping of flag values
+func migrateFlagValues(values *yaml.Node, source string) []string {
+	if values.Kind != yaml.MappingNode {
+		return nil
+	}
+	var changes []string
+	for _, d := range deprecations {
+		for i := 0; i+1 < len(values.Content); i += 2 {
+			key, value := values.Content[i], values.Content[i+1]
+			if key.Value != d.Flag {
+				continue
+			}
+			if value.Value != "true" || mappingValue(values, d.Replacement) != nil {
+				// A disabled flag or one already superseded has no effect and is dropped
+				values.Content = append(values.Content[:i], values.Content[i+2:]...)
+				changes = append(changes, fmt.Sprintf("%s: removed %s", source, d.Flag))
+				break
+			}
+			key.Value = d.Replacement
+			value.Value, value.Tag, value.Style = d.Value, "!!str", 0
+			changes = append(changes, fmt.Sprintf("%s: replaced %s with %s: %s", source, d.Flag, d.Replacement, d.Value))
+			break
+		}
+	}
+	return changes
+}
+
+// mappingValue returns the value stored under key in a YAML mapping node
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	if mapping.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// migrateCommandLine replaces deprecated flags in a command line, including their VG_* variables
+func migrateCommandLine(commandLine string) string {
+	fields := strings.Fields(commandLine)
+	migrated := make([]string, 0, len(fields))
+	for _, field := range fields {
+		replaced := false
+		for _, d := range deprecations {
+			envName := "VG_" + strings.ToUpper(strings.ReplaceAll(d.Flag, "-", "_"))
+			name, value, hasValue := strings.Cut(field, "=")
+			switch {
+			case name == "--"+d.Flag && (!hasValue || value == "true"):
+				migrated = append(migrated, "--"+d.Replacement+"="+d.Value)
+			case name == "--"+d.Flag:
+				// --flag=false changes nothing
+			case name == envName:
+				if value == "true" || value == "1" {
+					migrated = append(migrated, "VG_"+strings.ToUpper(d.Replacement)+"="+d.Value)
+				}
+			default:
+				continue
+			}
+			replaced = true
+			break
+		}
+		if !replaced {
+			migrated = append(migrated, field)
+		}
+	}
+	return strings.Join(migrated, " ")
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	gittype "version-generator/gitType"
+	"version-generator/pkg/versiongen"
+
+	"github.com/alecthomas/kong"
+)
+
+// Error codes reported with --output-format=json. Codes are stable across
+// releases; messages and hints are for people and may change.
+const (
+	ErrorUsage              = "usage"               // invalid flags, arguments or flag values
+	ErrorConfig             = "config"              // the config file or a profile cannot be loaded
+	ErrorRepository         = "repository"          // the repository cannot be opened
+	ErrorGit                = "git"                 // reading or updating the repository failed
+	ErrorRepositoryModified = "repository-modified" // --read-only found a change under .git
+	ErrorNoTags             = "no-tags"             // no tag is reachable and --on-no-tags=error
+	ErrorTagDistance        = "tag-distance"        // no tag within --max-tag-distance and --on-tag-distance=error
+	ErrorOrphanBranch       = "orphan-branch"       // the branch shares no history with main/master and --on-orphan=error
+	ErrorStaleTag           = "stale-tag"           // the last tag is older than --max-age and --on-max-age=error
+	ErrorTimeout            = "timeout"             // generation exceeded --timeout and --on-timeout=error
+	ErrorTagExists          = "tag-exists"          // the Docker tag is already published for --docker-image
+	ErrorRegistry           = "registry"            // a package registry cannot be queried
+	ErrorPolicy             = "policy"              // the release policy cannot be loaded or rejects the tag
+	ErrorOutput             = "output"              // an output file cannot be written, diffed or restored
+	ErrorBreakingChange     = "breaking-change"     // breaking finds breaking changes on a branch that forbids them
+	ErrorUnconventional     = "unconventional"      // commits since the last tag break Conventional Commits with --require-conventional
+	ErrorLocked             = "locked"              // another process held a git lock for longer than --lock-timeout
+	ErrorOffline            = "offline"             // a requested operation needs the network and --offline is set
+	ErrorPinMismatch        = "pin-mismatch"        // HEAD is not the commit recorded by the --from-pin lockfile
+	ErrorStaleOutput        = "stale-output"        // validate found a selected output file missing or out of date
+	ErrorProjectFailed      = "project-failed"      // --recursive could not version one or more projects
+	ErrorInternal           = "internal"            // an unexpected failure, such as encoding the output
+)
+
+// errorHints suggest a way out for the error codes that have one
+var errorHints = map[string]string{
+	ErrorUsage:          "run version-generator --help for the available flags",
+	ErrorRepository:     "run inside a git repository, or use --in-built-git when git is not installed",
+	ErrorNoTags:         "create a release tag, or use --on-no-tags=zero or --initial-version",
+	ErrorTagDistance:    "raise --max-tag-distance, or use --on-tag-distance=zero",
+	ErrorOrphanBranch:   "use --on-orphan=own-tags or --on-orphan=calver",
+	ErrorStaleTag:       "tag a release, raise --max-age, or use --on-max-age=warn",
+	ErrorTimeout:        "raise --timeout, or use --on-timeout=hash for a hash-only version",
+	ErrorTagExists:      "commit or tag before building again, so the version changes",
+	ErrorRegistry:       "check the registry URL, network access and credentials",
+	ErrorPolicy:         "use tag-release --policy-output=json for the violated rules",
+	ErrorUnconventional: "reword the commits as type(scope): description, or use --on-unconventional=unverified",
+	ErrorBreakingChange: "revert the breaking change, or release it from a branch not listed in forbid_breaking",
+	ErrorLocked:         "wait for the other git process to finish, raise --lock-timeout, or remove the lock file if no git process is running",
+	ErrorOffline:        "drop --offline, or leave out the operation that needs the network",
+	ErrorPinMismatch:    "check out the commit recorded in the lockfile before rebuilding from it",
+	ErrorStaleOutput:    "run the generator with the same flags to rewrite the output files",
+	ErrorProjectFailed:  "see the error of each failed project in the summary",
+}
+
+// errorCodes classify the errors returned by the git handlers and versiongen
+var errorCodes = map[error]string{
+	gittype.ErrNoTags:              ErrorNoTags,
+	gittype.ErrTagDistanceExceeded: ErrorTagDistance,
+	gittype.ErrOrphanBranch:        ErrorOrphanBranch,
+	gittype.ErrTimeout:             ErrorTimeout,
+	gittype.ErrLocked:              ErrorLocked,
+	versiongen.ErrInvalidOptions:   ErrorUsage,
+}
+
+// cliError is a failure as reported on stderr with --output-format=json
+type cliError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Hint    string `json:"hint,omitempty"`
+}
+
+// codedError attaches an error code to an error returned further down
+type codedError struct {
+	code string
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// withCode classifies err, keeping its message
+func withCode(code string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &codedError{code: code, err: err}
+}
+
+// jsonErrors is set once the command line asks for --output-format=json
+var jsonErrors bool
+
+// fatalf reports a failure and exits. The code classifies it for
+// --output-format=json unless an error argument carries a more specific one.
+func fatalf(code, format string, args ...any) {
+	if !jsonErrors {
+		log.Fatalf(format, args...)
+	}
+	for _, arg := range args {
+		if err, ok := arg.(error); ok {
+			code = errorCode(err, code)
+		}
+	}
+	report := cliError{Code: code, Message: fmt.Sprintf(format, args...), Hint: errorHints[code]}
+	encoded, err := json.Marshal(report)
+	if err != nil {
+		log.Fatalf(format, args...)
+	}
+	fmt.Fprintln(os.Stderr, string(encoded))
+	os.Exit(1)
+}
+
+// printWarning reports a warning on stderr, as JSON with --output-format=json
+func printWarning(warning gittype.Warning) {
+	if !jsonErrors {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning.Message)
+		return
+	}
+	encoded, err := json.Marshal(warning)
+	if err != nil {
+		fatalf(ErrorInternal, "Failed to encode warning: %v", err)
+	}
+	fmt.Fprintln(os.Stderr, string(encoded))
+}
+
+// errorCode returns the code of err, or fallback when it is not classified
+func errorCode(err error, fallback string) string {
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	for sentinel, code := range errorCodes {
+		if errors.Is(err, sentinel) {
+			return code
+		}
+	}
+	return fallback
+}
+
+// parseCommandLine parses the arguments like kong.Parse, but reports usage
+// errors as JSON when --output-format=json is requested
+func parseCommandLine(cli *CLI, options ...kong.Option) *kong.Context {
+	parser, err := kong.New(cli, options...)
+	if err != nil {
+		panic(err)
+	}
+	ctx, err := parser.Parse(os.Args[1:])
+	if err == nil {
+		err = useCommandFlags(cli, ctx)
+	}
+	if err != nil && (jsonErrors || jsonRequested(os.Args[1:])) {
+		jsonErrors = true
+		fatalf(ErrorUsage, "%v", err)
+	}
+	parser.FatalIfErrorf(err)
+	jsonErrors = cli.OutputFormat == "json"
+	return ctx
+}
+
+// jsonRequested looks for --output-format=json in arguments that failed to parse
+func jsonRequested(args []string) bool {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if arg == "--output-format=json" || (arg == "--output-format" && i+1 < len(args) && args[i+1] == "json") {
+			return true
+		}
+	}
+	return strings.TrimSpace(os.Getenv("VG_OUTPUT_FORMAT")) == "json"
+}
@@ -0,0 +1,160 @@
+package filetype
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WriteToArchive adds a file named member with data to an existing .zip, .tar,
+// .tar.gz or .tgz archive, replacing a member of the same name. Other members
+// are copied unchanged, and the archive is replaced only once it was rewritten.
+func WriteToArchive(archivePath, member string, data []byte, modTime time.Time) error {
+	member = path.Clean(strings.TrimPrefix(filepath.ToSlash(member), "/"))
+	if member == "." || member == ".." || strings.HasPrefix(member, "../") {
+		return fmt.Errorf("invalid archive member %q", member)
+	}
+
+	var write func(dst io.Writer, src *os.File) error
+	switch name := strings.ToLower(archivePath); {
+	case strings.HasSuffix(name, ".zip"):
+		write = func(dst io.Writer, src *os.File) error {
+			return rewriteZip(dst, src, member, data, modTime)
+		}
+	case strings.HasSuffix(name, ".tar"):
+		write = func(dst io.Writer, src *os.File) error {
+			return rewriteTar(dst, src, member, data, modTime)
+		}
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		write = func(dst io.Writer, src *os.File) error {
+			return rewriteTarGz(dst, src, member, data, modTime)
+		}
+	default:
+		return fmt.Errorf("unsupported archive %s: use .zip, .tar, .tar.gz or .tgz", archivePath)
+	}
+
+	src, err := os.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(archivePath), "."+filepath.Base(archivePath)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	err = write(temp, src)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to rewrite %s: %w", archivePath, err)
+	}
+	if err := os.Chmod(temp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), archivePath)
+}
+
+// rewriteZip copies a zip archive without recompressing it, replacing member
+func rewriteZip(dst io.Writer, src *os.File, member string, data []byte, modTime time.Time) error {
+	info, err := src.Stat()
+	if err != nil {
+		return err
+	}
+	reader, err := zip.NewReader(src, info.Size())
+	if err != nil {
+		return err
+	}
+	writer := zip.NewWriter(dst)
+	for _, file := range reader.File {
+		if path.Clean(file.Name) == member {
+			continue
+		}
+		if err := writer.Copy(file); err != nil {
+			return err
+		}
+	}
+	header := &zip.FileHeader{Name: member, Method: zip.Deflate, Modified: modTime}
+	header.SetMode(0644)
+	file, err := writer.CreateHeader(header)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	if reader.Comment != "" {
+		if err := writer.SetComment(reader.Comment); err != nil {
+			return err
+		}
+	}
+	return writer.Close()
+}
+
+// rewriteTarGz decompresses, rewrites and recompresses a gzipped tar archive
+func rewriteTarGz(dst io.Writer, src *os.File, member string, data []byte, modTime time.Time) error {
+	gzipReader, err := gzip.NewReader(src)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+	gzipWriter := gzip.NewWriter(dst)
+	gzipWriter.Header = gzipReader.Header
+	if err := rewriteTar(gzipWriter, gzipReader, member, data, modTime); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
+
+// rewriteTar copies a tar archive entry by entry, replacing member
+func rewriteTar(dst io.Writer, src io.Reader, member string, data []byte, modTime time.Time) error {
+	reader := tar.NewReader(src)
+	writer := tar.NewWriter(dst)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if path.Clean(header.Name) == member {
+			continue
+		}
+		if err := writer.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.Copy(writer, reader); err != nil {
+			return err
+		}
+	}
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     member,
+		Mode:     0644,
+		Size:     int64(len(data)),
+		ModTime:  modTime,
+		Format:   tar.FormatPAX,
+	}
+	if err := writer.WriteHeader(header); err != nil {
+		return err
+	}
+	if _, err := io.Copy(writer, bytes.NewReader(data)); err != nil {
+		return err
+	}
+	return writer.Close()
+}
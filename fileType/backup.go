@@ -0,0 +1,42 @@
+package filetype
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// DefaultBackupSuffix is appended to an output file path to name its backup copy
+const DefaultBackupSuffix = ".bak"
+
+// Backup copies an existing file to filePath+suffix before it is overwritten.
+// Missing files and files whose content matches the backup are left alone.
+func Backup(filePath, suffix string) error {
+	if suffix == "" {
+		suffix = DefaultBackupSuffix
+	}
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existing, err := os.ReadFile(filePath + suffix); err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+	return os.WriteFile(filePath+suffix, data, 0644)
+}
+
+// Restore moves the backup copy of filePath back into place
+func Restore(filePath, suffix string) error {
+	if suffix == "" {
+		suffix = DefaultBackupSuffix
+	}
+	backupPath := filePath + suffix
+	if _, err := os.Stat(backupPath); err != nil {
+		return fmt.Errorf("no backup found at %s: %w", backupPath, err)
+	}
+	return os.Rename(backupPath, filePath)
+}
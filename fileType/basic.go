@@ -1,18 +1,95 @@
 package filetype
 
 import (
-	"os"
-	"path/filepath"
+	"fmt"
+	"strconv"
+	"strings"
+	gittype "version-generator/gitType"
+)
+
+// Basic file formats
+const (
+	BasicFormatBare     = "bare"     // version string only
+	BasicFormatKeyValue = "keyvalue" // KEY=value lines
+	BasicFormatLayout   = "layout"   // single line rendered from Layout
 )
 
 type BasicFile struct {
+	// Format selects the file shape (default: bare)
+	Format string
+	// Layout is a printf-style line used by the layout format, with directives
+	// %v version, %t tag, %c commits since tag, %h short hash, %H full hash,
+	// %b branch, %a variant, %u source URL and %% for a literal percent sign
+	Layout string
 }
 
-func (b *BasicFile) WriteVersion(filePath string, version string) error {
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
-		return err
+func (b *BasicFile) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	var data string
+	switch b.Format {
+	case "", BasicFormatBare:
+		data = info.Version + "\n"
+	case BasicFormatKeyValue:
+		data = fmt.Sprintf("VERSION=%s\nTAG=%s\nCOMMITS_SINCE=%d\nGIT_COMMIT=%s\nBRANCH=%s\n",
+			info.Version, info.LastTag, info.CommitsSince, info.ShortHash, info.Branch)
+		if info.Variant != "" {
+			data += "VARIANT=" + info.Variant + "\n"
+		}
+		if info.Channel != "" {
+			data += "CHANNEL=" + info.Channel + "\n"
+		}
+		if info.URL != "" {
+			data += "URL=" + info.URL + "\n"
+		}
+	case BasicFormatLayout:
+		line, err := RenderLayout(b.Layout, info)
+		if err != nil {
+			return nil, err
+		}
+		data = line + "\n"
+	default:
+		return nil, fmt.Errorf("unknown basic file format %q", b.Format)
+	}
+	return []byte(data), nil
+}
+
+// RenderLayout expands the percent directives of a layout string
+func RenderLayout(layout string, info *gittype.VersionInfo) (string, error) {
+	if layout == "" {
+		return "", fmt.Errorf("layout format requires a layout string")
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' {
+			sb.WriteByte(layout[i])
+			continue
+		}
+		i++
+		if i == len(layout) {
+			return "", fmt.Errorf("layout %q ends with a dangling %%", layout)
+		}
+		switch layout[i] {
+		case 'v':
+			sb.WriteString(info.Version)
+		case 't':
+			sb.WriteString(info.LastTag)
+		case 'c':
+			sb.WriteString(strconv.Itoa(info.CommitsSince))
+		case 'h':
+			sb.WriteString(info.ShortHash)
+		case 'H':
+			sb.WriteString(info.Commit)
+		case 'b':
+			sb.WriteString(info.Branch)
+		case 'a':
+			sb.WriteString(info.Variant)
+		case 'u':
+			sb.WriteString(info.URL)
+		case '%':
+			sb.WriteByte('%')
+		default:
+			return "", fmt.Errorf("unknown layout directive %%%c", layout[i])
+		}
 	}
-	// Write file (this will overwrite existing file)
-	return os.WriteFile(filePath, []byte(version+"\n"), 0644)
+	return sb.String(), nil
 }
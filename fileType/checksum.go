@@ -0,0 +1,57 @@
+package filetype
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Checksum algorithms for WriteChecksums
+const (
+	ChecksumSHA256 = "sha256"
+	ChecksumSHA512 = "sha512"
+)
+
+// WriteChecksums writes a <file>.<algorithm> sidecar next to each file and a
+// combined <ALGORITHM>SUMS file in sumsDir, both in the format of sha256sum, so
+// they can be checked with sha256sum -c (or sha512sum -c). Paths in the
+// combined file are relative to sumsDir. It returns the path of the combined file.
+func WriteChecksums(algorithm string, paths []string, sumsDir string) (string, error) {
+	var newHash func() hash.Hash
+	switch algorithm {
+	case ChecksumSHA256:
+		newHash = sha256.New
+	case ChecksumSHA512:
+		newHash = sha512.New
+	default:
+		return "", fmt.Errorf("unknown checksum algorithm %q: use sha256 or sha512", algorithm)
+	}
+
+	var sums strings.Builder
+	for _, filePath := range paths {
+		data, err := os.ReadFile(filePath)
+		if err != nil {
+			return "", err
+		}
+		digest := newHash()
+		digest.Write(data)
+		sum := hex.EncodeToString(digest.Sum(nil))
+
+		sidecar := fmt.Sprintf("%s  %s\n", sum, filepath.Base(filePath))
+		if err := os.WriteFile(filePath+"."+algorithm, []byte(sidecar), 0644); err != nil {
+			return "", err
+		}
+		relPath, err := filepath.Rel(sumsDir, filePath)
+		if err != nil {
+			relPath = filePath
+		}
+		fmt.Fprintf(&sums, "%s  %s\n", sum, filepath.ToSlash(relPath))
+	}
+	sumsPath := filepath.Join(sumsDir, strings.ToUpper(algorithm)+"SUMS")
+	return sumsPath, os.WriteFile(sumsPath, []byte(sums.String()), 0644)
+}
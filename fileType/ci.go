@@ -0,0 +1,89 @@
+package filetype
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	gittype "version-generator/gitType"
+)
+
+// TeamCityType renders TeamCity service messages that set the build number and
+// export the version as env.VERSION* build parameters. They take effect when
+// printed on the standard output of a build step.
+type TeamCityType struct {
+}
+
+func (t *TeamCityType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	data := fmt.Sprintf("##teamcity[buildNumber '%s']\n", teamCityEscape(info.Version))
+	for _, variable := range ciVariables(info) {
+		data += fmt.Sprintf("##teamcity[setParameter name='env.%s' value='%s']\n", variable.name, teamCityEscape(variable.value))
+	}
+	return []byte(data), nil
+}
+
+// JenkinsType writes a Java properties file with the VERSION* variables, as
+// read by readProperties, the EnvInject plugin and the build name setter
+type JenkinsType struct {
+}
+
+func (j *JenkinsType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	var data string
+	for _, variable := range ciVariables(info) {
+		data += fmt.Sprintf("%s=%s\n", variable.name, propertiesEscape(variable.value))
+	}
+	return []byte(data), nil
+}
+
+func (j *JenkinsType) CommentPrefix() string {
+	return "#"
+}
+
+// ciVariable is a variable exported to a CI system
+type ciVariable struct {
+	name  string
+	value string
+}
+
+// ciVariables lists the variables exported to CI systems, prefixed with
+// VERSION so they do not replace the GIT_COMMIT and BRANCH the CI already sets
+func ciVariables(info *gittype.VersionInfo) []ciVariable {
+	variables := []ciVariable{
+		{"VERSION", info.Version},
+		{"VERSION_TAG", info.LastTag},
+		{"VERSION_COMMITS_SINCE", strconv.Itoa(info.CommitsSince)},
+		{"VERSION_COMMIT", info.ShortHash},
+		{"VERSION_BRANCH", info.Branch},
+	}
+	if info.Variant != "" {
+		variables = append(variables, ciVariable{"VERSION_VARIANT", info.Variant})
+	}
+	if info.Channel != "" {
+		variables = append(variables, ciVariable{"VERSION_CHANNEL", info.Channel})
+	}
+	return variables
+}
+
+// teamCityEscape escapes a service message attribute value
+var teamCityEscape = strings.NewReplacer("|", "||", "'", "|'", "[", "|[", "]", "|]", "\n", "|n", "\r", "|r").Replace
+
+// propertiesEscape escapes a Java properties value, writing control and
+// non-ASCII characters as \uXXXX since properties files are read as ISO-8859-1
+func propertiesEscape(s string) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			sb.WriteString(`\\`)
+		case r == ' ' && i == 0:
+			sb.WriteString(`\ `)
+		case r < 0x20 || r > 0x7e:
+			for _, unit := range utf16.Encode([]rune{r}) {
+				fmt.Fprintf(&sb, `\u%04x`, unit)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
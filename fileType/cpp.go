@@ -1,19 +1,21 @@
 package filetype
 
-import (
-	"os"
-	"path/filepath"
-)
+import gittype "version-generator/gitType"
 
 type CPPType struct {
 }
 
-func (c *CPPType) WriteVersion(filePath string, version string) error {
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
-		return err
+func (c *CPPType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	data := "#define VERSION \"" + info.Version + "\"\n"
+	if info.Variant != "" {
+		data += "#define VERSION_VARIANT \"" + info.Variant + "\"\n"
 	}
-	// Write file (this will overwrite existing file)
-	data := "#define VERSION \"" + version + "\"\n"
-	return os.WriteFile(filePath, []byte(data), 0644)
+	if info.Channel != "" {
+		data += "#define VERSION_CHANNEL \"" + info.Channel + "\"\n"
+	}
+	return []byte(data), nil
+}
+
+func (c *CPPType) CommentPrefix() string {
+	return "//"
 }
@@ -0,0 +1,94 @@
+package filetype
+
+import (
+	"encoding/json"
+	"errors"
+	"path"
+	gittype "version-generator/gitType"
+)
+
+// CycloneDXSpecVersion is the CycloneDX specification the fragment follows
+const CycloneDXSpecVersion = "1.5"
+
+// CycloneDXType writes a CycloneDX BOM holding only metadata.component, for
+// SBOM pipelines to merge into the BOM of the build
+type CycloneDXType struct {
+	Name string // Component name (default: the repository name of info.Repository)
+}
+
+// cycloneDXBOM is the part of a CycloneDX BOM written by CycloneDXType
+type cycloneDXBOM struct {
+	BOMFormat   string `json:"bomFormat"`
+	SpecVersion string `json:"specVersion"`
+	Version     int    `json:"version"`
+	Metadata    struct {
+		Component cycloneDXComponent `json:"component"`
+	} `json:"metadata"`
+}
+
+// cycloneDXComponent is the component the version describes
+type cycloneDXComponent struct {
+	Type               string               `json:"type"`
+	BOMRef             string               `json:"bom-ref"`
+	Name               string               `json:"name"`
+	Version            string               `json:"version"`
+	ExternalReferences []cycloneDXReference `json:"externalReferences,omitempty"`
+	Pedigree           *cycloneDXPedigree   `json:"pedigree,omitempty"`
+	Properties         []cycloneDXProperty  `json:"properties,omitempty"`
+}
+
+type cycloneDXReference struct {
+	Type string `json:"type"`
+	URL  string `json:"url"`
+}
+
+type cycloneDXPedigree struct {
+	Commits []cycloneDXCommit `json:"commits"`
+}
+
+type cycloneDXCommit struct {
+	UID string `json:"uid"`
+	URL string `json:"url,omitempty"`
+}
+
+type cycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (c *CycloneDXType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	name := c.Name
+	if name == "" && info.Repository != "" {
+		name = path.Base(info.Repository)
+	}
+	if name == "" {
+		return nil, errors.New("the CycloneDX component needs a name: set --cyclonedx-name or an origin remote URL")
+	}
+
+	component := cycloneDXComponent{
+		Type:    "application",
+		BOMRef:  name + "@" + info.Version,
+		Name:    name,
+		Version: info.Version,
+	}
+	if info.Repository != "" {
+		component.ExternalReferences = []cycloneDXReference{{Type: "vcs", URL: info.Repository}}
+	}
+	if info.Commit != "" {
+		component.Pedigree = &cycloneDXPedigree{Commits: []cycloneDXCommit{{UID: info.Commit, URL: info.URL}}}
+	}
+	if info.Branch != "" {
+		component.Properties = append(component.Properties, cycloneDXProperty{Name: "version-generator:branch", Value: info.Branch})
+	}
+	if info.LastTag != "" {
+		component.Properties = append(component.Properties, cycloneDXProperty{Name: "version-generator:tag", Value: info.LastTag})
+	}
+
+	bom := cycloneDXBOM{BOMFormat: "CycloneDX", SpecVersion: CycloneDXSpecVersion, Version: 1}
+	bom.Metadata.Component = component
+	out, err := json.MarshalIndent(bom, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
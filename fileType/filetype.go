@@ -1,5 +1,81 @@
 package filetype
 
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	gittype "version-generator/gitType"
+)
+
 type FileType interface {
-	WriteVersion(filePath string, version string) error
+	// Render returns the content of the version file. filePath is the destination,
+	// which writers that update an existing file read from.
+	Render(filePath string, info *gittype.VersionInfo) ([]byte, error)
+}
+
+// Line ending choices for WriteOptions
+const (
+	LineEndingLF   = "lf"
+	LineEndingCRLF = "crlf"
+)
+
+// WriteOptions controls how rendered content is encoded on disk
+type WriteOptions struct {
+	NoTrailingNewline bool   // Strip the final newline
+	BOM               bool   // Prepend a UTF-8 byte order mark
+	LineEnding        string // lf (default) or crlf
+	// Header lines are written as a comment block below GeneratedMarker for
+	// file types implementing Commenter
+	Header []string
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// WriteFile renders the file type and writes it to filePath, creating
+// directories as needed and overwriting any existing file
+func WriteFile(fileType FileType, filePath string, info *gittype.VersionInfo, options WriteOptions) error {
+	data, err := Prepare(fileType, filePath, info, options)
+	if err != nil {
+		return err
+	}
+
+	// Ensure directory exists
+	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, data, 0644)
+}
+
+// Prepare returns the exact bytes WriteFile would write to filePath
+func Prepare(fileType FileType, filePath string, info *gittype.VersionInfo, options WriteOptions) ([]byte, error) {
+	data, err := fileType.Render(filePath, info)
+	if err != nil {
+		return nil, err
+	}
+	if commenter, ok := fileType.(Commenter); ok && len(options.Header) > 0 {
+		data = addHeader(data, commenter.CommentPrefix(), options.Header)
+	}
+	return Encode(data, options)
+}
+
+// Encode applies newline and encoding options to rendered content
+func Encode(data []byte, options WriteOptions) ([]byte, error) {
+	if options.NoTrailingNewline {
+		data = bytes.TrimRight(data, "\n")
+	}
+
+	switch options.LineEnding {
+	case "", LineEndingLF:
+	case LineEndingCRLF:
+		data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+		data = bytes.ReplaceAll(data, []byte("\n"), []byte("\r\n"))
+	default:
+		return nil, fmt.Errorf("unknown line ending %q", options.LineEnding)
+	}
+
+	if options.BOM && !bytes.HasPrefix(data, utf8BOM) {
+		data = append(append([]byte{}, utf8BOM...), data...)
+	}
+	return data, nil
 }
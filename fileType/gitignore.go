@@ -0,0 +1,40 @@
+package filetype
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Gitignore policies for generated files
+const (
+	GitignoreNone   = "none"   // leave .gitignore alone
+	GitignoreIgnore = "ignore" // ensure the file is listed in .gitignore
+	GitignoreTrack  = "track"  // assert the file is committed
+)
+
+// AppendGitignore adds an anchored entry for relPath to the .gitignore at repoRoot
+func AppendGitignore(repoRoot, relPath string) error {
+	gitignorePath := filepath.Join(repoRoot, ".gitignore")
+	existing, err := os.ReadFile(gitignorePath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var sb strings.Builder
+	if len(existing) > 0 && !strings.HasSuffix(string(existing), "\n") {
+		sb.WriteString("\n")
+	}
+	sb.WriteString("/" + relPath + "\n")
+
+	f, err := os.OpenFile(gitignorePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(sb.String()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
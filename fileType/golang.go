@@ -1,19 +1,138 @@
 package filetype
 
 import (
-	"os"
-	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	gittype "version-generator/gitType"
+)
+
+// Go file formats
+const (
+	GoFormatConst = "const" // Version constant, plus Variant and Channel when set
+	GoFormatMap   = "map"   // Version constant, BuildInfo map and Build accessor struct
 )
 
 type GoType struct {
+	Package string // Package clause of the generated file (default: main)
+	Format  string // File shape (default: const)
+}
+
+func (g *GoType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	pkg := g.Package
+	if pkg == "" {
+		pkg = "main"
+	}
+	data := "package " + pkg + "\n\nconst Version = \"" + info.Version + "\"\n"
+	if g.Format == GoFormatMap {
+		return []byte(data + goBuildInfo(info)), nil
+	}
+	if info.Variant != "" {
+		data += "\nconst Variant = \"" + info.Variant + "\"\n"
+	}
+	if info.Channel != "" {
+		data += "\nconst Channel = \"" + info.Channel + "\"\n"
+	}
+	return []byte(data), nil
 }
 
-func (g *GoType) WriteVersion(filePath string, version string) error {
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
-		return err
+// goBuildInfo renders the BuildInfo map and its Build accessor struct. Every
+// key is present, with an empty value when unset, so lookups need no ok check.
+func goBuildInfo(info *gittype.VersionInfo) string {
+	date := ""
+	if !info.CommitDate.IsZero() {
+		date = info.CommitDate.UTC().Format(time.RFC3339)
+	}
+	entries := []struct{ key, field, value string }{
+		{"version", "Version", "Version"},
+		{"commit", "Commit", strconv.Quote(info.Commit)},
+		{"branch", "Branch", strconv.Quote(info.Branch)},
+		{"date", "Date", strconv.Quote(date)},
+		{"channel", "Channel", strconv.Quote(info.Channel)},
+		{"variant", "Variant", strconv.Quote(info.Variant)},
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n// BuildInfo describes this build: version, commit, branch, commit date (RFC 3339), channel and variant\n")
+	sb.WriteString("var BuildInfo = map[string]string{\n")
+	for _, entry := range entries {
+		sb.WriteString("\t" + goMapEntry(entry.key, entry.value))
 	}
-	// Write file (this will overwrite existing file)
-	data := "package main\n\nconst Version = \"" + version + "\"\n"
-	return os.WriteFile(filePath, []byte(data), 0644)
+	sb.WriteString("}\n")
+
+	sb.WriteString("\n// Build is BuildInfo as a struct, e.g. to encode in a /healthz response\n")
+	sb.WriteString("type Build struct {\n")
+	for _, entry := range entries {
+		sb.WriteString("\t" + entry.field + strings.Repeat(" ", len("Version")-len(entry.field)+1) + "string `json:\"" + entry.key + "\"`\n")
+	}
+	sb.WriteString("}\n")
+
+	sb.WriteString("\n// GetBuild returns BuildInfo as a Build\n")
+	sb.WriteString("func GetBuild() Build {\n\treturn Build{\n")
+	for _, entry := range entries {
+		field := entry.field + ":"
+		sb.WriteString("\t\t" + field + strings.Repeat(" ", len("Version:")-len(field)+1) + "BuildInfo[\"" + entry.key + "\"],\n")
+	}
+	sb.WriteString("\t}\n}\n")
+	return sb.String()
+}
+
+// goMapEntry renders one "key": value line of a map literal, aligned as gofmt
+// aligns keys no longer than "version"
+func goMapEntry(key, value string) string {
+	quoted := strconv.Quote(key) + ":"
+	return quoted + strings.Repeat(" ", max(len(`"version":`)-len(quoted), 0)+1) + value + ",\n"
+}
+
+func (g *GoType) CommentPrefix() string {
+	return "//"
+}
+
+// GoExpvarType writes a Go file that publishes the build info as the expvar
+// "build", so services importing expvar serve it on /debug/vars
+type GoExpvarType struct {
+	Package string // Package clause of the generated file (default: main)
+}
+
+func (g *GoExpvarType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	pkg := g.Package
+	if pkg == "" {
+		pkg = "main"
+	}
+	var sb strings.Builder
+	sb.WriteString("package " + pkg + "\n\nimport (\n\t\"expvar\"\n\t\"runtime/debug\"\n)\n\n")
+	sb.WriteString("// init publishes the build info as the expvar \"build\". The Go version, main\n")
+	sb.WriteString("// module and VCS settings recorded by debug.ReadBuildInfo are added when the\n")
+	sb.WriteString("// binary carries them; a vcs_revision other than commit means this file is stale.\n")
+	sb.WriteString("func init() {\n\texpvar.Publish(\"build\", expvar.Func(func() any {\n")
+	sb.WriteString("\t\tbuild := map[string]string{\n")
+	sb.WriteString("\t\t\t" + goMapEntry("version", strconv.Quote(info.Version)))
+	sb.WriteString("\t\t\t" + goMapEntry("commit", strconv.Quote(info.Commit)))
+	sb.WriteString("\t\t\t" + goMapEntry("branch", strconv.Quote(info.Branch)))
+	sb.WriteString("\t\t\t" + goMapEntry("channel", strconv.Quote(info.Channel)))
+	sb.WriteString("\t\t\t" + goMapEntry("variant", strconv.Quote(info.Variant)))
+	sb.WriteString("\t\t}\n")
+	sb.WriteString(`		if buildInfo, ok := debug.ReadBuildInfo(); ok {
+			build["go_version"] = buildInfo.GoVersion
+			build["module"] = buildInfo.Main.Path
+			for _, setting := range buildInfo.Settings {
+				switch setting.Key {
+				case "vcs.revision":
+					build["vcs_revision"] = setting.Value
+				case "vcs.time":
+					build["vcs_time"] = setting.Value
+				case "vcs.modified":
+					build["vcs_modified"] = setting.Value
+				}
+			}
+		}
+		return build
+	}))
+}
+`)
+	return []byte(sb.String()), nil
+}
+
+func (g *GoExpvarType) CommentPrefix() string {
+	return "//"
 }
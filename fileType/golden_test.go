@@ -0,0 +1,164 @@
+package filetype
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+)
+
+// update rewrites the golden files instead of comparing against them
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// goldenVersions are representative repository states every writer is rendered with
+var goldenVersions = []struct {
+	name string
+	info gittype.VersionInfo
+}{
+	{"release", gittype.VersionInfo{
+		Branch: "main", LastTag: "v1.2.3", ShortHash: "abc1234",
+		Commit: "abc1234def5678901234567890abcdef12345678", Version: "v1.2.3",
+		CommitDate: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC),
+	}},
+	{"prerelease", gittype.VersionInfo{
+		Branch: "main", LastTag: "v2.0.0-rc.1", CommitsSince: 4, ShortHash: "0fedcba",
+		Commit: "0fedcba9876543210fedcba9876543210fedcba9", Version: "v2.0.0-rc.1+4",
+	}},
+	{"metadata", gittype.VersionInfo{
+		Branch: "main", LastTag: "v1.2.3", CommitsSince: 5, ShortHash: "abc1234",
+		Commit: "abc1234def5678901234567890abcdef12345678", Version: "v1.2.3+5.debug.run.42.builder.ci-linux",
+		Variant:  "debug",
+		Metadata: []versionSchemes.BuildMetadata{{Key: "run", Value: "42"}, {Key: "builder", Value: "ci/linux"}},
+		Warnings: []gittype.Warning{{Code: gittype.WarningShallowClone, Message: "repository is a shallow clone; the commit count may be too low"}},
+	}},
+	{"dirty", gittype.VersionInfo{
+		Branch: "main", LastTag: "v1.2.3", CommitsSince: 5, ShortHash: "abc1234",
+		Commit: "abc1234def5678901234567890abcdef12345678", Version: "v1.2.3-5-gabc1234-dirty",
+	}},
+	{"channel", gittype.VersionInfo{
+		Branch: "main", LastTag: "v1.2.3", CommitsSince: 5, ShortHash: "abc1234",
+		Commit: "abc1234def5678901234567890abcdef12345678", Version: "v1.2.3-beta.5", Channel: "beta",
+	}},
+	{"shortlog", gittype.VersionInfo{
+		Branch: "main", LastTag: "v1.2.3", CommitsSince: 2, ShortHash: "abc1234",
+		Commit: "abc1234def5678901234567890abcdef12345678", Version: "v1.2.3+2",
+		Shortlog: []gittype.ShortlogEntry{
+			{Hash: "abc1234", Author: "Ada Lovelace", Subject: "fix: handle empty tags"},
+			{Hash: "9876fed", Author: "Grace Hopper", Subject: "feat: add 'quoted' subject"},
+		},
+	}},
+	{"url", gittype.VersionInfo{
+		Branch: "main", LastTag: "v1.2.3", CommitsSince: 2, ShortHash: "abc1234",
+		Commit: "abc1234def5678901234567890abcdef12345678", Version: "v1.2.3+2",
+		URL:        "https://github.com/acme/app/commit/abc1234def5678901234567890abcdef12345678",
+		Repository: "https://github.com/acme/app",
+	}},
+	{"generator", gittype.VersionInfo{
+		Branch: "main", LastTag: "v1.2.3", CommitsSince: 2, ShortHash: "abc1234",
+		Commit: "abc1234def5678901234567890abcdef12345678", Version: "v1.2.3+2",
+		Generator: &gittype.GeneratorInfo{Version: "v2.4.0", Commit: "0a1b2c3", Options: "sha256:3f1c0a9e5b7d2c41"},
+	}},
+	{"unicode-branch", gittype.VersionInfo{
+		Branch: "fix/ünïcödé-brånch", LastTag: "v1.2.3", CommitsSince: 2, ShortHash: "abc1234",
+		Commit: "abc1234def5678901234567890abcdef12345678", Version: "v1.2.3-fix--n-c-d--br-nch+2",
+	}},
+}
+
+// goldenWriters covers every file type and the options that change its shape
+var goldenWriters = []struct {
+	name     string
+	fileType FileType
+	input    string // Existing file read by writers that update one
+}{
+	{"basic", &BasicFile{}, ""},
+	{"basic-keyvalue", &BasicFile{Format: BasicFormatKeyValue}, ""},
+	{"basic-layout", &BasicFile{Format: BasicFormatLayout, Layout: "%v %t %c %h %H %b %a %%"}, ""},
+	{"go", &GoType{}, ""},
+	{"go-package", &GoType{Package: "version"}, ""},
+	{"go-map", &GoType{Package: "version", Format: GoFormatMap}, ""},
+	{"go-expvar", &GoExpvarType{}, ""},
+	{"cpp", &CPPType{}, ""},
+	{"yaml", &YAMLFile{}, ""},
+	{"yaml-key", &YAMLFile{Key: "app.build.version"}, ""},
+	{"yaml-merge", &YAMLFile{Merge: true, Key: "app.version"}, "merge.yaml"},
+	{"tfvars", &TerraformType{}, ""},
+	{"packer", &PackerType{}, ""},
+	{"json", &JSONType{}, ""},
+	{"cyclonedx", &CycloneDXType{Name: "app"}, ""},
+	{"nix", &NixType{}, ""},
+	{"shell", &ShellType{}, ""},
+	{"powershell", &PowerShellType{}, ""},
+	{"ini", &INIType{}, ""},
+	{"rc", &RCType{}, ""},
+	{"teamcity", &TeamCityType{}, ""},
+	{"jenkins", &JenkinsType{}, ""},
+}
+
+// goldenOptions are the encoding options, each rendered with the release version
+var goldenOptions = []struct {
+	name    string
+	options WriteOptions
+}{
+	{"no-newline", WriteOptions{NoTrailingNewline: true}},
+	{"crlf-bom", WriteOptions{LineEnding: LineEndingCRLF, BOM: true}},
+	{"header", WriteOptions{Header: []string{"command: version-generator --scheme semver", "commit: abc1234def5678901234567890abcdef12345678"}}},
+}
+
+func TestGolden(t *testing.T) {
+	for _, writer := range goldenWriters {
+		filePath := filepath.Join("testdata", "golden", "input", "missing")
+		if writer.input != "" {
+			filePath = filepath.Join("testdata", "golden", "input", writer.input)
+		}
+
+		for _, version := range goldenVersions {
+			t.Run(writer.name+"/"+version.name, func(t *testing.T) {
+				info := version.info
+				got, err := Prepare(writer.fileType, filePath, &info, WriteOptions{})
+				if err != nil {
+					t.Fatalf("Prepare: %v", err)
+				}
+				checkGolden(t, filepath.Join(writer.name, version.name+".golden"), got)
+			})
+		}
+
+		for _, option := range goldenOptions {
+			t.Run(writer.name+"/"+option.name, func(t *testing.T) {
+				info := goldenVersions[0].info
+				got, err := Prepare(writer.fileType, filePath, &info, option.options)
+				if err != nil {
+					t.Fatalf("Prepare: %v", err)
+				}
+				checkGolden(t, filepath.Join(writer.name, option.name+".golden"), got)
+			})
+		}
+	}
+}
+
+// checkGolden compares output with testdata/golden/<name>, or rewrites it with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "golden", name)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file (run go test ./fileType -update): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run go test ./fileType -update to accept):\n%s", path, UnifiedDiff(name, want, got))
+	}
+}
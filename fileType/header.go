@@ -0,0 +1,47 @@
+package filetype
+
+import (
+	"bytes"
+	"strings"
+)
+
+// GeneratedMarker is the first line of the provenance header. It follows the Go
+// convention for generated files so linters and reviewers recognise it.
+const GeneratedMarker = "Code generated by version-generator. DO NOT EDIT."
+
+// Commenter is implemented by file types whose format supports line comments
+type Commenter interface {
+	CommentPrefix() string
+}
+
+// addHeader prepends the marker and header lines as comments, replacing a header
+// left by a previous run (e.g. when merging into an existing file)
+func addHeader(data []byte, prefix string, lines []string) []byte {
+	data = stripHeader(data, prefix)
+
+	var buf bytes.Buffer
+	buf.WriteString(prefix + " " + GeneratedMarker + "\n")
+	for _, line := range lines {
+		buf.WriteString(prefix + " " + line + "\n")
+	}
+	buf.WriteString("\n")
+	buf.Write(data)
+	return buf.Bytes()
+}
+
+// stripHeader removes a leading header block written by addHeader
+func stripHeader(data []byte, prefix string) []byte {
+	marker := prefix + " " + GeneratedMarker + "\n"
+	if !bytes.HasPrefix(data, []byte(marker)) {
+		return data
+	}
+	rest := string(data[len(marker):])
+	for strings.HasPrefix(rest, prefix+" ") {
+		end := strings.IndexByte(rest, '\n')
+		if end < 0 {
+			return nil
+		}
+		rest = rest[end+1:]
+	}
+	return []byte(strings.TrimPrefix(rest, "\n"))
+}
@@ -0,0 +1,34 @@
+package filetype
+
+import (
+	"fmt"
+	gittype "version-generator/gitType"
+)
+
+// INIType writes a [version] section in INI format
+type INIType struct {
+}
+
+func (i *INIType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	data := fmt.Sprintf("[version]\nversion=%s\ncommit=%s\nbranch=%s\n", info.Version, info.ShortHash, info.Branch)
+	if info.Variant != "" {
+		data += "variant=" + info.Variant + "\n"
+	}
+	if info.Channel != "" {
+		data += "channel=" + info.Channel + "\n"
+	}
+	if info.URL != "" {
+		data += "url=" + info.URL + "\n"
+	}
+	if len(info.Metadata) > 0 {
+		data += "\n[metadata]\n"
+		for _, meta := range info.Metadata {
+			data += fmt.Sprintf("%s=%s\n", meta.Key, meta.Value)
+		}
+	}
+	return []byte(data), nil
+}
+
+func (i *INIType) CommentPrefix() string {
+	return ";"
+}
@@ -0,0 +1,64 @@
+package filetype
+
+import (
+	"encoding/json"
+	gittype "version-generator/gitType"
+)
+
+// JSONType writes the version and how it was derived as a JSON object, the
+// same object --output-format=json prints
+type JSONType struct {
+}
+
+// jsonVersion is the object written by JSONType
+type jsonVersion struct {
+	Version      string                  `json:"version"`
+	Tag          string                  `json:"tag"`
+	CommitsSince int                     `json:"commits_since"`
+	Branch       string                  `json:"branch"`
+	ShortHash    string                  `json:"short_hash,omitempty"`
+	Commit       string                  `json:"commit,omitempty"`
+	Variant      string                  `json:"variant,omitempty"`
+	Channel      string                  `json:"channel,omitempty"`
+	URL          string                  `json:"url,omitempty"`
+	Metadata     map[string]string       `json:"metadata,omitempty"`
+	Warnings     []gittype.Warning       `json:"warnings,omitempty"`
+	Commits      []gittype.ShortlogEntry `json:"commits,omitempty"`
+	Issues       []string                `json:"issues,omitempty"`
+	Contributors []gittype.Contributor   `json:"contributors,omitempty"`
+	Mainline     string                  `json:"mainline,omitempty"`
+	AheadOfMain  *int                    `json:"ahead_of_main,omitempty"`
+	BehindMain   *int                    `json:"behind_main,omitempty"`
+	Generator    *gittype.GeneratorInfo  `json:"generator,omitempty"`
+}
+
+func (j *JSONType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	data := jsonVersion{
+		Version:      info.Version,
+		Tag:          info.LastTag,
+		CommitsSince: info.CommitsSince,
+		Branch:       info.Branch,
+		ShortHash:    info.ShortHash,
+		Commit:       info.Commit,
+		Variant:      info.Variant,
+		Channel:      info.Channel,
+		URL:          info.URL,
+		Warnings:     info.Warnings,
+		Commits:      info.Shortlog,
+		Issues:       info.Issues,
+		Contributors: info.Contributors,
+		Generator:    info.Generator,
+	}
+	if info.Mainline != "" {
+		data.Mainline = info.Mainline
+		data.AheadOfMain, data.BehindMain = &info.AheadOfMain, &info.BehindMain
+	}
+	if len(info.Metadata) > 0 {
+		data.Metadata = metadataMap(info)
+	}
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
@@ -0,0 +1,45 @@
+package filetype
+
+import (
+	"strings"
+	gittype "version-generator/gitType"
+)
+
+// defaultVersionKey is used by structured writers when no key path is configured
+const defaultVersionKey = "version"
+
+// splitKeyPath splits a dotted key path such as app.build.version into its segments
+func splitKeyPath(keyPath string) []string {
+	if keyPath == "" {
+		keyPath = defaultVersionKey
+	}
+	var segments []string
+	for _, segment := range strings.Split(keyPath, ".") {
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+	if len(segments) == 0 {
+		return []string{defaultVersionKey}
+	}
+	return segments
+}
+
+// nestedValue wraps value in maps following the given key path
+func nestedValue(keyPath string, value interface{}) map[string]interface{} {
+	segments := splitKeyPath(keyPath)
+	result := map[string]interface{}{segments[len(segments)-1]: value}
+	for i := len(segments) - 2; i >= 0; i-- {
+		result = map[string]interface{}{segments[i]: result}
+	}
+	return result
+}
+
+// metadataMap returns the --meta build metadata as a key/value map
+func metadataMap(info *gittype.VersionInfo) map[string]string {
+	metadata := make(map[string]string, len(info.Metadata))
+	for _, meta := range info.Metadata {
+		metadata[meta.Key] = meta.Value
+	}
+	return metadata
+}
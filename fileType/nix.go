@@ -0,0 +1,43 @@
+package filetype
+
+import (
+	"fmt"
+	"strings"
+	gittype "version-generator/gitType"
+)
+
+// NixType writes a Nix attribute set that derivations can import
+type NixType struct {
+}
+
+func (n *NixType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	data := fmt.Sprintf("{\n  version = %s;\n  rev = %s;\n  shortRev = %s;\n",
+		nixQuote(info.Version), nixQuote(info.Commit), nixQuote(info.ShortHash))
+	if info.Variant != "" {
+		data += fmt.Sprintf("  variant = %s;\n", nixQuote(info.Variant))
+	}
+	if info.Channel != "" {
+		data += fmt.Sprintf("  channel = %s;\n", nixQuote(info.Channel))
+	}
+	if info.URL != "" {
+		data += fmt.Sprintf("  url = %s;\n", nixQuote(info.URL))
+	}
+	if len(info.Metadata) > 0 {
+		data += "  metadata = {\n"
+		for _, meta := range info.Metadata {
+			data += fmt.Sprintf("    %s = %s;\n", nixQuote(meta.Key), nixQuote(meta.Value))
+		}
+		data += "  };\n"
+	}
+	return []byte(data + "}\n"), nil
+}
+
+func (n *NixType) CommentPrefix() string {
+	return "#"
+}
+
+// nixQuote quotes a string for Nix, escaping antiquotations
+func nixQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "${", `\${`).Replace(s)
+	return `"` + s + `"`
+}
@@ -0,0 +1,74 @@
+package filetype
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ValidatePath refuses output paths that resolve outside repoRoot or into its
+// .git directory, which are almost always typos rather than intent
+func ValidatePath(filePath, repoRoot string) error {
+	root, err := resolvePath(repoRoot)
+	if err != nil {
+		return fmt.Errorf("failed to resolve repository root: %w", err)
+	}
+	target, err := resolvePath(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", filePath, err)
+	}
+
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s is outside the repository root %s (use --force to write anyway)", filePath, root)
+	}
+	if rel == ".git" || strings.HasPrefix(rel, ".git"+string(filepath.Separator)) {
+		return fmt.Errorf("%s is inside the .git directory (use --force to write anyway)", filePath)
+	}
+	return nil
+}
+
+// RelativeToRoot returns filePath relative to repoRoot using forward slashes
+func RelativeToRoot(filePath, repoRoot string) (string, error) {
+	root, err := resolvePath(repoRoot)
+	if err != nil {
+		return "", err
+	}
+	target, err := resolvePath(filePath)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(root, target)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// resolvePath returns the absolute path with symlinks resolved for the longest
+// existing prefix, so paths to files that do not exist yet can be compared
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	existing, rest := abs, ""
+	for {
+		resolved, err := filepath.EvalSymlinks(existing)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return abs, nil
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
@@ -0,0 +1,76 @@
+package filetype
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	gittype "version-generator/gitType"
+)
+
+// RCType writes a Windows resource script with a VERSIONINFO block
+type RCType struct {
+}
+
+const rcTemplate = `#include <winver.h>
+
+VS_VERSION_INFO VERSIONINFO
+ FILEVERSION %[1]s
+ PRODUCTVERSION %[1]s
+ FILEFLAGSMASK VS_FFI_FILEFLAGSMASK
+ FILEFLAGS %[3]s
+ FILEOS VOS_NT_WINDOWS32
+ FILETYPE VFT_APP
+ FILESUBTYPE VFT2_UNKNOWN
+BEGIN
+    BLOCK "StringFileInfo"
+    BEGIN
+        BLOCK "040904b0"
+        BEGIN
+            VALUE "FileVersion", "%[2]s"
+            VALUE "ProductVersion", "%[2]s"
+%[4]s        END
+    END
+    BLOCK "VarFileInfo"
+    BEGIN
+        VALUE "Translation", 0x409, 1200
+    END
+END
+`
+
+func (r *RCType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	quad := versionQuad(info.LastTag, info.CommitsSince)
+	// A variant is reported as a special build of the same version
+	flags, specialBuild := "0x0L", ""
+	if info.Variant != "" {
+		flags = "VS_FF_SPECIALBUILD"
+		specialBuild = fmt.Sprintf("            VALUE \"SpecialBuild\", \"%s\"\n", strings.ReplaceAll(info.Variant, `"`, `""`))
+	}
+	data := fmt.Sprintf(rcTemplate,
+		fmt.Sprintf("%d,%d,%d,%d", quad[0], quad[1], quad[2], quad[3]),
+		strings.ReplaceAll(info.Version, `"`, `""`), flags, specialBuild)
+	return []byte(data), nil
+}
+
+func (r *RCType) CommentPrefix() string {
+	return "//"
+}
+
+var quadPartPattern = regexp.MustCompile(`\d+`)
+
+// versionQuad derives a Windows major,minor,patch,build quad from a semver tag
+// and the number of commits since it. Each field is clamped to 16 bits.
+func versionQuad(tag string, commitsSince int) [4]int {
+	var quad [4]int
+	core := strings.SplitN(strings.TrimPrefix(tag, "v"), "-", 2)[0]
+	for i, part := range quadPartPattern.FindAllString(core, 3) {
+		quad[i], _ = strconv.Atoi(part)
+	}
+	quad[3] = commitsSince
+	for i := range quad {
+		if quad[i] > 0xFFFF {
+			quad[i] = 0xFFFF
+		}
+	}
+	return quad
+}
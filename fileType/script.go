@@ -0,0 +1,57 @@
+package filetype
+
+import (
+	"fmt"
+	"strings"
+	gittype "version-generator/gitType"
+)
+
+// ShellType writes a POSIX shell snippet meant to be sourced
+type ShellType struct {
+}
+
+func (s *ShellType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	data := fmt.Sprintf("VERSION=%s\nGIT_COMMIT=%s\nBRANCH=%s\nexport VERSION GIT_COMMIT BRANCH\n",
+		shellQuote(info.Version), shellQuote(info.ShortHash), shellQuote(info.Branch))
+	if info.Variant != "" {
+		data += fmt.Sprintf("VARIANT=%s\nexport VARIANT\n", shellQuote(info.Variant))
+	}
+	if info.Channel != "" {
+		data += fmt.Sprintf("CHANNEL=%s\nexport CHANNEL\n", shellQuote(info.Channel))
+	}
+	return []byte(data), nil
+}
+
+func (s *ShellType) CommentPrefix() string {
+	return "#"
+}
+
+// PowerShellType writes a PowerShell snippet meant to be dot-sourced
+type PowerShellType struct {
+}
+
+func (p *PowerShellType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	data := fmt.Sprintf("$VERSION = %s\n$GIT_COMMIT = %s\n$BRANCH = %s\n",
+		powerShellQuote(info.Version), powerShellQuote(info.ShortHash), powerShellQuote(info.Branch))
+	if info.Variant != "" {
+		data += fmt.Sprintf("$VARIANT = %s\n", powerShellQuote(info.Variant))
+	}
+	if info.Channel != "" {
+		data += fmt.Sprintf("$CHANNEL = %s\n", powerShellQuote(info.Channel))
+	}
+	return []byte(data), nil
+}
+
+func (p *PowerShellType) CommentPrefix() string {
+	return "#"
+}
+
+// shellQuote wraps a string in single quotes for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// powerShellQuote wraps a string in single quotes for PowerShell
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
@@ -0,0 +1,76 @@
+package filetype
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	gittype "version-generator/gitType"
+)
+
+// TerraformType writes a Terraform variable definitions file (*.auto.tfvars)
+type TerraformType struct {
+}
+
+func (t *TerraformType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	data := fmt.Sprintf("version = %s\ncommit  = %s\nbranch  = %s\n",
+		hclQuote(info.Version), hclQuote(info.ShortHash), hclQuote(info.Branch))
+	if info.Variant != "" {
+		data += fmt.Sprintf("variant = %s\n", hclQuote(info.Variant))
+	}
+	if info.Channel != "" {
+		data += fmt.Sprintf("channel = %s\n", hclQuote(info.Channel))
+	}
+	if info.URL != "" {
+		data += fmt.Sprintf("url     = %s\n", hclQuote(info.URL))
+	}
+	if len(info.Metadata) > 0 {
+		data += "metadata = {\n"
+		for _, meta := range info.Metadata {
+			data += fmt.Sprintf("  %s = %s\n", hclQuote(meta.Key), hclQuote(meta.Value))
+		}
+		data += "}\n"
+	}
+	return []byte(data), nil
+}
+
+func (t *TerraformType) CommentPrefix() string {
+	return "#"
+}
+
+// PackerType writes a Packer JSON variable file (*.pkrvars.json)
+type PackerType struct {
+}
+
+func (p *PackerType) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	data := map[string]interface{}{
+		"version": info.Version,
+		"commit":  info.ShortHash,
+		"branch":  info.Branch,
+	}
+	if info.Variant != "" {
+		data["variant"] = info.Variant
+	}
+	if info.Channel != "" {
+		data["channel"] = info.Channel
+	}
+	if info.URL != "" {
+		data["url"] = info.URL
+	}
+	if len(info.Metadata) > 0 {
+		data["metadata"] = metadataMap(info)
+	}
+	if len(info.Warnings) > 0 {
+		data["warnings"] = info.Warnings
+	}
+	out, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// hclQuote quotes a string for HCL, escaping template sequences
+func hclQuote(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "${", "$${", "%{", "%%{").Replace(s)
+	return `"` + s + `"`
+}
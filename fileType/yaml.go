@@ -1,25 +1,162 @@
 package filetype
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"os"
-	"path/filepath"
+	gittype "version-generator/gitType"
 
 	"gopkg.in/yaml.v3"
 )
 
 type YAMLFile struct {
+	// Merge updates the version key in an existing file instead of overwriting it
+	Merge bool
+	// Key is a dotted path to the version key (default: version)
+	Key string
 }
 
-func (y *YAMLFile) WriteVersion(filePath string, version string) error {
-	// Ensure directory exists
-	if err := os.MkdirAll(filepath.Dir(filePath), 0755); err != nil {
-		return err
+func (y *YAMLFile) Render(filePath string, info *gittype.VersionInfo) ([]byte, error) {
+	if y.Merge {
+		existing, err := os.ReadFile(filePath)
+		if err == nil {
+			out, err := mergeYAMLVersion(existing, y.Key, info.Version)
+			if err != nil {
+				return nil, fmt.Errorf("failed to merge into %s: %w", filePath, err)
+			}
+			return out, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
 	}
-	// Write file (this will overwrite existing file)
-	data := map[string]string{"version": version}
-	out, err := yaml.Marshal(data)
-	if err != nil {
-		return err
+
+	data := nestedValue(y.Key, info.Version)
+	if _, taken := data["variant"]; !taken && info.Variant != "" {
+		data["variant"] = info.Variant
+	}
+	if _, taken := data["channel"]; !taken && info.Channel != "" {
+		data["channel"] = info.Channel
+	}
+	if _, taken := data["url"]; !taken && info.URL != "" {
+		data["url"] = info.URL
+	}
+	if _, taken := data["metadata"]; !taken && len(info.Metadata) > 0 {
+		data["metadata"] = metadataMap(info)
+	}
+	if _, taken := data["warnings"]; !taken && len(info.Warnings) > 0 {
+		data["warnings"] = info.Warnings
+	}
+	if _, taken := data["commits"]; !taken && len(info.Shortlog) > 0 {
+		data["commits"] = info.Shortlog
+	}
+	if _, taken := data["issues"]; !taken && len(info.Issues) > 0 {
+		data["issues"] = info.Issues
+	}
+	if _, taken := data["contributors"]; !taken && len(info.Contributors) > 0 {
+		data["contributors"] = info.Contributors
+	}
+	if _, taken := data["mainline"]; !taken && info.Mainline != "" {
+		data["mainline"] = info.Mainline
+		data["ahead_of_main"] = info.AheadOfMain
+		data["behind_main"] = info.BehindMain
+	}
+	if _, taken := data["generator"]; !taken && info.Generator != nil {
+		data["generator"] = info.Generator
+	}
+	return yaml.Marshal(data)
+}
+
+func (y *YAMLFile) CommentPrefix() string {
+	return "#"
+}
+
+// mergeYAMLVersion sets the version key path in the first document of a (possibly
+// multi-document) YAML stream, leaving other keys, comments and documents intact
+func mergeYAMLVersion(existing []byte, keyPath, version string) ([]byte, error) {
+	var docs []*yaml.Node
+	decoder := yaml.NewDecoder(bytes.NewReader(existing))
+	for {
+		doc := &yaml.Node{}
+		err := decoder.Decode(doc)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 0 {
+		docs = append(docs, &yaml.Node{Kind: yaml.DocumentNode})
+	}
+	root := docs[0]
+	if len(root.Content) == 0 {
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"})
+	}
+	if root.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("first document is not a mapping")
+	}
+	if err := setMappingPath(root.Content[0], splitKeyPath(keyPath), version); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// setMappingPath walks (creating as needed) nested mappings along path and sets the final key
+func setMappingPath(mapping *yaml.Node, path []string, value string) error {
+	for _, key := range path[:len(path)-1] {
+		child := mappingChild(mapping, key)
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			mapping.Content = append(mapping.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, child)
+		}
+		if child.Kind != yaml.MappingNode {
+			return fmt.Errorf("key %q is not a mapping", key)
+		}
+		mapping = child
+	}
+	setMappingValue(mapping, path[len(path)-1], value)
+	return nil
+}
+
+// mappingChild returns the value node for key in a mapping node, or nil
+func mappingChild(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue replaces the scalar value for key in a mapping node, appending it if missing
+func setMappingValue(mapping *yaml.Node, key, value string) {
+	if valueNode := mappingChild(mapping, key); valueNode != nil {
+		valueNode.Kind = yaml.ScalarNode
+		valueNode.Tag = "!!str"
+		valueNode.Value = value
+		valueNode.Content = nil
+		return
 	}
-	return os.WriteFile(filePath, out, 0644)
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value},
+	)
 }
@@ -0,0 +1,198 @@
+package gitType
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// AuthOptions configure how the go-git backend authenticates to remotes.
+// System git uses its own credential helpers and ssh configuration instead.
+type AuthOptions struct {
+	// SSHKey is a private key file for ssh remotes, tried before ssh-agent
+	SSHKey string
+	// SSHKeyPassphrase decrypts SSHKey or the default key files
+	SSHKeyPassphrase string
+	// TokenEnv names the environment variable holding a token for HTTPS remotes
+	TokenEnv string
+	// Netrc is the netrc file read for HTTPS credentials (default: $NETRC or ~/.netrc)
+	Netrc string
+}
+
+// defaultSSHKeys are tried in order when neither SSHKey nor ssh-agent is available
+var defaultSSHKeys = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// tokenUser is sent with a token from TokenEnv when the URL names no user;
+// GitHub and GitLab accept any user name with a personal access token
+const tokenUser = "x-access-token"
+
+// authFor chooses the credentials for a remote URL. For ssh remotes: SSHKey,
+// then ssh-agent, then the default key files in ~/.ssh. For HTTPS remotes:
+// credentials in the URL, then the TokenEnv token, then a netrc entry for the
+// host, then none. Local and file remotes need no credentials.
+func authFor(url string, options AuthOptions) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote URL %s: %w", RedactURL(url), err)
+	}
+	switch endpoint.Protocol {
+	case "ssh":
+		return sshAuth(endpoint, options)
+	case "http", "https":
+		return httpAuth(endpoint, options)
+	}
+	return nil, nil
+}
+
+// sshAuth returns the first available ssh credentials
+func sshAuth(endpoint *transport.Endpoint, options AuthOptions) (transport.AuthMethod, error) {
+	user := endpoint.User
+	if user == "" {
+		user = "git"
+	}
+	if options.SSHKey != "" {
+		auth, err := ssh.NewPublicKeysFromFile(user, options.SSHKey, options.SSHKeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ssh key %s: %w", options.SSHKey, err)
+		}
+		return auth, nil
+	}
+	if os.Getenv("SSH_AUTH_SOCK") != "" {
+		if auth, err := ssh.NewSSHAgentAuth(user); err == nil {
+			return auth, nil
+		}
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		for _, name := range defaultSSHKeys {
+			path := filepath.Join(home, ".ssh", name)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			auth, err := ssh.NewPublicKeysFromFile(user, path, options.SSHKeyPassphrase)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read ssh key %s: %w", path, err)
+			}
+			return auth, nil
+		}
+	}
+	return nil, fmt.Errorf("no ssh credentials for %s: set --ssh-key or start ssh-agent", endpoint.Host)
+}
+
+// httpAuth returns the first configured HTTPS credentials, or nil for anonymous access
+func httpAuth(endpoint *transport.Endpoint, options AuthOptions) (transport.AuthMethod, error) {
+	if endpoint.Password != "" {
+		return &http.BasicAuth{Username: endpoint.User, Password: endpoint.Password}, nil
+	}
+	if options.TokenEnv != "" {
+		if token := os.Getenv(options.TokenEnv); token != "" {
+			user := endpoint.User
+			if user == "" {
+				user = tokenUser
+			}
+			return &http.BasicAuth{Username: user, Password: token}, nil
+		}
+	}
+	login, password, found, err := netrcCredentials(options.Netrc, endpoint.Host)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return &http.BasicAuth{Username: login, Password: password}, nil
+	}
+	return nil, nil
+}
+
+// netrcCredentials looks up the login and password for host in a netrc file,
+// falling back to its default entry. A missing file has no credentials.
+func netrcCredentials(path, host string) (login, password string, found bool, err error) {
+	if path == "" {
+		path = os.Getenv("NETRC")
+	}
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "", false, nil
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return "", "", false, nil
+	}
+	if err != nil {
+		return "", "", false, fmt.Errorf("failed to read netrc: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Split(bufio.ScanWords)
+	var tokens []string
+	for scanner.Scan() {
+		tokens = append(tokens, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", false, fmt.Errorf("failed to read netrc: %w", err)
+	}
+
+	// Entries start at machine or default and hold login and password pairs
+	var defaultLogin, defaultPassword string
+	hasDefault, inMatch, inDefault := false, false, false
+	for i := 0; i < len(tokens); i++ {
+		value := ""
+		if i+1 < len(tokens) {
+			value = tokens[i+1]
+		}
+		switch tokens[i] {
+		case "machine":
+			if inMatch {
+				return login, password, true, nil
+			}
+			inMatch, inDefault = value == host, false
+			i++
+		case "default":
+			if inMatch {
+				return login, password, true, nil
+			}
+			inMatch, inDefault, hasDefault = false, true, true
+		case "login":
+			if inMatch {
+				login = value
+			} else if inDefault {
+				defaultLogin = value
+			}
+			i++
+		case "password":
+			if inMatch {
+				password = value
+			} else if inDefault {
+				defaultPassword = value
+			}
+			i++
+		case "account", "macdef":
+			i++
+		}
+	}
+	if inMatch {
+		return login, password, true, nil
+	}
+	return defaultLogin, defaultPassword, hasDefault, nil
+}
+
+// urlCredentials matches the user information of URLs in free text
+var urlCredentials = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/@\s]+@`)
+
+// RedactURL hides the user name and password or token in a URL, or in any
+// URLs within a message, so they never reach logs
+func RedactURL(text string) string {
+	return urlCredentials.ReplaceAllStringFunc(text, func(match string) string {
+		scheme, _, _ := strings.Cut(match, "://")
+		return scheme + "://***@"
+	})
+}
@@ -1,12 +1,18 @@
 package gitType
 
 import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
 	"version-generator/versionSchemes"
 )
 
 // BaseGitHandler provides common functionality for git handlers
 type BaseGitHandler struct {
 	versionGenerator *versionSchemes.VersionGenerator
+	options          GitOptions
 }
 
 // NewBaseGitHandler creates a new base git handler
@@ -16,23 +22,221 @@ func NewBaseGitHandler() *BaseGitHandler {
 	}
 }
 
+// tagDistanceExceeded applies the configured policy when no tag was found within MaxTagDistance
+func (b *BaseGitHandler) tagDistanceExceeded() (string, bool, error) {
+	if b.options.FailOnTagDistance {
+		return "", false, fmt.Errorf("%w (%d commits)", ErrTagDistanceExceeded, b.options.MaxTagDistance)
+	}
+	return "", false, nil
+}
+
+// applyNoTagsPolicy picks the base version to report when no tag was found
+func (b *BaseGitHandler) applyNoTagsPolicy(lastTag string, found bool) (string, error) {
+	if found {
+		return lastTag, nil
+	}
+
+	switch b.options.NoTagsPolicy {
+	case "", NoTagsZero:
+		return NoTagVersion, nil
+	case NoTagsInitial:
+		if b.options.InitialVersion == "" {
+			return "", fmt.Errorf("no-tags policy %q requires an initial version", NoTagsInitial)
+		}
+		return b.options.InitialVersion, nil
+	case NoTagsError:
+		return "", ErrNoTags
+	default:
+		return "", fmt.Errorf("unknown no-tags policy %q", b.options.NoTagsPolicy)
+	}
+}
+
+// detachedHeadWarnings describes a detached HEAD whose branch was inferred from
+// the branches containing it
+func detachedHeadWarnings(branchName string, containing []string) []Warning {
+	warnings := []Warning{{
+		Code:    WarningDetachedHead,
+		Message: fmt.Sprintf("HEAD is detached; versioning it as branch %q", branchName),
+	}}
+	if len(containing) > 1 {
+		warnings = append(warnings, Warning{
+			Code:    WarningAmbiguousBranch,
+			Message: fmt.Sprintf("HEAD is contained in %d branches (%s); picked %q", len(containing), strings.Join(containing, ", "), branchName),
+		})
+	}
+	return warnings
+}
+
+// shallowCloneWarning reports a shallow clone, where tags beyond the boundary are missing
+func shallowCloneWarning() Warning {
+	return Warning{
+		Code:    WarningShallowClone,
+		Message: "repository is a shallow clone; tags and commit counts beyond its depth are missing (fetch with --unshallow)",
+	}
+}
+
+// unbornVersionInfo describes a repository whose current branch has no commits
+// yet: the no-tags baseline with zero commits and no hash, e.g. v0.0.0+0
+func (b *BaseGitHandler) unbornVersionInfo(branchName string, options versionSchemes.VersioningOptions) (*VersionInfo, error) {
+	baseline, err := b.applyNoTagsPolicy("", false)
+	if err != nil {
+		return nil, err
+	}
+	version := baseline + "+0"
+	if options.Variant != "" {
+		version = versionSchemes.AppendBuildMetadata(version, []versionSchemes.BuildMetadata{{Key: options.Variant}})
+	}
+	return &VersionInfo{
+		Branch:  branchName,
+		LastTag: baseline,
+		Version: version,
+		Variant: options.Variant,
+	}, nil
+}
+
+// checkOrphanPolicy fails for an orphan branch under OrphanError and rejects unknown policies
+func (b *BaseGitHandler) checkOrphanPolicy(branchName string) error {
+	switch b.options.OrphanPolicy {
+	case "", OrphanOwnTags, OrphanCalVer:
+		return nil
+	case OrphanError:
+		return fmt.Errorf("%s: %w", branchName, ErrOrphanBranch)
+	default:
+		return fmt.Errorf("unknown orphan branch policy %q", b.options.OrphanPolicy)
+	}
+}
+
+// isMainline reports whether branchName is main or master, the branches other branches are measured against
+func isMainline(branchName string) bool {
+	return branchName == "main" || branchName == "master"
+}
+
+// maintenanceBranchPattern matches maintenance branches such as 1.4.x, v2.x or release/1.4.x
+var maintenanceBranchPattern = regexp.MustCompile(`^(?:.*/)?v?(\d+(?:\.\d+)?)\.x$`)
+
+// tagMatchFor returns the TagMatch of tag lookups on branchName and whether it
+// is a maintenance branch. Those are limited to their release line, e.g. v1.4.*
+// on 1.4.x, so newer tags merged back into the branch are never picked up; a
+// TagMatch set by the caller is kept.
+func (b *BaseGitHandler) tagMatchFor(branchName string) (string, bool) {
+	if b.options.NoMaintenanceBranches {
+		return b.options.TagMatch, false
+	}
+	match := maintenanceBranchPattern.FindStringSubmatch(branchName)
+	if match == nil {
+		return b.options.TagMatch, false
+	}
+	if b.options.TagMatch != "" {
+		return b.options.TagMatch, true
+	}
+	return b.options.Tag("v" + match[1] + ".*"), true
+}
+
+// applyAlways replaces the version of an untagged commit when GitOptions.Always is
+// set, since v0.0.0+N suggests a release that never happened
+func (b *BaseGitHandler) applyAlways(info *VersionInfo, found bool) *VersionInfo {
+	if found || !b.options.Always {
+		return info
+	}
+	if b.options.NoTagsPolicy == NoTagsInitial {
+		info.Version = fmt.Sprintf("%s+%d.g%s", info.LastTag, info.CommitsSince, info.ShortHash)
+	} else {
+		info.Version = "g" + info.ShortHash
+	}
+	if info.Variant != "" {
+		info.Version = versionSchemes.AppendBuildMetadata(info.Version, []versionSchemes.BuildMetadata{{Key: info.Variant}})
+	}
+	return info
+}
+
+// tagMatches reports whether a tag name passes the TagPrefix, TagMatch and ExcludeTags filters
+func (b *BaseGitHandler) tagMatches(name string) bool {
+	return b.tagMatchesGlob(name, b.options.TagMatch)
+}
+
+// tagMatchesGlob is tagMatches with match in place of TagMatch
+func (b *BaseGitHandler) tagMatchesGlob(name, match string) bool {
+	if !b.options.HasTagPrefix(name) || slices.Contains(b.options.ExcludeTags, name) {
+		return false
+	}
+	if match == "" {
+		return true
+	}
+	ok, _ := path.Match(match, name)
+	return ok
+}
+
+// excludeTags adds tag names to ExcludeTags
+func (b *BaseGitHandler) excludeTags(names []string) {
+	b.options.ExcludeTags = append(slices.Clip(b.options.ExcludeTags), names...)
+}
+
+// tagGlob returns the pattern tag lookups with match in place of TagMatch
+// should pass to git, or "" for all tags
+func (b *BaseGitHandler) tagGlob(match string) string {
+	switch {
+	case match != "":
+		return match
+	case b.options.TagPrefix != "":
+		return b.options.Tag("*")
+	default:
+		return ""
+	}
+}
+
+// displayTag strips TagPrefix from a tag for use in the version
+func (b *BaseGitHandler) displayTag(tag string) string {
+	return b.options.TagVersion(tag)
+}
+
+// hasPathFilter reports whether commit counts are restricted to certain paths
+func (b *BaseGitHandler) hasPathFilter() bool {
+	return len(b.options.Paths) > 0 || len(b.options.ExcludePaths) > 0
+}
+
+// pathSelected reports whether a changed file counts under the Paths and ExcludePaths filters
+func (b *BaseGitHandler) pathSelected(file string) bool {
+	for _, excluded := range b.options.ExcludePaths {
+		if pathContains(excluded, file) {
+			return false
+		}
+	}
+	if len(b.options.Paths) == 0 {
+		return true
+	}
+	for _, included := range b.options.Paths {
+		if pathContains(included, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// pathContains reports whether file is dir itself or lies below it
+func pathContains(dir, file string) bool {
+	dir = path.Clean(dir)
+	return dir == "." || file == dir || strings.HasPrefix(file, dir+"/")
+}
+
 // GenerateVersionInfoFromComponents creates VersionInfo from git components
-func (b *BaseGitHandler) GenerateVersionInfoFromComponents(branchName, shortHash, lastTag string, commitsSince int, dockerFormat bool) *VersionInfo {
+func (b *BaseGitHandler) GenerateVersionInfoFromComponents(branchName, commit, shortHash, lastTag string, commitsSince int, dockerFormat bool) *VersionInfo {
 	// Generate version string using legacy format for backward compatibility
-	version := b.versionGenerator.GenerateLegacy(lastTag, commitsSince, shortHash, branchName, dockerFormat)
+	version := versionSchemes.NewVersionGeneratorWith(b.options.Naming).GenerateLegacy(lastTag, commitsSince, shortHash, branchName, dockerFormat)
 
 	return &VersionInfo{
 		Branch:       branchName,
 		LastTag:      lastTag,
 		CommitsSince: commitsSince,
 		ShortHash:    shortHash,
+		Commit:       commit,
 		Version:      version,
 	}
 }
 
 // GenerateVersionInfoFromComponentsWithOptions creates VersionInfo with custom options
-func (b *BaseGitHandler) GenerateVersionInfoFromComponentsWithOptions(branchName, shortHash, lastTag string, commitsSince int, options versionSchemes.VersioningOptions) *VersionInfo {
-	// Generate version string using new options
+func (b *BaseGitHandler) GenerateVersionInfoFromComponentsWithOptions(branchName, commit, shortHash, lastTag string, commitsSince int, options versionSchemes.VersioningOptions) *VersionInfo {
+	// Generate version string using new options, naming tags and branches as the handler does
+	options.Naming = b.options.Naming
 	version := b.versionGenerator.GenerateVersion(lastTag, commitsSince, shortHash, branchName, options)
 
 	return &VersionInfo{
@@ -40,6 +244,8 @@ func (b *BaseGitHandler) GenerateVersionInfoFromComponentsWithOptions(branchName
 		LastTag:      lastTag,
 		CommitsSince: commitsSince,
 		ShortHash:    shortHash,
+		Commit:       commit,
 		Version:      version,
+		Variant:      options.Variant,
 	}
 }
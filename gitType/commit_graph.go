@@ -0,0 +1,244 @@
+package gitType
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+var (
+	// errNoCommonAncestor is returned when two commits share no history
+	errNoCommonAncestor = errors.New("no common ancestor found")
+	// errStopWalk ends a commitGraph walk early without reporting an error
+	errStopWalk = errors.New("stop walk")
+	// errSkipParents keeps a commitGraph walk from following the current commit's parents
+	errSkipParents = errors.New("skip parents")
+)
+
+// deadlineBatch is how many commits a walk visits between checks for the
+// deadline and cancellation of GitOptions
+const deadlineBatch = 1024
+
+// commitGraph resolves commit parents for go-git history walks the way system
+// git does: replace refs and info/grafts substitute parents (unless disabled),
+// and shallow commits are treated as having no parents. With firstParent only
+// the first parent of each commit is followed.
+type commitGraph struct {
+	repo        *git.Repository
+	replace     map[plumbing.Hash]plumbing.Hash
+	grafts      map[plumbing.Hash][]plumbing.Hash
+	shallow     map[plumbing.Hash]bool
+	firstParent bool
+	interrupted func() error
+	progress    *progress
+}
+
+// newCommitGraph loads replace refs, grafts and shallow boundaries for repo
+func newCommitGraph(repo *git.Repository, options GitOptions) (*commitGraph, error) {
+	graph := &commitGraph{
+		repo:        repo,
+		replace:     make(map[plumbing.Hash]plumbing.Hash),
+		grafts:      make(map[plumbing.Hash][]plumbing.Hash),
+		shallow:     make(map[plumbing.Hash]bool),
+		firstParent: options.Traversal == TraversalFirstParent,
+		interrupted: options.interrupted,
+		progress:    newProgress(options.Progress),
+	}
+
+	shallow, err := repo.Storer.Shallow()
+	if err != nil {
+		return nil, err
+	}
+	for _, hash := range shallow {
+		graph.shallow[hash] = true
+	}
+
+	if options.NoReplaceObjects {
+		return graph, nil
+	}
+
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		original, ok := strings.CutPrefix(ref.Name().String(), "refs/replace/")
+		if ok && plumbing.IsHash(original) {
+			graph.replace[plumbing.NewHash(original)] = ref.Hash()
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := graph.loadGrafts(); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+// loadGrafts reads the legacy info/grafts file ("<commit> <parent>..." per line)
+func (cg *commitGraph) loadGrafts() error {
+	storage, ok := cg.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil
+	}
+
+	f, err := storage.Filesystem().Open("info/grafts")
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || !plumbing.IsHash(fields[0]) {
+			continue
+		}
+		parents := []plumbing.Hash{}
+		for _, field := range fields[1:] {
+			parents = append(parents, plumbing.NewHash(field))
+		}
+		cg.grafts[plumbing.NewHash(fields[0])] = parents
+	}
+	return scanner.Err()
+}
+
+// parents returns the effective parents of a commit
+func (cg *commitGraph) parents(hash plumbing.Hash) ([]plumbing.Hash, error) {
+	parents, err := cg.allParents(hash)
+	if err != nil {
+		return nil, err
+	}
+	if cg.firstParent && len(parents) > 1 {
+		return parents[:1], nil
+	}
+	return parents, nil
+}
+
+// allParents returns the parents of a commit after grafts, replacements and shallow boundaries
+func (cg *commitGraph) allParents(hash plumbing.Hash) ([]plumbing.Hash, error) {
+	if cg.shallow[hash] {
+		return nil, nil
+	}
+	if parents, ok := cg.grafts[hash]; ok {
+		return parents, nil
+	}
+
+	objectHash := hash
+	if replacement, ok := cg.replace[hash]; ok {
+		objectHash = replacement
+	}
+	commit, err := cg.repo.CommitObject(objectHash)
+	if err != nil {
+		return nil, err
+	}
+	return commit.ParentHashes, nil
+}
+
+// walk visits every commit reachable from start once, nearest generations
+// first, following only first parents with firstParent. Returning errStopWalk
+// from visit ends the walk without error, and errSkipParents prunes the
+// history behind the current commit.
+func (cg *commitGraph) walk(start plumbing.Hash, visit func(hash plumbing.Hash) error) error {
+	return cg.walkParents(start, cg.parents, visit)
+}
+
+// walkAll is walk following every parent whatever firstParent is, for
+// reachability and merge-bases, which git computes over the full graph
+func (cg *commitGraph) walkAll(start plumbing.Hash, visit func(hash plumbing.Hash) error) error {
+	return cg.walkParents(start, cg.allParents, visit)
+}
+
+// walkParents visits the commits reachable from start through parents
+func (cg *commitGraph) walkParents(start plumbing.Hash, parents func(plumbing.Hash) ([]plumbing.Hash, error), visit func(hash plumbing.Hash) error) error {
+	seen := map[plumbing.Hash]bool{start: true}
+	queue := []plumbing.Hash{start}
+	defer cg.progress.clear()
+
+	for visited := 0; len(queue) > 0; visited++ {
+		hash := queue[0]
+		queue = queue[1:]
+		cg.progress.step()
+		if visited%deadlineBatch == 0 {
+			if err := cg.interrupted(); err != nil {
+				return fmt.Errorf("%w while walking history", err)
+			}
+		}
+
+		if err := visit(hash); err != nil {
+			if errors.Is(err, errStopWalk) {
+				return nil
+			}
+			if errors.Is(err, errSkipParents) {
+				continue
+			}
+			return err
+		}
+
+		next, err := parents(hash)
+		if err != nil {
+			return err
+		}
+		for _, parent := range next {
+			if !seen[parent] {
+				seen[parent] = true
+				queue = append(queue, parent)
+			}
+		}
+	}
+	return nil
+}
+
+// ancestors returns the set of commits reachable from start, including start,
+// through every parent
+func (cg *commitGraph) ancestors(start plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	set := make(map[plumbing.Hash]bool)
+	err := cg.walkAll(start, func(hash plumbing.Hash) error {
+		set[hash] = true
+		return nil
+	})
+	return set, err
+}
+
+// countRange counts commits reachable from to but not from from, like
+// git rev-list --count from..to
+func (cg *commitGraph) countRange(from, to plumbing.Hash) (int, error) {
+	commits, err := cg.rangeCommits(from, to)
+	return len(commits), err
+}
+
+// rangeCommits lists commits reachable from to but not from from, nearest
+// first. A zero from lists the whole history of to. As with git rev-list
+// --first-parent to ^from, firstParent limits the commits listed but not the
+// history of from that is excluded.
+func (cg *commitGraph) rangeCommits(from, to plumbing.Hash) ([]plumbing.Hash, error) {
+	excluded := make(map[plumbing.Hash]bool)
+	if !from.IsZero() {
+		var err error
+		if excluded, err = cg.ancestors(from); err != nil {
+			return nil, err
+		}
+	}
+
+	var commits []plumbing.Hash
+	err := cg.walk(to, func(hash plumbing.Hash) error {
+		if excluded[hash] {
+			return errSkipParents
+		}
+		commits = append(commits, hash)
+		return nil
+	})
+	return commits, err
+}
@@ -0,0 +1,99 @@
+package gitType
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// minAbbrev is the shortest abbreviated hash git prints
+const minAbbrev = 7
+
+// Describe renders HEAD like git describe --tags --dirty --always:
+// <tag>-<count>-g<hash>, the tag alone on a tagged commit, or the abbreviated
+// hash when no tag is reachable, each followed by -dirty for tracked changes
+func (g *GoGitHandler) Describe() (string, error) {
+	head, err := g.head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	tag, found, err := g.findTagFromCurrentBranch(head.Hash(), g.options.TagMatch)
+	if err != nil {
+		return "", err
+	}
+	var description string
+	if found {
+		count, err := g.GetCommitsSinceTag(tag)
+		if err != nil {
+			return "", err
+		}
+		description = tag
+		if count > 0 {
+			abbrev, err := g.abbreviate(head.Hash())
+			if err != nil {
+				return "", err
+			}
+			description = fmt.Sprintf("%s-%d-g%s", tag, count, abbrev)
+		}
+	} else {
+		if description, err = g.abbreviate(head.Hash()); err != nil {
+			return "", err
+		}
+	}
+
+	// The working tree does not apply to another revision
+	if g.options.Revision != "" {
+		return description, nil
+	}
+	state, err := g.GetWorktreeState()
+	if err != nil {
+		return "", err
+	}
+	if state.Dirty {
+		description += "-dirty"
+	}
+	return description, nil
+}
+
+// abbreviate shortens a hash the way git does by default: core.abbrev when it
+// is a number, otherwise a length scaled to the object count, and never shorter
+// than needed to be unique among the repository's objects
+func (g *GoGitHandler) abbreviate(hash plumbing.Hash) (string, error) {
+	objects, err := g.repo.Storer.IterEncodedObjects(plumbing.AnyObject)
+	if err != nil {
+		return "", fmt.Errorf("failed to list objects: %w", err)
+	}
+	count, unique := 0, 1
+	err = objects.ForEach(func(obj plumbing.EncodedObject) error {
+		count++
+		if other := obj.Hash(); other != hash {
+			unique = max(unique, commonHexPrefix(hash, other)+1)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to list objects: %w", err)
+	}
+
+	// git's auto length: half the bits needed to count the objects, in hex digits
+	length := max((bits.Len(uint(count))+1)/2, minAbbrev)
+	if cfg, err := g.repo.Config(); err == nil {
+		if configured, err := strconv.Atoi(cfg.Raw.Section("core").Option("abbrev")); err == nil {
+			length = min(max(configured, 4), len(hash.String()))
+		}
+	}
+	return hash.String()[:min(max(length, unique), len(hash.String()))], nil
+}
+
+// commonHexPrefix returns the number of leading hex digits two hashes share
+func commonHexPrefix(a, b plumbing.Hash) int {
+	x, y := a.String(), b.String()
+	n := 0
+	for n < len(x) && x[n] == y[n] {
+		n++
+	}
+	return n
+}
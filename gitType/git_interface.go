@@ -1,6 +1,21 @@
 package gitType
 
-import "version-generator/versionSchemes"
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+	"version-generator/versionSchemes"
+)
+
+// ErrNoTags is returned when the repository has no reachable tag and NoTagsPolicy is NoTagsError
+var ErrNoTags = errors.New("no reachable tag found")
+
+// ErrTagDistanceExceeded is returned when no tag is found within GitOptions.MaxTagDistance
+var ErrTagDistanceExceeded = errors.New("no tag found within maximum tag distance")
+
+// ErrTimeout is returned by git operations still running at GitOptions.Deadline
+var ErrTimeout = errors.New("version generation timed out")
 
 // VersionInfo contains git version information
 type VersionInfo struct {
@@ -8,13 +23,213 @@ type VersionInfo struct {
 	LastTag      string
 	CommitsSince int
 	ShortHash    string
+	Commit       string
+	CommitDate   time.Time // Committer date of HEAD, set with --go-format=map
 	Version      string
+	Variant      string                         // Build flavor selected with --variant
+	Channel      string                         // Build channel selected with --channel
+	URL          string                         // Browsable URL of the commit, set with --url-template
+	Repository   string                         // Web URL of the origin remote, set with --cyclonedx
+	Metadata     []versionSchemes.BuildMetadata // Build metadata added with --meta, in order
+	Warnings     []Warning                      // Soft problems found while generating the version
+	Shortlog     []ShortlogEntry                // Commits since the last tag, newest first, added with --shortlog
+	Issues       []string                       // Issue keys referenced since the last tag, added with --issue-keys
+	Contributors []Contributor                  // Authors and co-authors since the last tag, added with --contributors
+	Mainline     string                         // Main branch AheadOfMain and BehindMain count against, set with --ahead-behind
+	AheadOfMain  int                            // Commits on HEAD that are not on Mainline
+	BehindMain   int                            // Commits on Mainline that are not on HEAD
+	Generator    *GeneratorInfo                 // Build of the tool and options that produced the version
+}
+
+// GeneratorInfo identifies the version-generator build and options behind an
+// output, so files produced by an outdated or misconfigured generator stand out
+type GeneratorInfo struct {
+	Version string `json:"version" yaml:"version"`
+	Commit  string `json:"commit" yaml:"commit"`
+	Options string `json:"options" yaml:"options"` // Hash of the effective options
+}
+
+// ShortlogEntry is a commit listed in the metadata output
+type ShortlogEntry struct {
+	Hash    string `json:"hash" yaml:"hash"` // Short hash
+	Author  string `json:"author" yaml:"author"`
+	Subject string `json:"subject" yaml:"subject"`
+}
+
+// Contributor is an author or Co-authored-by trailer of a commit
+type Contributor struct {
+	Name  string `json:"name" yaml:"name"`
+	Email string `json:"email" yaml:"email"`
+}
+
+// Branch is a local or remote-tracking branch, as listed by GetBranches
+type Branch struct {
+	Name string // Short name, e.g. feature/x or origin/feature/x
+	Ref  string // Full reference, e.g. refs/heads/feature/x
+}
+
+// ReleaseTag is a tag reachable from HEAD, as listed by GetReleaseTags
+type ReleaseTag struct {
+	Name    string    // Tag name without TagPrefix
+	Date    time.Time // Tagger date of annotated tags, commit date of lightweight ones
+	Commits int       // Commits since the previous release tag, or since the root for the first
+}
+
+// Warning is a soft problem that does not stop version generation but may make
+// the version misleading, such as missing history in a shallow clone
+type Warning struct {
+	Code    string `json:"code" yaml:"code"`
+	Message string `json:"message" yaml:"message"`
 }
 
+// Warning codes
+const (
+	WarningShallowClone    = "shallow-clone"    // history is truncated, so counts and tags may be wrong
+	WarningDetachedHead    = "detached-head"    // HEAD is not on a branch; the branch was inferred
+	WarningAmbiguousBranch = "ambiguous-branch" // several branches contain a detached HEAD
+	WarningMalformedTag    = "malformed-tag"    // the last tag is not a semantic version
+	WarningStaleTag        = "stale-tag"        // the last tag is older than the allowed release age
+	WarningTimeout         = "timeout"          // generation ran out of time; the version is the commit only
+	WarningUnconventional  = "unconventional"   // commits since the last tag do not follow Conventional Commits
+	WarningNoMainline      = "no-mainline"      // --ahead-behind found no main or master branch
+)
+
 // VersioningOptions defines different versioning scheme options
 // Deprecated: Use versionSchemes.VersioningOptions instead
 type VersioningOptions = versionSchemes.VersioningOptions
 
+// ErrOrphanBranch is returned for a branch without history in common with main/master
+// when OrphanPolicy is OrphanError
+var ErrOrphanBranch = errors.New("branch shares no history with main/master")
+
+// ErrNoMainline is returned when GitOptions.MergedOnly is set in a repository
+// without a main or master branch
+var ErrNoMainline = errors.New("no main or master branch to check tags against")
+
+// NoTagVersion is reported as LastTag when no tag is reachable and the
+// NoTagsZero policy applies
+const NoTagVersion = "v0.0.0"
+
+// Policies for repositories without a reachable tag
+const (
+	NoTagsZero    = "zero"    // use v0.0.0 and count all commits (default)
+	NoTagsInitial = "initial" // use GitOptions.InitialVersion and count all commits
+	NoTagsError   = "error"   // fail with ErrNoTags
+)
+
+// Orphan branch policies, applied to branches sharing no history with main/master
+const (
+	OrphanOwnTags = "own-tags" // version from the orphan branch's own tags (default)
+	OrphanCalVer  = "calver"   // version the orphan branch with Calendar Versioning
+	OrphanError   = "error"    // fail with ErrOrphanBranch
+)
+
+// Traversal strategies shared by both backends
+const (
+	TraversalAll         = "all"          // follow every parent; nearest tag by commit count (default)
+	TraversalFirstParent = "first-parent" // follow first parents only, like --first-parent
+	TraversalAuthorDate  = "author-date"  // follow every parent; newest tag by author date
+)
+
+// CommitEntry is one commit as listed by GetCommitLog
+type CommitEntry struct {
+	Hash    string
+	Author  string
+	Email   string
+	Subject string
+	Body    string // message after the subject line, trimmed
+	When    time.Time
+}
+
+// GitOptions configures how handlers resolve tags and count commits
+type GitOptions struct {
+	// MaxTagDistance is the most commits a tag may be behind, counted like the
+	// commits since the tag in the version; tag resolution searches no further (0 means unlimited)
+	MaxTagDistance int
+	// FailOnTagDistance returns ErrTagDistanceExceeded instead of falling back to v0.0.0
+	FailOnTagDistance bool
+	// NoTagsPolicy selects what happens when no tag is reachable (default NoTagsZero)
+	NoTagsPolicy string
+	// InitialVersion is the baseline used by NoTagsInitial, e.g. v0.1.0
+	InitialVersion string
+	// OrphanPolicy selects how branches without a merge-base with main/master are versioned (default OrphanOwnTags)
+	OrphanPolicy string
+	// Always reports g<hash> without a reachable tag, or <initial>+<count>.g<hash> with NoTagsInitial
+	Always bool
+	// IncludeRemoteTags also considers refs/remotes/<remote>/tags/* when resolving tags
+	IncludeRemoteTags bool
+	// NoReplaceObjects ignores refs/replace and info/grafts when walking history
+	NoReplaceObjects bool
+	// NoMaintenanceBranches versions maintenance branches such as 1.4.x like any
+	// other branch instead of from the tags of their release line
+	NoMaintenanceBranches bool
+	// Traversal selects how history is walked and the last tag is chosen (default TraversalAll)
+	Traversal string
+	// Naming holds TagPrefix, which restricts tags to those starting with it and
+	// is stripped from the reported tag, and the sanitization of branch names;
+	// versions generated with VersioningOptions use this Naming
+	versionSchemes.Naming
+	// TagMatch is a glob tags must match, e.g. tools/v[0-9]*
+	TagMatch string
+	// ExcludeTags are tag names never used as the last tag, such as a moving nightly tag
+	ExcludeTags []string
+	// MergedOnly ignores tags whose commits are not ancestors of main/master, such
+	// as tags left on abandoned branches
+	MergedOnly bool
+	// Paths restricts commit counts to commits touching these repository-relative paths
+	Paths []string
+	// ExcludePaths ignores changes below these repository-relative paths when counting
+	ExcludePaths []string
+	// Deadline stops history walks and git commands still running at that time
+	// with ErrTimeout (zero for none)
+	Deadline time.Time
+	// Context stops history walks and git commands once it is canceled, with its
+	// error, or with ErrTimeout when its deadline passes (nil for none)
+	Context context.Context
+	// LockTimeout is how long to retry, with backoff, while another process holds
+	// a git lock file such as index.lock before failing with ErrLocked (zero to
+	// fail at once)
+	LockTimeout time.Duration
+	// Auth holds the credentials the go-git backend uses for remotes
+	Auth AuthOptions
+	// Progress receives a rate-limited progress line while the go-git backend walks
+	// history (nil for none); system git runs silently
+	Progress io.Writer
+	// Revision is versioned instead of HEAD: a branch, tag or commit. The working
+	// tree is ignored, so the version is never dirty.
+	Revision string
+	// Branch is reported as the current branch instead of the one HEAD is on,
+	// e.g. the branch named by Revision
+	Branch string
+	// Resolvers replace the branch, tag and commit count steps of version
+	// generation for library users with their own rules
+	Resolvers Resolvers
+}
+
+// baseContext returns Context, or the background context without one
+func (o GitOptions) baseContext() context.Context {
+	if o.Context == nil {
+		return context.Background()
+	}
+	return o.Context
+}
+
+// interrupted returns the error of a canceled Context, or ErrTimeout once the
+// Deadline or the deadline of Context has passed
+func (o GitOptions) interrupted() error {
+	if o.Context != nil {
+		if err := o.Context.Err(); errors.Is(err, context.Canceled) {
+			return err
+		} else if err != nil {
+			return ErrTimeout
+		}
+	}
+	if !o.Deadline.IsZero() && time.Now().After(o.Deadline) {
+		return ErrTimeout
+	}
+	return nil
+}
+
 // GitHandler interface defines methods for git operations
 type GitHandler interface {
 	// GenerateVersionInfo generates version information from git repository
@@ -26,20 +241,133 @@ type GitHandler interface {
 	// GetCurrentBranch returns the current branch name
 	GetCurrentBranch() (string, error)
 
-	// GetLastTag finds the last reachable tag
-	GetLastTag(branchName string) (string, error)
+	// GetLastTag finds the last reachable tag; found is false when there is none
+	GetLastTag(branchName string) (tag string, found bool, err error)
 
-	// GetCommitsSinceTag counts commits since the specified tag
+	// GetCommitsSinceTag counts commits since the specified tag, or all commits
+	// when tagName is empty
 	GetCommitsSinceTag(tagName string) (int, error)
 
+	// GetCommitsSinceTagAfter counts commits since the specified tag, or all
+	// commits, that were committed at or after a time
+	GetCommitsSinceTagAfter(tagName string, after time.Time) (int, error)
+
+	// GetCommitsSinceRevision counts commits since any revision: a tag, branch or commit
+	GetCommitsSinceRevision(revision string) (int, error)
+
+	// GetAheadBehind counts the commits HEAD has that main (or master) lacks and
+	// the reverse, like git rev-list --left-right --count; it returns
+	// ErrNoMainline when neither branch exists
+	GetAheadBehind() (mainline string, ahead, behind int, err error)
+
 	// GetShortHash returns the short hash of current commit
 	GetShortHash() (string, error)
+
+	// GetBranches lists the local branches, or the remote-tracking ones when
+	// remote is set, sorted by name
+	GetBranches(remote bool) ([]Branch, error)
+
+	// GetTagDate returns the tagger date of an annotated tag or the commit date of
+	// a lightweight one; found is false when the tag does not exist
+	GetTagDate(tagName string) (date time.Time, found bool, err error)
+
+	// GetReleaseTags lists the tags reachable from HEAD, oldest first
+	GetReleaseTags() ([]ReleaseTag, error)
+
+	// GetTagNames lists every tag, reachable or not, without TagPrefix and sorted
+	GetTagNames() ([]string, error)
+
+	// GetWarnings reports repository conditions that make info less reliable
+	GetWarnings(info *VersionInfo) ([]Warning, error)
+
+	// Describe renders HEAD exactly like git describe --tags --dirty --always
+	Describe() (string, error)
+
+	// GetFullHash returns the full hash of current commit
+	GetFullHash() (string, error)
+
+	// GetCommitDate returns the committer date of the current commit
+	GetCommitDate() (time.Time, error)
+
+	// GetRemoteURL returns the fetch URL of a remote; found is false when the
+	// remote is not configured
+	GetRemoteURL(name string) (url string, found bool, err error)
+
+	// GetRepoRoot returns the top-level directory of the working tree
+	GetRepoRoot() (string, error)
+
+	// IsIgnored reports whether a repository-relative path matches a gitignore rule
+	IsIgnored(relPath string) (bool, error)
+
+	// IsTracked reports whether a repository-relative path is in the index
+	IsTracked(relPath string) (bool, error)
+
+	// GetCommitLog lists the commits since the specified tag, newest first, or
+	// the whole history when tagName is empty
+	GetCommitLog(tagName string) ([]CommitEntry, error)
+
+	// CreateTag creates an annotated tag at HEAD, signed when signing is not nil
+	CreateTag(name, message string, signing *SigningOptions) error
+
+	// CreateLightweightTag creates a lightweight tag at HEAD
+	CreateLightweightTag(name string) error
+
+	// MoveTag points an annotated tag at HEAD, replacing any tag of that name
+	MoveTag(name, message string) error
+
+	// FetchHistory fetches the history and tags a shallow clone is missing from
+	// origin; fetched is false when the repository is not shallow
+	FetchHistory() (fetched bool, err error)
+
+	// AppendNote appends a line to the git note of HEAD under refs/notes/<notesRef>
+	AppendNote(notesRef, line string) error
+
+	// ReadNote returns the git note of a revision under refs/notes/<notesRef>;
+	// found is false when the revision has no note
+	ReadNote(notesRef, revision string) (note string, found bool, err error)
+
+	// GetWorktreeState reports uncommitted changes to tracked files and when the index last changed
+	GetWorktreeState() (versionSchemes.WorktreeState, error)
+
+	// CommitFiles stages repository-relative paths and commits them, either as
+	// a new commit with message or by amending HEAD (message is then ignored)
+	CommitFiles(relPaths []string, message string, amend bool) error
 }
 
 // GetGitHandler returns appropriate git handler based on inBuiltGit flag
 func GetGitHandler(inBuiltGit bool, repoPath string) (GitHandler, error) {
+	return GetGitHandlerWithOptions(inBuiltGit, repoPath, GitOptions{})
+}
+
+// GetGitHandlerWithOptions returns a git handler configured with the given options
+func GetGitHandlerWithOptions(inBuiltGit bool, repoPath string, options GitOptions) (GitHandler, error) {
 	if inBuiltGit {
-		return NewGoGitHandler(repoPath)
+		handler, err := NewGoGitHandler(repoPath)
+		if err != nil {
+			return nil, err
+		}
+		handler.options = options
+		if options.MergedOnly {
+			unmerged, err := handler.unmergedTags()
+			if err != nil {
+				return nil, err
+			}
+			handler.excludeTags(unmerged)
+		}
+		return handler, nil
+	}
+
+	handler, err := NewSystemGitHandler(repoPath)
+	if err != nil {
+		return nil, err
+	}
+	handler.options = options
+	if options.MergedOnly {
+		unmerged, err := handler.unmergedTags()
+		if err != nil {
+			return nil, err
+		}
+		handler.excludeTags(unmerged)
 	}
-	return NewSystemGitHandler(repoPath)
+	return handler, nil
 }
@@ -1,24 +1,36 @@
 package gitType
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"math"
+	"os"
 	"sort"
+	"strings"
+	"time"
 	"version-generator/versionSchemes"
 
 	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+	"github.com/go-git/go-git/v5/plumbing/format/index"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/filesystem"
 )
 
 // GoGitHandler implements GitHandler using go-git library
 type GoGitHandler struct {
-	repo *git.Repository
+	repo  *git.Repository
+	graph *commitGraph
 	*BaseGitHandler
 }
 
-// NewGoGitHandler creates a new go-git handler
+// NewGoGitHandler creates a new go-git handler for the repository containing
+// repoPath, which may be any directory of its working tree, as with system git
 func NewGoGitHandler(repoPath string) (*GoGitHandler, error) {
-	repo, err := git.PlainOpen(repoPath)
+	repo, err := git.PlainOpenWithOptions(repoPath, &git.PlainOpenOptions{DetectDotGit: true})
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
 	}
@@ -31,66 +43,344 @@ func NewGoGitHandler(repoPath string) (*GoGitHandler, error) {
 
 // GenerateVersionInfo generates version information using go-git
 func (g *GoGitHandler) GenerateVersionInfo(dockerFormat bool) (*VersionInfo, error) {
+	// A freshly initialized repository has no commits to version yet
+	if branchName, unborn, err := g.unbornBranch(); err != nil || unborn {
+		if err != nil {
+			return nil, err
+		}
+		return g.unbornVersionInfo(branchName, versionSchemes.VersioningOptions{})
+	}
+
 	// Get current branch
-	branchName, err := g.GetCurrentBranch()
+	resolvers := g.resolversFor(g)
+	branchName, err := resolvers.Branch.GetCurrentBranch()
 	if err != nil {
 		return nil, err
 	}
 
+	// Orphan branches may switch to Calendar Versioning
+	if orphan, err := g.isOrphanCalVer(branchName); err != nil {
+		return nil, err
+	} else if orphan {
+		return g.GenerateVersionInfoWithOptions(versionSchemes.VersioningOptions{Scheme: versionSchemes.SchemeCalVer})
+	}
+
 	// Get short hash
 	shortHash, err := g.GetShortHash()
 	if err != nil {
 		return nil, err
 	}
 
+	// Get full hash
+	commit, err := g.GetFullHash()
+	if err != nil {
+		return nil, err
+	}
+
 	// Find the last tag
-	lastTag, err := g.GetLastTag(branchName)
+	lastTag, found, err := resolvers.Tag.GetLastTag(branchName)
 	if err != nil {
 		return nil, err
 	}
 
 	// Count commits since last tag
-	commitsSince, err := g.GetCommitsSinceTag(lastTag)
+	commitsSince, err := resolvers.Counter.GetCommitsSinceTag(lastTag)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the policy for repositories without tags
+	lastTag, err = g.applyNoTagsPolicy(g.displayTag(lastTag), found)
 	if err != nil {
 		return nil, err
 	}
 
 	// Use base handler to generate version info
-	return g.GenerateVersionInfoFromComponents(branchName, shortHash, lastTag, commitsSince, dockerFormat), nil
+	return g.applyAlways(g.GenerateVersionInfoFromComponents(branchName, commit, shortHash, lastTag, commitsSince, dockerFormat), found), nil
 }
 
 // GenerateVersionInfoWithOptions generates version information using go-git with custom options
 func (g *GoGitHandler) GenerateVersionInfoWithOptions(options versionSchemes.VersioningOptions) (*VersionInfo, error) {
+	// A freshly initialized repository has no commits to version yet
+	if branchName, unborn, err := g.unbornBranch(); err != nil || unborn {
+		if err != nil {
+			return nil, err
+		}
+		return g.unbornVersionInfo(branchName, options)
+	}
+
 	// Get current branch
-	branchName, err := g.GetCurrentBranch()
+	resolvers := g.resolversFor(g)
+	branchName, err := resolvers.Branch.GetCurrentBranch()
 	if err != nil {
 		return nil, err
 	}
 
+	// Orphan branches may switch to Calendar Versioning
+	if orphan, err := g.isOrphanCalVer(branchName); err != nil {
+		return nil, err
+	} else if orphan {
+		options.Scheme = versionSchemes.SchemeCalVer
+	}
+
 	// Get short hash
 	shortHash, err := g.GetShortHash()
 	if err != nil {
 		return nil, err
 	}
 
+	// Get full hash
+	commit, err := g.GetFullHash()
+	if err != nil {
+		return nil, err
+	}
+
 	// Find the last tag
-	lastTag, err := g.GetLastTag(branchName)
+	lastTag, found, err := resolvers.Tag.GetLastTag(branchName)
 	if err != nil {
 		return nil, err
 	}
 
 	// Count commits since last tag
-	commitsSince, err := g.GetCommitsSinceTag(lastTag)
+	commitsSince, err := resolvers.Counter.GetCommitsSinceTag(lastTag)
+	if err != nil {
+		return nil, err
+	}
+
+	// Count the commits of the current CalVer period when the counter resets
+	if periodStart, ok := options.CalVerPeriodStart(options.Now()); ok {
+		periodCommits, err := resolvers.Counter.GetCommitsSinceTagAfter(lastTag, periodStart)
+		if err != nil {
+			return nil, err
+		}
+		options.PeriodCommits = &periodCommits
+	}
+
+	// Apply the policy for repositories without tags
+	lastTag, err = g.applyNoTagsPolicy(g.displayTag(lastTag), found)
 	if err != nil {
 		return nil, err
 	}
 
+	// Describe uncommitted changes when the scheme uses them
+	if options.NeedsWorktreeState() && g.options.Revision == "" {
+		state, err := g.GetWorktreeState()
+		if err != nil {
+			return nil, err
+		}
+		options.Worktree = &state
+	}
+
 	// Use base handler to generate version info with options
-	return g.GenerateVersionInfoFromComponentsWithOptions(branchName, shortHash, lastTag, commitsSince, options), nil
+	return g.applyAlways(g.GenerateVersionInfoFromComponentsWithOptions(branchName, commit, shortHash, lastTag, commitsSince, options), found), nil
+}
+
+// GetTagDate returns the tagger date of an annotated tag or the commit date of a lightweight one
+func (g *GoGitHandler) GetTagDate(tagName string) (time.Time, bool, error) {
+	tagRefs, err := g.tagReferences()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	for _, tagRef := range tagRefs {
+		if tagRef.name != tagName {
+			continue
+		}
+		if tagObject, err := g.repo.TagObject(tagRef.ref.Hash()); err == nil {
+			return tagObject.Tagger.When, true, nil
+		}
+		commitHash, err := g.peelToCommit(tagRef.ref)
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		commit, err := g.repo.CommitObject(commitHash)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("failed to read date of %s: %w", tagName, err)
+		}
+		return commit.Committer.When, true, nil
+	}
+	return time.Time{}, false, nil
+}
+
+// GetBranches lists the local or remote-tracking branches, sorted by name
+func (g *GoGitHandler) GetBranches(remote bool) ([]Branch, error) {
+	refs, err := g.repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	var branches []Branch
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		// Skip symbolic refs such as refs/remotes/origin/HEAD
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		if (remote && ref.Name().IsRemote()) || (!remote && ref.Name().IsBranch()) {
+			branches = append(branches, Branch{Name: ref.Name().Short(), Ref: ref.Name().String()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	sort.Slice(branches, func(i, j int) bool {
+		return branches[i].Ref < branches[j].Ref
+	})
+	return branches, nil
+}
+
+// GetTagNames lists every tag, reachable or not, without TagPrefix and sorted
+func (g *GoGitHandler) GetTagNames() ([]string, error) {
+	refs, err := g.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	var names []string
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if name := ref.Name().Short(); g.tagMatches(name) {
+			names = append(names, g.displayTag(name))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetReleaseTags lists the tags reachable from HEAD, oldest first
+func (g *GoGitHandler) GetReleaseTags() ([]ReleaseTag, error) {
+	head, err := g.head()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	graph, err := g.commitGraph()
+	if err != nil {
+		return nil, err
+	}
+	reachable, err := graph.ancestors(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+
+	refs, err := g.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	type hashTag struct {
+		hash plumbing.Hash
+		ReleaseTag
+	}
+	var tags []hashTag
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if !g.tagMatches(name) {
+			return nil
+		}
+		commitHash, err := g.peelToCommit(ref)
+		if err != nil || !reachable[commitHash] {
+			return nil // Skip unreachable tags and tags pointing at non-commit objects
+		}
+		commit, err := g.repo.CommitObject(commitHash)
+		if err != nil {
+			return nil
+		}
+		date := commit.Committer.When
+		if tagObject, err := g.repo.TagObject(ref.Hash()); err == nil {
+			date = tagObject.Tagger.When
+		}
+		tags = append(tags, hashTag{hash: commitHash, ReleaseTag: ReleaseTag{Name: g.displayTag(name), Date: date}})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		if !tags[i].Date.Equal(tags[j].Date) {
+			return tags[i].Date.Before(tags[j].Date)
+		}
+		return tags[i].Name < tags[j].Name
+	})
+
+	releases := make([]ReleaseTag, len(tags))
+	for i, tag := range tags {
+		from := plumbing.ZeroHash
+		if i > 0 {
+			from = tags[i-1].hash
+		}
+		commits, err := g.rangeCommits(from, tag.hash)
+		if err != nil {
+			return nil, err
+		}
+		tag.Commits = len(commits)
+		releases[i] = tag.ReleaseTag
+	}
+	return releases, nil
+}
+
+// GetWarnings reports a shallow clone and a detached HEAD
+func (g *GoGitHandler) GetWarnings(info *VersionInfo) ([]Warning, error) {
+	var warnings []Warning
+	shallow, err := g.repo.Storer.Shallow()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for a shallow clone: %w", err)
+	}
+	if len(shallow) > 0 {
+		warnings = append(warnings, shallowCloneWarning())
+	}
+
+	// A Revision is versioned as given, so it is never a detached HEAD
+	if g.options.Revision != "" {
+		return warnings, nil
+	}
+	head, err := g.repo.Head()
+	if err != nil || head.Name().IsBranch() {
+		// An unborn HEAD is on a branch, just without commits
+		return warnings, nil
+	}
+	branches, err := g.repo.Branches()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	var containing []string
+	err = branches.ForEach(func(ref *plumbing.Reference) error {
+		found, err := g.isCommitReachable(ref.Hash(), head.Hash())
+		if err == nil && found {
+			containing = append(containing, ref.Name().Short())
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+	sort.Strings(containing)
+	return append(warnings, detachedHeadWarnings(info.Branch, containing)...), nil
+}
+
+// unbornBranch reports whether HEAD points at a branch without commits, and its name
+func (g *GoGitHandler) unbornBranch() (string, bool, error) {
+	if g.options.Revision != "" {
+		_, err := g.head()
+		return "", false, err
+	}
+	_, err := g.repo.Head()
+	if err == nil {
+		return "", false, nil
+	}
+	if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return "", false, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	head, err := g.repo.Reference(plumbing.HEAD, false)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	return head.Target().Short(), true, nil
 }
 
 // GetCurrentBranch returns the current branch name
 func (g *GoGitHandler) GetCurrentBranch() (string, error) {
+	if g.options.Branch != "" {
+		return g.options.Branch, nil
+	}
 	head, err := g.repo.Head()
 	if err != nil {
 		return "", fmt.Errorf("failed to get HEAD: %w", err)
@@ -112,24 +402,11 @@ func (g *GoGitHandler) GetCurrentBranch() (string, error) {
 	err = refs.ForEach(func(ref *plumbing.Reference) error {
 		if ref.Name().IsBranch() {
 			// Check if this branch contains the current commit
-			branchCommit, err := g.repo.CommitObject(ref.Hash())
+			found, err := g.isCommitReachable(ref.Hash(), currentHash)
 			if err != nil {
 				return nil // Continue to next branch
 			}
 
-			// Walk through the branch history to see if it contains our commit
-			iter := object.NewCommitPreorderIter(branchCommit, nil, nil)
-			defer iter.Close()
-
-			found := false
-			iter.ForEach(func(c *object.Commit) error {
-				if c.Hash == currentHash {
-					found = true
-					return fmt.Errorf("found") // Break the loop
-				}
-				return nil
-			})
-
 			if found {
 				return fmt.Errorf("branch:%s", ref.Name().Short()) // Break and return this branch
 			}
@@ -149,293 +426,1027 @@ func (g *GoGitHandler) GetCurrentBranch() (string, error) {
 
 // GetShortHash returns the short hash of current commit
 func (g *GoGitHandler) GetShortHash() (string, error) {
-	head, err := g.repo.Head()
+	head, err := g.head()
 	if err != nil {
 		return "", fmt.Errorf("failed to get HEAD: %w", err)
 	}
 	return head.Hash().String()[:7], nil
 }
 
-// GetLastTag finds the last reachable tag
-func (g *GoGitHandler) GetLastTag(branchName string) (string, error) {
-	head, err := g.repo.Head()
+// GetFullHash returns the full hash of current commit
+func (g *GoGitHandler) GetFullHash() (string, error) {
+	head, err := g.head()
 	if err != nil {
 		return "", fmt.Errorf("failed to get HEAD: %w", err)
 	}
+	return head.Hash().String(), nil
+}
 
-	// For non-main/master branches, find tags from the rebase point
-	if branchName != "main" && branchName != "master" {
-		return g.findTagFromRebasePoint(head.Hash(), branchName)
+// GetCommitDate returns the committer date of the current commit
+func (g *GoGitHandler) GetCommitDate() (time.Time, error) {
+	head, err := g.head()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get HEAD: %w", err)
 	}
-
-	// For main/master branches, use the original logic
-	return g.findTagFromCurrentBranch(head.Hash())
+	commit, err := g.repo.CommitObject(head.Hash())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get commit date: %w", err)
+	}
+	return commit.Committer.When, nil
 }
 
-// GetCommitsSinceTag counts commits since the specified tag
-func (g *GoGitHandler) GetCommitsSinceTag(tagName string) (int, error) {
-	head, err := g.repo.Head()
+// GetRemoteURL returns the fetch URL of a remote
+func (g *GoGitHandler) GetRemoteURL(name string) (string, bool, error) {
+	remote, err := g.repo.Remote(name)
+	if errors.Is(err, git.ErrRemoteNotFound) {
+		return "", false, nil
+	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to get HEAD: %w", err)
+		return "", false, fmt.Errorf("failed to read the URL of %s: %w", name, err)
 	}
+	if urls := remote.Config().URLs; len(urls) > 0 {
+		return urls[0], true, nil
+	}
+	return "", false, nil
+}
 
-	if tagName == "v0.0.0" {
-		// Count all commits if no tag exists
-		return g.countAllCommits(head.Hash())
+// GetRepoRoot returns the top-level directory of the working tree
+func (g *GoGitHandler) GetRepoRoot() (string, error) {
+	worktree, err := g.repo.Worktree()
+	if err != nil {
+		return "", fmt.Errorf("failed to get worktree: %w", err)
 	}
+	return worktree.Filesystem.Root(), nil
+}
 
-	// Find the tag commit hash
-	tagRef, err := g.repo.Tag(tagName)
+// IsIgnored reports whether a repository-relative path matches a gitignore rule
+func (g *GoGitHandler) IsIgnored(relPath string) (bool, error) {
+	worktree, err := g.repo.Worktree()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get tag reference: %w", err)
+		return false, fmt.Errorf("failed to get worktree: %w", err)
 	}
 
-	var tagCommitHash plumbing.Hash
-	obj, err := g.repo.Object(plumbing.AnyObject, tagRef.Hash())
+	patterns, err := gitignore.ReadPatterns(worktree.Filesystem, nil)
 	if err != nil {
-		return 0, err
+		return false, fmt.Errorf("failed to read gitignore patterns: %w", err)
 	}
+	patterns = append(patterns, worktree.Excludes...)
+
+	matcher := gitignore.NewMatcher(patterns)
+	return matcher.Match(strings.Split(relPath, "/"), false), nil
+}
 
-	switch o := obj.(type) {
-	case *object.Tag:
-		tagCommitHash = o.Target
-	case *object.Commit:
-		tagCommitHash = tagRef.Hash()
-	default:
-		return 0, fmt.Errorf("tag points to unsupported object type")
+// IsTracked reports whether a repository-relative path is in the index
+func (g *GoGitHandler) IsTracked(relPath string) (bool, error) {
+	idx, err := g.repo.Storer.Index()
+	if err != nil {
+		return false, fmt.Errorf("failed to read index: %w", err)
 	}
 
-	if head.Hash() == tagCommitHash {
-		return 0, nil
+	_, err = idx.Entry(relPath)
+	if errors.Is(err, index.ErrEntryNotFound) {
+		return false, nil
 	}
+	return err == nil, err
+}
 
-	// Count commits between current and tag
-	commit, err := g.repo.CommitObject(head.Hash())
+// GetCommitLog lists the commits since the specified tag, newest first
+func (g *GoGitHandler) GetCommitLog(tagName string) ([]CommitEntry, error) {
+	head, err := g.head()
 	if err != nil {
-		return 0, err
+		return nil, fmt.Errorf("failed to get HEAD: %w", err)
 	}
 
-	count := 0
-	iter := object.NewCommitPreorderIter(commit, nil, nil)
-	defer iter.Close()
-
-	err = iter.ForEach(func(c *object.Commit) error {
-		if c.Hash == tagCommitHash {
-			return fmt.Errorf("found tag") // Break the loop
+	from := plumbing.ZeroHash
+	if tagName != "" {
+		if from, err = g.resolveTagCommit(tagName); err != nil {
+			return nil, err
 		}
-		count++
-		return nil
-	})
+	}
 
-	if err != nil && err.Error() != "found tag" {
-		return 0, err
+	hashes, err := g.rangeCommits(from, head.Hash())
+	if err != nil {
+		return nil, err
 	}
 
-	return count, nil
+	entries := make([]CommitEntry, 0, len(hashes))
+	for _, hash := range hashes {
+		commit, err := g.repo.CommitObject(hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		subject, body, _ := strings.Cut(commit.Message, "\n")
+		entries = append(entries, CommitEntry{
+			Hash:    hash.String(),
+			Author:  commit.Author.Name,
+			Email:   commit.Author.Email,
+			Subject: subject,
+			Body:    strings.TrimSpace(body),
+			When:    commit.Committer.When,
+		})
+	}
+
+	// Match git log's default ordering by commit date
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].When.After(entries[j].When)
+	})
+	return entries, nil
 }
 
-// findTagFromRebasePoint finds tags from the rebase point for feature branches
-func (g *GoGitHandler) findTagFromRebasePoint(commitHash plumbing.Hash, branchName string) (string, error) {
-	// Try to find main or master branch
-	var mainBranch *plumbing.Reference
+// CreateTag creates an annotated tag at HEAD, signed when signing is not nil
+func (g *GoGitHandler) CreateTag(name, message string, signing *SigningOptions) error {
+	if err := g.waitForLocks(plumbing.NewTagReferenceName(name).String(), "packed-refs"); err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	head, err := g.repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
 
-	// Try to get main branch first
-	mainRef, err := g.repo.Reference(plumbing.NewBranchReferenceName("main"), true)
-	if err == nil {
-		mainBranch = mainRef
-	} else {
-		// Try master branch
-		masterRef, err := g.repo.Reference(plumbing.NewBranchReferenceName("master"), true)
-		if err == nil {
-			mainBranch = masterRef
+	tagger := g.configSignature()
+	if tagger == nil {
+		return fmt.Errorf("failed to create tag %s: user.name and user.email are not configured", name)
+	}
+
+	if signing == nil {
+		_, err = g.repo.CreateTag(name, head.Hash(), &git.CreateTagOptions{Tagger: tagger, Message: message})
+		if err != nil {
+			return fmt.Errorf("failed to create tag %s: %w", name, err)
 		}
+		return nil
 	}
 
-	if mainBranch == nil {
-		// If no main/master branch found, fall back to current branch logic
-		return g.findTagFromCurrentBranch(commitHash)
+	if _, err := g.repo.Reference(plumbing.NewTagReferenceName(name), false); err == nil {
+		return fmt.Errorf("failed to create tag %s: %w", name, git.ErrTagExists)
 	}
 
-	// Find common ancestor between current branch and main/master
-	commonAncestor, err := g.findCommonAncestor(commitHash, mainBranch.Hash())
+	cfg, err := g.repo.ConfigScoped(config.GlobalScope)
 	if err != nil {
-		// If can't find common ancestor, fall back to current branch logic
-		return g.findTagFromCurrentBranch(commitHash)
+		return fmt.Errorf("failed to read git config: %w", err)
+	}
+	sign, err := newTagSigner(cfg.Raw.Section("gpg").Option("format"), cfg.Raw.Section("user").Option("signingkey"), signing)
+	if err != nil {
+		return fmt.Errorf("failed to sign tag %s: %w", name, err)
 	}
 
-	// Find tags reachable from the common ancestor
-	return g.findTagFromCurrentBranch(commonAncestor)
-}
+	if !strings.HasSuffix(message, "\n") {
+		message += "\n"
+	}
+	tag := &object.Tag{
+		Name:       name,
+		Tagger:     *tagger,
+		Message:    message,
+		TargetType: plumbing.CommitObject,
+		Target:     head.Hash(),
+	}
 
-// findCommonAncestor finds the common ancestor between two commits
-func (g *GoGitHandler) findCommonAncestor(commit1, commit2 plumbing.Hash) (plumbing.Hash, error) {
-	if commit1 == commit2 {
-		return commit1, nil
+	unsigned := g.repo.Storer.NewEncodedObject()
+	if err := tag.EncodeWithoutSignature(unsigned); err != nil {
+		return fmt.Errorf("failed to encode tag %s: %w", name, err)
+	}
+	reader, err := unsigned.Reader()
+	if err != nil {
+		return fmt.Errorf("failed to encode tag %s: %w", name, err)
+	}
+	payload, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return fmt.Errorf("failed to encode tag %s: %w", name, err)
+	}
+
+	if tag.PGPSignature, err = sign(payload); err != nil {
+		return fmt.Errorf("failed to sign tag %s: %w", name, err)
 	}
 
-	// Get commits for both hashes
-	c1, err := g.repo.CommitObject(commit1)
+	signed := g.repo.Storer.NewEncodedObject()
+	if err := tag.Encode(signed); err != nil {
+		return fmt.Errorf("failed to encode tag %s: %w", name, err)
+	}
+	hash, err := g.repo.Storer.SetEncodedObject(signed)
 	if err != nil {
-		return plumbing.ZeroHash, err
+		return fmt.Errorf("failed to store tag %s: %w", name, err)
 	}
+	return g.repo.Storer.SetReference(plumbing.NewHashReference(plumbing.NewTagReferenceName(name), hash))
+}
 
-	c2, err := g.repo.CommitObject(commit2)
+// CreateLightweightTag creates a lightweight tag at HEAD
+func (g *GoGitHandler) CreateLightweightTag(name string) error {
+	if err := g.waitForLocks(plumbing.NewTagReferenceName(name).String(), "packed-refs"); err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	head, err := g.repo.Head()
 	if err != nil {
-		return plumbing.ZeroHash, err
+		return fmt.Errorf("failed to get HEAD: %w", err)
 	}
+	if _, err := g.repo.CreateTag(name, head.Hash(), nil); err != nil {
+		return fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	return nil
+}
 
-	// Create a map of all ancestors of commit2
-	ancestorsMap := make(map[plumbing.Hash]bool)
-	iter := object.NewCommitPreorderIter(c2, nil, nil)
-	defer iter.Close()
+// MoveTag points an annotated tag at HEAD, replacing any tag of that name
+func (g *GoGitHandler) MoveTag(name, message string) error {
+	if err := g.waitForLocks(plumbing.NewTagReferenceName(name).String(), "packed-refs"); err != nil {
+		return fmt.Errorf("failed to move tag %s: %w", name, err)
+	}
+	err := g.repo.DeleteTag(name)
+	if err != nil && !errors.Is(err, git.ErrTagNotFound) {
+		return fmt.Errorf("failed to move tag %s: %w", name, err)
+	}
+	return g.CreateTag(name, message, nil)
+}
 
-	err = iter.ForEach(func(c *object.Commit) error {
-		ancestorsMap[c.Hash] = true
-		return nil
-	})
+// FetchHistory fetches the full history and all tags from origin when the
+// repository is a shallow clone, authenticating with GitOptions.Auth
+func (g *GoGitHandler) FetchHistory() (bool, error) {
+	shallow, err := g.repo.Storer.Shallow()
 	if err != nil {
-		return plumbing.ZeroHash, err
+		return false, fmt.Errorf("failed to check for a shallow clone: %w", err)
+	}
+	if len(shallow) == 0 {
+		return false, nil
 	}
 
-	// Walk through ancestors of commit1 to find first common ancestor
-	iter1 := object.NewCommitPreorderIter(c1, nil, nil)
-	defer iter1.Close()
-
-	var commonAncestor plumbing.Hash
-	err = iter1.ForEach(func(c *object.Commit) error {
-		if ancestorsMap[c.Hash] {
-			commonAncestor = c.Hash
-			return fmt.Errorf("found common ancestor") // Break the loop
-		}
-		return nil
+	remote, err := g.repo.Remote("origin")
+	if err != nil {
+		return false, fmt.Errorf("failed to find origin: %w", err)
+	}
+	url := remote.Config().URLs[0]
+	auth, err := authFor(url, g.options.Auth)
+	if err != nil {
+		return false, err
+	}
+	err = remote.FetchContext(g.options.baseContext(), &git.FetchOptions{
+		Auth: auth,
+		Tags: git.AllTags,
+		// git fetch --unshallow asks for this depth too
+		Depth: math.MaxInt32,
 	})
-
-	if err != nil && err.Error() == "found common ancestor" {
-		return commonAncestor, nil
+	if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+		return false, fmt.Errorf("failed to fetch from %s: %s", RedactURL(url), RedactURL(err.Error()))
 	}
 
-	return plumbing.ZeroHash, fmt.Errorf("no common ancestor found")
+	// go-git ignores the unshallow lines of the reply, but at this depth no
+	// commit is left shallow. Like git, drop the shallow file rather than
+	// leaving it empty, which git would still treat as a shallow clone.
+	if storage, ok := g.repo.Storer.(*filesystem.Storage); ok {
+		err = storage.Filesystem().Remove("shallow")
+	} else {
+		err = g.repo.Storer.SetShallow(nil)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, fmt.Errorf("failed to update the shallow commits: %w", err)
+	}
+	return true, nil
 }
 
-// findTagFromCurrentBranch finds tags reachable from current branch
-func (g *GoGitHandler) findTagFromCurrentBranch(commitHash plumbing.Hash) (string, error) {
-	// Get all tags
-	tagRefs, err := g.repo.Tags()
+// GetWorktreeState reports uncommitted changes to tracked files and when the index last changed
+func (g *GoGitHandler) GetWorktreeState() (versionSchemes.WorktreeState, error) {
+	var state versionSchemes.WorktreeState
+
+	worktree, err := g.repo.Worktree()
+	if err != nil {
+		return state, fmt.Errorf("failed to get worktree: %w", err)
+	}
+	status, err := worktree.Status()
 	if err != nil {
-		return "", fmt.Errorf("failed to get tags: %w", err)
+		return state, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+	for _, fileStatus := range status {
+		if isChange(fileStatus.Staging) || isChange(fileStatus.Worktree) {
+			state.Dirty = true
+			break
+		}
 	}
 
-	var tags []struct {
-		name string
-		hash plumbing.Hash
-		time int64
+	if storage, ok := g.repo.Storer.(*filesystem.Storage); ok {
+		if info, err := storage.Filesystem().Stat("index"); err == nil {
+			state.IndexTime = info.ModTime()
+		}
 	}
+	return state, nil
+}
 
-	err = tagRefs.ForEach(func(ref *plumbing.Reference) error {
-		tagName := ref.Name().Short()
+// isChange reports whether a status code describes a change to a tracked file
+func isChange(code git.StatusCode) bool {
+	return code != git.Unmodified && code != git.Untracked
+}
 
-		// Get the commit that the tag points to
-		var tagCommitHash plumbing.Hash
+// CommitFiles stages and commits the given paths. Unlike system git, any other
+// changes already staged in the index are committed as well.
+func (g *GoGitHandler) CommitFiles(relPaths []string, message string, amend bool) error {
+	locks := []string{"index", "HEAD"}
+	if head, err := g.repo.Reference(plumbing.HEAD, false); err == nil && head.Type() == plumbing.SymbolicReference {
+		locks = append(locks, head.Target().String())
+	}
+	if err := g.waitForLocks(locks...); err != nil {
+		return fmt.Errorf("failed to commit files: %w", err)
+	}
+	worktree, err := g.repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to get worktree: %w", err)
+	}
 
-		// Check if it's an annotated tag
-		obj, err := g.repo.Object(plumbing.AnyObject, ref.Hash())
-		if err != nil {
-			return err
+	for _, relPath := range relPaths {
+		if _, err := worktree.Add(relPath); err != nil {
+			return fmt.Errorf("failed to stage %s: %w", relPath, err)
 		}
+	}
 
-		switch o := obj.(type) {
-		case *object.Tag:
-			// Annotated tag
-			tagCommitHash = o.Target
-		case *object.Commit:
-			// Lightweight tag
-			tagCommitHash = ref.Hash()
-		default:
-			return nil // Skip non-commit/tag objects
+	options := &git.CommitOptions{}
+	if amend {
+		// go-git's own Amend option reuses the HEAD tree, so rebuild the
+		// commit from the index on top of HEAD's parents instead
+		head, err := g.repo.Head()
+		if err != nil {
+			return fmt.Errorf("failed to get HEAD: %w", err)
 		}
-
-		// Check if this tag is reachable from the current commit
-		isReachable, err := g.isCommitReachable(commitHash, tagCommitHash)
+		headCommit, err := g.repo.CommitObject(head.Hash())
 		if err != nil {
 			return err
 		}
+		if len(headCommit.ParentHashes) == 0 {
+			return fmt.Errorf("cannot amend the root commit with the built-in git backend")
+		}
 
-		if isReachable {
-			commit, err := g.repo.CommitObject(tagCommitHash)
+		author := headCommit.Author
+		options.Author = &author
+		options.Committer = g.configSignature()
+		options.Parents = headCommit.ParentHashes
+		options.AllowEmptyCommits = true
+		message = headCommit.Message
+	}
+
+	if _, err := worktree.Commit(message, options); err != nil {
+		return fmt.Errorf("failed to commit files: %w", err)
+	}
+	return nil
+}
+
+// configSignature returns a signature for the configured user, or nil to let
+// go-git fall back to the author
+func (g *GoGitHandler) configSignature() *object.Signature {
+	cfg, err := g.repo.ConfigScoped(config.GlobalScope)
+	if err != nil || cfg.User.Name == "" || cfg.User.Email == "" {
+		return nil
+	}
+	return &object.Signature{Name: cfg.User.Name, Email: cfg.User.Email, When: time.Now()}
+}
+
+// GetLastTag finds the last reachable tag
+func (g *GoGitHandler) GetLastTag(branchName string) (string, bool, error) {
+	head, err := g.head()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	// For non-main/master branches, find tags from the rebase point;
+	// maintenance branches carry their own release tags
+	match, maintenance := g.tagMatchFor(branchName)
+	if !isMainline(branchName) && !maintenance {
+		return g.findTagFromRebasePoint(head.Hash(), branchName)
+	}
+
+	// For main/master branches, use the original logic
+	return g.findTagFromCurrentBranch(head.Hash(), match)
+}
+
+// GetCommitsSinceTag counts commits since the specified tag
+func (g *GoGitHandler) GetCommitsSinceTag(tagName string) (int, error) {
+	head, err := g.head()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	// Count all commits if no tag exists
+	tagCommitHash := plumbing.ZeroHash
+	if tagName != "" {
+		// Find the tag commit hash
+		tagCommitHash, err = g.resolveTagCommit(tagName)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if head.Hash() == tagCommitHash {
+		return 0, nil
+	}
+
+	// Count commits between current and tag
+	commits, err := g.rangeCommits(tagCommitHash, head.Hash())
+	return len(commits), err
+}
+
+// GetCommitsSinceTagAfter counts commits since the specified tag made at or after a time
+func (g *GoGitHandler) GetCommitsSinceTagAfter(tagName string, after time.Time) (int, error) {
+	head, err := g.head()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	tagCommitHash := plumbing.ZeroHash
+	if tagName != "" {
+		if tagCommitHash, err = g.resolveTagCommit(tagName); err != nil {
+			return 0, err
+		}
+	}
+	if head.Hash() == tagCommitHash {
+		return 0, nil
+	}
+
+	commits, err := g.rangeCommits(tagCommitHash, head.Hash())
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	for _, hash := range commits {
+		commit, err := g.repo.CommitObject(hash)
+		if err != nil {
+			return 0, fmt.Errorf("failed to read commit %s: %w", hash, err)
+		}
+		if !commit.Committer.When.Before(after) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// GetAheadBehind counts the commits HEAD and main/master have that the other lacks
+func (g *GoGitHandler) GetAheadBehind() (string, int, int, error) {
+	mainline, ok := g.mainlineBranch()
+	if !ok {
+		return "", 0, 0, ErrNoMainline
+	}
+	head, err := g.head()
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	graph, err := g.commitGraph()
+	if err != nil {
+		return "", 0, 0, err
+	}
+	ahead, err := graph.countRange(mainline.Hash(), head.Hash())
+	if err != nil {
+		return "", 0, 0, err
+	}
+	behind, err := graph.countRange(head.Hash(), mainline.Hash())
+	if err != nil {
+		return "", 0, 0, err
+	}
+	return mainline.Name().Short(), ahead, behind, nil
+}
+
+// head returns HEAD, or the commit of GitOptions.Revision as a hash reference
+func (g *GoGitHandler) head() (*plumbing.Reference, error) {
+	if g.options.Revision == "" {
+		return g.repo.Head()
+	}
+	hash, err := g.repo.ResolveRevision(plumbing.Revision(g.options.Revision))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", g.options.Revision, err)
+	}
+	return plumbing.NewHashReference(plumbing.HEAD, *hash), nil
+}
+
+// GetCommitsSinceRevision counts commits since any revision: a tag, branch or commit
+func (g *GoGitHandler) GetCommitsSinceRevision(revision string) (int, error) {
+	head, err := g.head()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	from, err := g.repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve %s: %w", revision, err)
+	}
+
+	commits, err := g.rangeCommits(*from, head.Hash())
+	return len(commits), err
+}
+
+// rangeCommits lists the commits reachable from to but not from from that
+// touch the selected paths, nearest first. A zero from covers all history.
+func (g *GoGitHandler) rangeCommits(from, to plumbing.Hash) ([]plumbing.Hash, error) {
+	graph, err := g.commitGraph()
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := graph.rangeCommits(from, to)
+	if err != nil || !g.hasPathFilter() {
+		return hashes, err
+	}
+
+	selected := hashes[:0]
+	for _, hash := range hashes {
+		touches, err := g.touchesSelectedPaths(graph, hash)
+		if err != nil {
+			return nil, err
+		}
+		if touches {
+			selected = append(selected, hash)
+		}
+	}
+	return selected, nil
+}
+
+// touchesSelectedPaths reports whether a commit changes a selected path
+// relative to each of its parents, mirroring git rev-list -- <paths>: a merge
+// that leaves the paths as one of its parents had them is not counted
+func (g *GoGitHandler) touchesSelectedPaths(graph *commitGraph, hash plumbing.Hash) (bool, error) {
+	commit, err := g.repo.CommitObject(hash)
+	if err != nil {
+		return false, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return false, err
+	}
+	parents, err := graph.parents(hash)
+	if err != nil {
+		return false, err
+	}
+
+	// A root commit is compared against the empty tree
+	parentTrees := []*object.Tree{nil}
+	if len(parents) > 0 {
+		parentTrees = parentTrees[:0]
+		for _, parent := range parents {
+			parentCommit, err := g.repo.CommitObject(parent)
+			if err != nil {
+				return false, err
+			}
+			parentTree, err := parentCommit.Tree()
 			if err != nil {
-				return err
+				return false, err
 			}
+			parentTrees = append(parentTrees, parentTree)
+		}
+	}
+
+	for _, parentTree := range parentTrees {
+		changes, err := object.DiffTree(parentTree, tree)
+		if err != nil {
+			return false, fmt.Errorf("failed to diff %s: %w", hash, err)
+		}
+		touched := false
+		for _, change := range changes {
+			from, to := change.From.Name, change.To.Name
+			if (from != "" && g.pathSelected(from)) || (to != "" && g.pathSelected(to)) {
+				touched = true
+				break
+			}
+		}
+		if !touched {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// findTagFromRebasePoint finds tags from the rebase point for feature branches
+func (g *GoGitHandler) findTagFromRebasePoint(commitHash plumbing.Hash, branchName string) (string, bool, error) {
+	commonAncestor, hasMainline, err := g.mergeBaseWithMainline(commitHash)
+	if err != nil {
+		return "", false, err
+	}
+	if !hasMainline {
+		// If no main/master branch found, fall back to current branch logic
+		return g.findTagFromCurrentBranch(commitHash, g.options.TagMatch)
+	}
+	if commonAncestor.IsZero() {
+		// An orphan branch (e.g. gh-pages) can only be versioned from its own tags
+		if err := g.checkOrphanPolicy(branchName); err != nil {
+			return "", false, err
+		}
+		return g.findTagFromCurrentBranch(commitHash, g.options.TagMatch)
+	}
+
+	// Find tags reachable from the common ancestor
+	return g.findTagFromCurrentBranch(commonAncestor, g.options.TagMatch)
+}
+
+// mergeBaseWithMainline returns the common ancestor of commitHash and main, or
+// master when there is no main. hasMainline is false when neither exists; a
+// zero base with hasMainline set means commitHash is on an orphan branch.
+func (g *GoGitHandler) mergeBaseWithMainline(commitHash plumbing.Hash) (base plumbing.Hash, hasMainline bool, err error) {
+	mainline, ok := g.mainlineBranch()
+	if !ok {
+		return plumbing.ZeroHash, false, nil
+	}
+	base, err = g.findCommonAncestor(commitHash, mainline.Hash())
+	if errors.Is(err, errNoCommonAncestor) {
+		return plumbing.ZeroHash, true, nil
+	}
+	return base, true, err
+}
+
+// mainlineBranch returns the main branch, or master when there is no main
+func (g *GoGitHandler) mainlineBranch() (*plumbing.Reference, bool) {
+	for _, mainline := range []string{"main", "master"} {
+		if ref, err := g.repo.Reference(plumbing.NewBranchReferenceName(mainline), true); err == nil {
+			return ref, true
+		}
+	}
+	return nil, false
+}
+
+// unmergedTags lists the tags whose commits are not ancestors of main/master
+func (g *GoGitHandler) unmergedTags() ([]string, error) {
+	mainline, ok := g.mainlineBranch()
+	if !ok {
+		return nil, ErrNoMainline
+	}
+	graph, err := g.commitGraph()
+	if err != nil {
+		return nil, err
+	}
+	merged, err := graph.ancestors(mainline.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", mainline.Name().Short(), err)
+	}
+	tagRefs, err := g.tagReferences()
+	if err != nil {
+		return nil, err
+	}
 
-			tags = append(tags, struct {
-				name string
-				hash plumbing.Hash
-				time int64
-			}{
-				name: tagName,
-				hash: tagCommitHash,
-				time: commit.Committer.When.Unix(),
-			})
+	var tags []string
+	for _, tagRef := range tagRefs {
+		commitHash, err := g.peelToCommit(tagRef.ref)
+		if err == nil && !merged[commitHash] {
+			tags = append(tags, tagRef.name)
 		}
+	}
+	return tags, nil
+}
+
+// isOrphanCalVer reports whether HEAD is on an orphan branch versioned with OrphanCalVer
+func (g *GoGitHandler) isOrphanCalVer(branchName string) (bool, error) {
+	if g.options.OrphanPolicy != OrphanCalVer || isMainline(branchName) {
+		return false, nil
+	}
+	head, err := g.head()
+	if err != nil {
+		return false, fmt.Errorf("failed to get HEAD: %w", err)
+	}
+	base, hasMainline, err := g.mergeBaseWithMainline(head.Hash())
+	return hasMainline && base.IsZero(), err
+}
+
+// findCommonAncestor finds the common ancestor between two commits
+func (g *GoGitHandler) findCommonAncestor(commit1, commit2 plumbing.Hash) (plumbing.Hash, error) {
+	if commit1 == commit2 {
+		return commit1, nil
+	}
+
+	graph, err := g.commitGraph()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	// Create a map of all ancestors of commit2
+	ancestorsMap, err := graph.ancestors(commit2)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
 
+	// Walk through ancestors of commit1 to find first common ancestor; like git
+	// merge-base, every parent is followed whatever the traversal
+	var commonAncestor plumbing.Hash
+	err = graph.walkAll(commit1, func(hash plumbing.Hash) error {
+		if ancestorsMap[hash] {
+			commonAncestor = hash
+			return errStopWalk
+		}
 		return nil
 	})
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
 
+	if commonAncestor.IsZero() {
+		return plumbing.ZeroHash, errNoCommonAncestor
+	}
+	return commonAncestor, nil
+}
+
+// findTagFromCurrentBranch finds tags reachable from current branch that match
+// match in place of TagMatch
+func (g *GoGitHandler) findTagFromCurrentBranch(commitHash plumbing.Hash, match string) (string, bool, error) {
+	// With a distance limit, only commits within that many generations can be
+	// that many commits away, so the others are never candidates
+	var withinDistance map[plumbing.Hash]bool
+	truncated := false
+	if g.options.MaxTagDistance > 0 {
+		var err error
+		withinDistance, truncated, err = g.commitsWithinDistance(commitHash, g.options.MaxTagDistance)
+		if err != nil {
+			return "", false, err
+		}
+	}
+
+	// Get all tags
+	tagRefs, err := g.tagReferences()
 	if err != nil {
-		return "", err
+		return "", false, err
+	}
+
+	var tags []tagCandidate
+	for _, tagRef := range tagRefs {
+		if !g.tagMatchesGlob(tagRef.name, match) {
+			continue
+		}
+
+		// Get the commit that the tag points to, peeling annotated tags
+		tagCommitHash, err := g.peelToCommit(tagRef.ref)
+		if err != nil {
+			continue // Skip tags pointing at non-commit objects
+		}
+
+		// Check if this tag is reachable from the current commit
+		var isReachable bool
+		if withinDistance != nil {
+			isReachable = withinDistance[tagCommitHash]
+		} else {
+			isReachable, err = g.isCommitTraversed(commitHash, tagCommitHash)
+			if err != nil {
+				return "", false, err
+			}
+		}
+
+		if isReachable {
+			commit, err := g.repo.CommitObject(tagCommitHash)
+			if err != nil {
+				return "", false, err
+			}
+			candidate := tagCandidate{
+				name:       tagRef.name,
+				hash:       tagCommitHash,
+				time:       commit.Committer.When.Unix(),
+				authorTime: commit.Author.When.Unix(),
+			}
+			if tagObject, err := g.repo.TagObject(tagRef.ref.Hash()); err == nil {
+				candidate.annotated = true
+				candidate.taggerTime = tagObject.Tagger.When.Unix()
+			}
+			tags = append(tags, candidate)
+		}
 	}
 
+	if withinDistance != nil {
+		if tags, err = g.tagsWithinDistance(tags, commitHash); err != nil {
+			return "", false, err
+		}
+	}
 	if len(tags) == 0 {
-		return "v0.0.0", nil // No tags found
+		if truncated {
+			return g.tagDistanceExceeded()
+		}
+		return "", false, nil // No tags found
+	}
+
+	if g.options.Traversal != TraversalAuthorDate {
+		tags = preferredTagPerCommit(tags)
+	}
+	if err := g.rankTags(tags, commitHash); err != nil {
+		return "", false, err
+	}
+	return tags[0].name, true, nil
+}
+
+// tagCandidate is a reachable tag considered by findTagFromCurrentBranch
+type tagCandidate struct {
+	name       string
+	hash       plumbing.Hash
+	time       int64
+	authorTime int64
+	distance   int
+	annotated  bool
+	taggerTime int64
+}
+
+// preferredTagPerCommit keeps one tag per commit, chosen like git describe:
+// annotated tags win over lightweight ones, then the newest tagger date, then
+// the first name in ref order
+func preferredTagPerCommit(tags []tagCandidate) []tagCandidate {
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].name < tags[j].name })
+
+	best := make(map[plumbing.Hash]int, len(tags))
+	var kept []tagCandidate
+	for _, tag := range tags {
+		i, seen := best[tag.hash]
+		switch {
+		case !seen:
+			best[tag.hash] = len(kept)
+			kept = append(kept, tag)
+		case tag.annotated && !kept[i].annotated,
+			tag.annotated && kept[i].annotated && tag.taggerTime > kept[i].taggerTime:
+			kept[i] = tag
+		}
 	}
+	return kept
+}
 
-	// Sort tags by commit time (newest first)
-	sort.Slice(tags, func(i, j int) bool {
+// rankTags orders candidates so the tag system git would pick comes first:
+// the newest author date for TraversalAuthorDate, otherwise the fewest commits
+// between the tag and start, like git describe, with newer commits winning ties
+func (g *GoGitHandler) rankTags(tags []tagCandidate, start plumbing.Hash) error {
+	if g.options.Traversal == TraversalAuthorDate {
+		sort.SliceStable(tags, func(i, j int) bool {
+			if tags[i].authorTime != tags[j].authorTime {
+				return tags[i].authorTime > tags[j].authorTime
+			}
+			return tags[i].name > tags[j].name
+		})
+		return nil
+	}
+
+	graph, err := g.commitGraph()
+	if err != nil {
+		return err
+	}
+	for i := range tags {
+		tags[i].distance, err = graph.countRange(tags[i].hash, start)
+		if err != nil {
+			return err
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		if tags[i].distance != tags[j].distance {
+			return tags[i].distance < tags[j].distance
+		}
 		return tags[i].time > tags[j].time
 	})
-
-	return tags[0].name, nil
+	return nil
 }
 
-// isCommitReachable checks if a commit is reachable from another commit
-func (g *GoGitHandler) isCommitReachable(from, to plumbing.Hash) (bool, error) {
-	if from == to {
-		return true, nil
+// tagsWithinDistance keeps the candidates at most MaxTagDistance commits behind
+// start, counted like the commits since the tag in the version
+func (g *GoGitHandler) tagsWithinDistance(tags []tagCandidate, start plumbing.Hash) ([]tagCandidate, error) {
+	graph, err := g.commitGraph()
+	if err != nil {
+		return nil, err
 	}
+	var kept []tagCandidate
+	for _, tag := range tags {
+		distance, err := graph.countRange(tag.hash, start)
+		if err != nil {
+			return nil, err
+		}
+		if distance <= g.options.MaxTagDistance {
+			kept = append(kept, tag)
+		}
+	}
+	return kept, nil
+}
 
-	// Get commit iterator from 'from' commit
-	commit, err := g.repo.CommitObject(from)
+// commitsWithinDistance collects the commits at most maxDistance parent steps
+// away from start. truncated reports whether more than maxDistance+1 commits
+// are reachable, so a tag that is not within the distance may exist beyond it.
+func (g *GoGitHandler) commitsWithinDistance(start plumbing.Hash, maxDistance int) (map[plumbing.Hash]bool, bool, error) {
+	graph, err := g.commitGraph()
 	if err != nil {
-		return false, err
+		return nil, false, err
 	}
 
-	iter := object.NewCommitPreorderIter(commit, nil, nil)
-	defer iter.Close()
+	seen := map[plumbing.Hash]bool{start: true}
+	frontier := []plumbing.Hash{start}
+	truncated := false
 
-	err = iter.ForEach(func(c *object.Commit) error {
-		if c.Hash == to {
-			return fmt.Errorf("found") // Use error to break the loop
+	for distance := 0; len(frontier) > 0; distance++ {
+		var next []plumbing.Hash
+		for _, hash := range frontier {
+			parents, err := graph.parents(hash)
+			if err != nil {
+				return nil, false, err
+			}
+			for _, parent := range parents {
+				if seen[parent] {
+					continue
+				}
+				if distance == maxDistance {
+					truncated = true
+					continue
+				}
+				seen[parent] = true
+				next = append(next, parent)
+			}
+		}
+		frontier = next
+	}
+
+	return seen, truncated || len(seen) > maxDistance+1, nil
+}
+
+// tagReference is a tag ref together with the bare tag name it represents
+type tagReference struct {
+	name string
+	ref  *plumbing.Reference
+}
+
+// tagReferences lists refs/tags and, with IncludeRemoteTags, refs/remotes/<remote>/tags.
+// A local tag shadows remote tags of the same name, like system git does.
+func (g *GoGitHandler) tagReferences() ([]tagReference, error) {
+	refs, err := g.repo.References()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	var local, remote []tagReference
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name()
+		switch {
+		case name.IsTag():
+			local = append(local, tagReference{name: name.Short(), ref: ref})
+		case g.options.IncludeRemoteTags && name.IsRemote():
+			_, tagName, ok := strings.Cut(strings.TrimPrefix(name.String(), "refs/remotes/"), "/tags/")
+			if ok && tagName != "" {
+				remote = append(remote, tagReference{name: tagName, ref: ref})
+			}
 		}
 		return nil
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
 
-	return err != nil && err.Error() == "found", nil
+	seen := make(map[string]bool, len(local))
+	for _, tag := range local {
+		seen[tag.name] = true
+	}
+	for _, tag := range remote {
+		if !seen[tag.name] {
+			seen[tag.name] = true
+			local = append(local, tag)
+		}
+	}
+	return local, nil
 }
 
-// countAllCommits counts all commits from a given commit
-func (g *GoGitHandler) countAllCommits(commitHash plumbing.Hash) (int, error) {
-	commit, err := g.repo.CommitObject(commitHash)
+// resolveTagCommit returns the commit a tag name points to
+func (g *GoGitHandler) resolveTagCommit(tagName string) (plumbing.Hash, error) {
+	tagRefs, err := g.tagReferences()
 	if err != nil {
-		return 0, err
+		return plumbing.ZeroHash, err
+	}
+	for _, tagRef := range tagRefs {
+		if tagRef.name == tagName {
+			return g.peelToCommit(tagRef.ref)
+		}
 	}
+	return plumbing.ZeroHash, fmt.Errorf("failed to get tag reference: %s not found", tagName)
+}
 
-	count := 0
-	iter := object.NewCommitPreorderIter(commit, nil, nil)
-	defer iter.Close()
+// peelToCommit resolves a (possibly packed or annotated) tag ref to its commit
+func (g *GoGitHandler) peelToCommit(ref *plumbing.Reference) (plumbing.Hash, error) {
+	hash, err := g.repo.ResolveRevision(plumbing.Revision(ref.Name()))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to resolve %s: %w", ref.Name(), err)
+	}
+	return *hash, nil
+}
 
-	err = iter.ForEach(func(c *object.Commit) error {
-		count++
+// isCommitReachable checks if a commit is reachable from another commit
+// through any parent, like git branch --contains
+func (g *GoGitHandler) isCommitReachable(from, to plumbing.Hash) (bool, error) {
+	return g.reaches(from, to, (*commitGraph).walkAll)
+}
+
+// isCommitTraversed checks if a commit is on the history of another commit
+// walked with the selected traversal, like the candidates of git describe
+func (g *GoGitHandler) isCommitTraversed(from, to plumbing.Hash) (bool, error) {
+	return g.reaches(from, to, (*commitGraph).walk)
+}
+
+// reaches reports whether walk from from visits to
+func (g *GoGitHandler) reaches(from, to plumbing.Hash, walk func(*commitGraph, plumbing.Hash, func(plumbing.Hash) error) error) (bool, error) {
+	if from == to {
+		return true, nil
+	}
+
+	graph, err := g.commitGraph()
+	if err != nil {
+		return false, err
+	}
+
+	found := false
+	err = walk(graph, from, func(hash plumbing.Hash) error {
+		if hash == to {
+			found = true
+			return errStopWalk
+		}
 		return nil
 	})
 
-	return count, err
+	return found, err
+}
+
+// commitGraph returns the parent resolver used for history walks, loading it on first use
+func (g *GoGitHandler) commitGraph() (*commitGraph, error) {
+	if g.graph == nil {
+		graph, err := newCommitGraph(g.repo, g.options)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load commit graph: %w", err)
+		}
+		g.graph = graph
+	}
+	return g.graph, nil
 }
@@ -0,0 +1,100 @@
+package gitType
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/go-git/go-git/v5/storage/filesystem"
+)
+
+// ErrLocked is returned when another process holds a git lock file for longer
+// than GitOptions.LockTimeout
+var ErrLocked = errors.New("git lock held by another process")
+
+// LockError names the lock file another process holds
+type LockError struct {
+	Path  string    // Lock file, e.g. /repo/.git/index.lock
+	Since time.Time // When the lock file was created, zero when unknown
+	Err   error     // Failure of the git command that ran into the lock, if any
+}
+
+func (e *LockError) Error() string {
+	held := ""
+	if !e.Since.IsZero() {
+		held = fmt.Sprintf(" for %s", time.Since(e.Since).Round(time.Second))
+	}
+	return fmt.Sprintf("%s has been held by another git process%s; if no git process is running, a crashed one left it behind and it can be removed", e.Path, held)
+}
+
+func (e *LockError) Unwrap() []error {
+	return []error{ErrLocked, e.Err}
+}
+
+// Backoff between attempts to take a lock
+const (
+	lockRetryInitial = 50 * time.Millisecond
+	lockRetryMax     = time.Second
+)
+
+// lockFileError matches git's message for a lock file that already exists
+var lockFileError = regexp.MustCompile(`Unable to create '([^']+\.lock)': File exists`)
+
+// lockErrorFrom returns a LockError when a git command failed on a held lock
+func lockErrorFrom(repoPath, stderr string, err error) *LockError {
+	match := lockFileError.FindStringSubmatch(stderr)
+	if match == nil {
+		return nil
+	}
+	path := match[1]
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repoPath, path)
+	}
+	lockErr := &LockError{Path: path, Err: err}
+	if info, err := os.Stat(path); err == nil {
+		lockErr.Since = info.ModTime()
+	}
+	return lockErr
+}
+
+// retryLocked runs attempt until it succeeds, fails for another reason than a
+// held lock or GitOptions.LockTimeout runs out, backing off exponentially
+// between attempts. The Deadline and Context also end the retries.
+func retryLocked(options GitOptions, attempt func() error) error {
+	stop := time.Now().Add(options.LockTimeout)
+	if !options.Deadline.IsZero() && options.Deadline.Before(stop) {
+		stop = options.Deadline
+	}
+	wait := lockRetryInitial
+	for {
+		err := attempt()
+		if !errors.Is(err, ErrLocked) || time.Now().Add(wait).After(stop) || options.interrupted() != nil {
+			return err
+		}
+		time.Sleep(wait)
+		wait = min(wait*2, lockRetryMax)
+	}
+}
+
+// waitForLocks waits for other processes to release the lock files of the
+// given files under the git directory, e.g. index or refs/tags/v1.2.3. go-git
+// ignores git's lock files, so writing while one exists would race the process
+// holding it.
+func (g *GoGitHandler) waitForLocks(names ...string) error {
+	storage, ok := g.repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return nil
+	}
+	fs := storage.Filesystem()
+	return retryLocked(g.options, func() error {
+		for _, name := range names {
+			if info, err := fs.Stat(name + ".lock"); err == nil {
+				return &LockError{Path: filepath.Join(fs.Root(), name+".lock"), Since: info.ModTime()}
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,209 @@
+package gitType
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// notesRefName expands a notes ref given as "versions" or "refs/notes/versions"
+func notesRefName(notesRef string) plumbing.ReferenceName {
+	if strings.HasPrefix(notesRef, "refs/") {
+		return plumbing.ReferenceName(notesRef)
+	}
+	return plumbing.ReferenceName("refs/notes/" + notesRef)
+}
+
+// AppendNote appends a line to the git note of HEAD under refs/notes/<notesRef>.
+// go-git has no notes support, so the notes commit is written directly.
+func (g *GoGitHandler) AppendNote(notesRef, line string) error {
+	if err := g.waitForLocks(notesRefName(notesRef).String()); err != nil {
+		return fmt.Errorf("failed to add note: %w", err)
+	}
+	head, err := g.repo.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD: %w", err)
+	}
+
+	var parents []plumbing.Hash
+	var entries []object.TreeEntry
+	refName := notesRefName(notesRef)
+	if ref, err := g.repo.Reference(refName, true); err == nil {
+		notesCommit, err := g.repo.CommitObject(ref.Hash())
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", refName, err)
+		}
+		tree, err := notesCommit.Tree()
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", refName, err)
+		}
+		parents = []plumbing.Hash{notesCommit.Hash}
+		entries = tree.Entries
+	} else if !errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return fmt.Errorf("failed to read %s: %w", refName, err)
+	}
+
+	note, _, err := g.ReadNote(notesRef, head.Hash().String())
+	if err != nil {
+		return err
+	}
+	if note != "" {
+		note += "\n"
+	}
+	blobHash, err := g.storeBlob(note + line + "\n")
+	if err != nil {
+		return err
+	}
+
+	// Drop any earlier note for HEAD, flat or in a fan-out directory, and store it flat
+	name := head.Hash().String()
+	if entries, err = g.withoutFanoutNote(entries, name); err != nil {
+		return err
+	}
+	entries = append(entries, object.TreeEntry{Name: name, Mode: filemode.Regular, Hash: blobHash})
+	sort.Slice(entries, func(i, j int) bool {
+		return treeEntrySortKey(entries[i]) < treeEntrySortKey(entries[j])
+	})
+
+	treeHash, err := g.storeObject(&object.Tree{Entries: entries})
+	if err != nil {
+		return err
+	}
+
+	signature := g.configSignature()
+	if signature == nil {
+		signature = &object.Signature{Name: "version-generator", Email: "version-generator@localhost", When: time.Now()}
+	}
+	commitHash, err := g.storeObject(&object.Commit{
+		Author:       *signature,
+		Committer:    *signature,
+		Message:      "Notes added by 'version-generator'\n",
+		TreeHash:     treeHash,
+		ParentHashes: parents,
+	})
+	if err != nil {
+		return err
+	}
+	return g.repo.Storer.SetReference(plumbing.NewHashReference(refName, commitHash))
+}
+
+// ReadNote returns the git note of a revision under refs/notes/<notesRef>
+func (g *GoGitHandler) ReadNote(notesRef, revision string) (string, bool, error) {
+	commitHash, err := g.repo.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to resolve %s: %w", revision, err)
+	}
+
+	ref, err := g.repo.Reference(notesRefName(notesRef), true)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read notes: %w", err)
+	}
+	notesCommit, err := g.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read notes: %w", err)
+	}
+	tree, err := notesCommit.Tree()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read notes: %w", err)
+	}
+
+	name := commitHash.String()
+	file, err := tree.File(name)
+	if errors.Is(err, object.ErrFileNotFound) {
+		file, err = tree.File(name[:2] + "/" + name[2:])
+	}
+	if errors.Is(err, object.ErrFileNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read note: %w", err)
+	}
+
+	contents, err := file.Contents()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read note: %w", err)
+	}
+	return strings.TrimRight(contents, "\n"), true, nil
+}
+
+// withoutFanoutNote removes the note for name from a notes tree, either as a
+// flat entry or as <xx>/<rest> inside a fan-out directory
+func (g *GoGitHandler) withoutFanoutNote(entries []object.TreeEntry, name string) ([]object.TreeEntry, error) {
+	kept := make([]object.TreeEntry, 0, len(entries))
+	for _, entry := range entries {
+		switch {
+		case entry.Name == name:
+			continue
+		case entry.Name == name[:2] && entry.Mode == filemode.Dir:
+			subtree, err := g.repo.TreeObject(entry.Hash)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read notes: %w", err)
+			}
+			subEntries := make([]object.TreeEntry, 0, len(subtree.Entries))
+			for _, subEntry := range subtree.Entries {
+				if subEntry.Name != name[2:] {
+					subEntries = append(subEntries, subEntry)
+				}
+			}
+			if len(subEntries) == 0 {
+				continue
+			}
+			if entry.Hash, err = g.storeObject(&object.Tree{Entries: subEntries}); err != nil {
+				return nil, err
+			}
+		}
+		kept = append(kept, entry)
+	}
+	return kept, nil
+}
+
+// treeEntrySortKey orders tree entries like git: directories sort as if their name ended in "/"
+func treeEntrySortKey(entry object.TreeEntry) string {
+	if entry.Mode == filemode.Dir {
+		return entry.Name + "/"
+	}
+	return entry.Name
+}
+
+// storeBlob writes content as a blob object
+func (g *GoGitHandler) storeBlob(content string) (plumbing.Hash, error) {
+	obj := g.repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	writer, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := io.WriteString(writer, content); err != nil {
+		writer.Close()
+		return plumbing.ZeroHash, err
+	}
+	if err := writer.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return g.repo.Storer.SetEncodedObject(obj)
+}
+
+// storeObject encodes and writes a tree or commit object
+func (g *GoGitHandler) storeObject(o interface {
+	Encode(plumbing.EncodedObject) error
+}) (plumbing.Hash, error) {
+	obj := g.repo.Storer.NewEncodedObject()
+	if err := o.Encode(obj); err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to encode object: %w", err)
+	}
+	hash, err := g.repo.Storer.SetEncodedObject(obj)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("failed to store object: %w", err)
+	}
+	return hash, nil
+}
@@ -0,0 +1,258 @@
+package gitType
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"version-generator/versionSchemes"
+)
+
+// testRepo builds a repository with git, one commit date per minute
+type testRepo struct {
+	t    *testing.T
+	dir  string
+	tick int
+}
+
+// git runs git in the repository, failing the test on error
+func (r *testRepo) git(args ...string) {
+	r.t.Helper()
+	r.tick++
+	r.gitAt(fmt.Sprintf("2024-05-01T12:%02d:00Z", r.tick), args...)
+}
+
+// gitAt runs git with the author and committer dates set to date
+func (r *testRepo) gitAt(date string, args ...string) {
+	r.t.Helper()
+	command := exec.Command("git", args...)
+	command.Dir = r.dir
+	command.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_AUTHOR_DATE="+date,
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com", "GIT_COMMITTER_DATE="+date,
+	)
+	if output, err := command.CombinedOutput(); err != nil {
+		r.t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+}
+
+// mergeRepo tags v1.0.0 on the merge of side into main
+func mergeRepo(r *testRepo) {
+	r.git("init", "-q", "-b", "main")
+	r.git("commit", "-q", "--allow-empty", "-m", "c0")
+	r.git("tag", "v0.1.0")
+	r.git("checkout", "-q", "-b", "side")
+	r.git("commit", "-q", "--allow-empty", "-m", "s1")
+	r.git("checkout", "-q", "main")
+	r.git("commit", "-q", "--allow-empty", "-m", "m1")
+	r.git("merge", "-q", "--no-ff", "side", "-m", "merge side")
+	r.git("tag", "v1.0.0")
+	r.git("commit", "-q", "--allow-empty", "-m", "m2")
+}
+
+// backMergeRepo continues mergeRepo by merging main back into side
+func backMergeRepo(r *testRepo) {
+	mergeRepo(r)
+	r.git("checkout", "-q", "side")
+	r.git("commit", "-q", "--allow-empty", "-m", "s2")
+	r.git("merge", "-q", "--no-ff", "main", "-m", "merge main")
+	r.git("commit", "-q", "--allow-empty", "-m", "s3")
+}
+
+// TestTraversalParity versions merge topologies with both backends, which
+// must agree with each other and with git rev-list
+func TestTraversalParity(t *testing.T) {
+	topologies := []struct {
+		name  string
+		build func(*testRepo)
+		want  map[string]map[string]string // branch -> traversal -> version
+	}{
+		{"merge", mergeRepo, map[string]map[string]string{
+			"main": {TraversalAll: "v1.0.0+1", TraversalFirstParent: "v1.0.0+1", TraversalAuthorDate: "v1.0.0+1"},
+			"side": {TraversalAll: "v0.1.0-side+1", TraversalFirstParent: "v0.1.0-side+1", TraversalAuthorDate: "v0.1.0-side+1"},
+		}},
+		{"back-merge", backMergeRepo, map[string]map[string]string{
+			"main": {TraversalAll: "v1.0.0+1", TraversalFirstParent: "v1.0.0+1", TraversalAuthorDate: "v1.0.0+1"},
+			"side": {TraversalAll: "v1.0.0-side+4", TraversalFirstParent: "v1.0.0-side+3", TraversalAuthorDate: "v1.0.0-side+4"},
+		}},
+	}
+
+	for _, topology := range topologies {
+		repo := &testRepo{t: t, dir: t.TempDir()}
+		topology.build(repo)
+		for branch, traversals := range topology.want {
+			repo.git("checkout", "-q", branch)
+			for traversal, want := range traversals {
+				t.Run(topology.name+"/"+branch+"/"+traversal, func(t *testing.T) {
+					for _, inBuiltGit := range []bool{false, true} {
+						handler, err := GetGitHandlerWithOptions(inBuiltGit, repo.dir, GitOptions{Traversal: traversal})
+						if err != nil {
+							t.Fatal(err)
+						}
+						info, err := handler.GenerateVersionInfo(false)
+						if err != nil {
+							t.Fatalf("in-built git %v: %v", inBuiltGit, err)
+						}
+						if info.Version != want {
+							t.Errorf("in-built git %v: version %q, want %q", inBuiltGit, info.Version, want)
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestCalVerResetParity counts the commits of the current CalVer period with
+// both backends; a commit made exactly when the period starts is in it
+func TestCalVerResetParity(t *testing.T) {
+	repo := &testRepo{t: t, dir: t.TempDir()}
+	repo.gitAt("2023-12-01T12:00:00Z", "init", "-q", "-b", "main")
+	repo.gitAt("2023-12-01T12:00:00Z", "commit", "-q", "--allow-empty", "-m", "release")
+	repo.gitAt("2023-12-01T12:00:00Z", "tag", "v1.0.0")
+	commitAt := func(dates ...string) {
+		for _, date := range dates {
+			repo.gitAt(date, "commit", "-q", "--allow-empty", "-m", "at "+date)
+		}
+	}
+
+	check := func(now time.Time, format string, want map[string]string) {
+		t.Helper()
+		for reset, version := range want {
+			for _, inBuiltGit := range []bool{false, true} {
+				handler, err := GetGitHandler(inBuiltGit, repo.dir)
+				if err != nil {
+					t.Fatal(err)
+				}
+				info, err := handler.GenerateVersionInfoWithOptions(versionSchemes.VersioningOptions{
+					Scheme: versionSchemes.SchemeCalVer, CalVerFormat: format, CalVerReset: reset, Clock: versionSchemes.FixedClock(now),
+				})
+				if err != nil {
+					t.Fatalf("reset %s, in-built git %v: %v", reset, inBuiltGit, err)
+				}
+				if info.Version != version {
+					t.Errorf("reset %s at %v, in-built git %v: version %q, want %q", reset, now, inBuiltGit, info.Version, version)
+				}
+			}
+		}
+	}
+
+	commitAt(
+		"2023-12-31T23:59:59Z", "2024-01-01T00:00:00Z", // year start
+		"2024-04-30T23:59:59Z", "2024-05-01T00:00:00Z", // month start
+		"2024-05-12T23:59:59Z", "2024-05-13T00:00:00Z", // Monday, week start
+		"2024-05-16T10:00:00Z",
+	)
+	check(time.Date(2024, 5, 16, 12, 0, 0, 0, time.UTC), "YYYY.0M", map[string]string{
+		versionSchemes.CalVerResetNone:  "2024.05.7",
+		versionSchemes.CalVerResetYear:  "2024.05.6",
+		versionSchemes.CalVerResetMonth: "2024.05.4",
+		versionSchemes.CalVerResetWeek:  "2024.05.2",
+	})
+
+	// Week 1 of 2025 starts on Monday 2024-12-30
+	commitAt("2024-12-29T23:59:59Z", "2024-12-30T00:00:00Z", "2025-01-01T08:00:00Z")
+	check(time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC), "YYYY.0W", map[string]string{
+		versionSchemes.CalVerResetWeek: "2025.01.2",
+		versionSchemes.CalVerResetYear: "2025.01.1",
+	})
+}
+
+// TestMaintenanceBranchTags versions a maintenance branch from its release
+// line without narrowing the tags the handler lists afterwards
+func TestMaintenanceBranchTags(t *testing.T) {
+	repo := &testRepo{t: t, dir: t.TempDir()}
+	repo.git("init", "-q", "-b", "main")
+	repo.git("commit", "-q", "--allow-empty", "-m", "release 1.4")
+	repo.git("tag", "v1.4.0")
+	repo.git("branch", "1.4.x")
+	repo.git("commit", "-q", "--allow-empty", "-m", "release 1.5")
+	repo.git("tag", "v1.5.0")
+	repo.git("checkout", "-q", "1.4.x")
+	repo.git("commit", "-q", "--allow-empty", "-m", "fix")
+	repo.git("tag", "v1.4.1")
+	repo.git("merge", "-q", "--no-ff", "main", "-m", "merge main")
+
+	for _, inBuiltGit := range []bool{false, true} {
+		handler, err := GetGitHandler(inBuiltGit, repo.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		info, err := handler.GenerateVersionInfo(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.LastTag != "v1.4.1" {
+			t.Errorf("in-built git %v: last tag %q on 1.4.x, want v1.4.1", inBuiltGit, info.LastTag)
+		}
+		tags, err := handler.GetTagNames()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(tags) != "[v1.4.0 v1.4.1 v1.5.0]" {
+			t.Errorf("in-built git %v: tags %v after versioning 1.4.x, want every tag", inBuiltGit, tags)
+		}
+	}
+}
+
+// TestTagDistanceParity limits the tag distance on a merged feature branch with
+// both backends, which count the distance like the commits since the tag
+func TestTagDistanceParity(t *testing.T) {
+	repo := &testRepo{t: t, dir: t.TempDir()}
+	repo.git("init", "-q", "-b", "main")
+	repo.git("commit", "-q", "--allow-empty", "-m", "c0")
+	repo.git("tag", "v1.0.0")
+	repo.git("checkout", "-q", "-b", "feature")
+	for i := 1; i <= 5; i++ {
+		repo.git("commit", "-q", "--allow-empty", "-m", fmt.Sprintf("f%d", i))
+	}
+	repo.git("checkout", "-q", "main")
+	repo.git("commit", "-q", "--allow-empty", "-m", "m1")
+	repo.git("merge", "-q", "--no-ff", "feature", "-m", "merge feature")
+
+	tests := []struct {
+		distance  int
+		traversal string
+		want      string
+		exceeded  bool
+	}{
+		{3, TraversalAll, "v0.0.0+8", true},
+		{6, TraversalAll, "v0.0.0+8", true},
+		{7, TraversalAll, "v1.0.0+7", false},
+		{3, TraversalAuthorDate, "v0.0.0+8", true},
+		{7, TraversalAuthorDate, "v1.0.0+7", false},
+		{1, TraversalFirstParent, "v0.0.0+3", true},
+		{2, TraversalFirstParent, "v1.0.0+2", false},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s/%d", test.traversal, test.distance), func(t *testing.T) {
+			for _, inBuiltGit := range []bool{false, true} {
+				for _, fail := range []bool{false, true} {
+					handler, err := GetGitHandlerWithOptions(inBuiltGit, repo.dir, GitOptions{
+						MaxTagDistance: test.distance, FailOnTagDistance: fail, Traversal: test.traversal,
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+					info, err := handler.GenerateVersionInfo(false)
+					if test.exceeded && fail {
+						if !errors.Is(err, ErrTagDistanceExceeded) {
+							t.Errorf("in-built git %v: error %v, want ErrTagDistanceExceeded", inBuiltGit, err)
+						}
+						continue
+					}
+					if err != nil {
+						t.Fatalf("in-built git %v, fail %v: %v", inBuiltGit, fail, err)
+					}
+					if info.Version != test.want {
+						t.Errorf("in-built git %v, fail %v: version %q, want %q", inBuiltGit, fail, info.Version, test.want)
+					}
+				}
+			}
+		})
+	}
+}
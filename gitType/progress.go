@@ -0,0 +1,67 @@
+package gitType
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// progressDelay keeps runs that finish quickly from drawing anything
+	progressDelay = 500 * time.Millisecond
+	// progressInterval is the shortest time between two redraws
+	progressInterval = 100 * time.Millisecond
+	// progressBatch is how many commits are visited between clock reads
+	progressBatch = 256
+)
+
+// spinnerFrames animate the progress line
+var spinnerFrames = []string{"|", "/", "-", `\`}
+
+// progress redraws a single line with the number of commits walked so far.
+// A nil progress reports nothing, so walks can call it unconditionally.
+type progress struct {
+	out     io.Writer
+	started time.Time
+	drawn   time.Time
+	commits int
+	frame   int
+	visible bool
+}
+
+// newProgress returns a progress line written to out, or nil when out is nil
+func newProgress(out io.Writer) *progress {
+	if out == nil {
+		return nil
+	}
+	return &progress{out: out, started: time.Now()}
+}
+
+// step counts a visited commit and redraws the line when it is due
+func (p *progress) step() {
+	if p == nil {
+		return
+	}
+	p.commits++
+	if p.commits%progressBatch != 0 {
+		return
+	}
+	now := time.Now()
+	elapsed := now.Sub(p.started)
+	if elapsed < progressDelay || now.Sub(p.drawn) < progressInterval {
+		return
+	}
+	p.drawn = now
+	p.frame = (p.frame + 1) % len(spinnerFrames)
+	fmt.Fprintf(p.out, "\r%s Walking history: %d commits (%.0f/s)", spinnerFrames[p.frame], p.commits, float64(p.commits)/elapsed.Seconds())
+	p.visible = true
+}
+
+// clear erases the line once a walk ends; the count carries over to the next walk
+func (p *progress) clear() {
+	if p == nil || !p.visible {
+		return
+	}
+	fmt.Fprint(p.out, "\r\x1b[K")
+	p.visible = false
+}
@@ -0,0 +1,45 @@
+package gitType
+
+import "time"
+
+// BranchResolver names the branch a version is generated for
+type BranchResolver interface {
+	GetCurrentBranch() (string, error)
+}
+
+// TagResolver finds the last tag of a branch; found is false when there is none
+type TagResolver interface {
+	GetLastTag(branchName string) (tag string, found bool, err error)
+}
+
+// Counter counts the commits since a tag returned by the TagResolver, or all
+// commits when the tag is empty
+type Counter interface {
+	GetCommitsSinceTag(tagName string) (int, error)
+	GetCommitsSinceTagAfter(tagName string, after time.Time) (int, error)
+}
+
+// Resolvers replace steps of version generation, e.g. a TagResolver reading
+// release tags from a database. Steps left nil are done by the handler, and
+// everything else (hashes, policies, schemes) is unchanged. A custom
+// TagResolver usually needs a Counter that understands the tags it returns.
+type Resolvers struct {
+	Branch  BranchResolver
+	Tag     TagResolver
+	Counter Counter
+}
+
+// resolversFor fills the steps without a custom resolver with handler itself
+func (b *BaseGitHandler) resolversFor(handler GitHandler) Resolvers {
+	resolvers := b.options.Resolvers
+	if resolvers.Branch == nil {
+		resolvers.Branch = handler
+	}
+	if resolvers.Tag == nil {
+		resolvers.Tag = handler
+	}
+	if resolvers.Counter == nil {
+		resolvers.Counter = handler
+	}
+	return resolvers
+}
@@ -0,0 +1,183 @@
+package gitType
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Signature formats, matching git's gpg.format
+const (
+	SigningFormatOpenPGP = "openpgp"
+	SigningFormatSSH     = "ssh"
+)
+
+// SigningOptions selects how CreateTag signs a tag
+type SigningOptions struct {
+	// Key overrides user.signingkey. System git passes it to git tag -u (a key ID
+	// for OpenPGP, a key path for SSH); go-git reads it as an armored OpenPGP
+	// secret key or an SSH private key file.
+	Key string
+	// Passphrase unlocks an encrypted key file read by go-git
+	Passphrase string
+}
+
+// tagSigner produces a detached, armored signature over an encoded tag object
+type tagSigner func(payload []byte) (string, error)
+
+// newTagSigner loads the signing key for go-git, following gpg.format and user.signingkey
+func newTagSigner(format, configuredKey string, signing *SigningOptions) (tagSigner, error) {
+	keyPath := signing.Key
+	if format == "" {
+		format = SigningFormatOpenPGP
+	}
+
+	switch format {
+	case SigningFormatOpenPGP:
+		if keyPath == "" {
+			return nil, errors.New("go-git cannot read the gpg keyring: pass an armored secret key file as the signing key, or use system git")
+		}
+		return openPGPSigner(keyPath, signing.Passphrase)
+	case SigningFormatSSH:
+		if keyPath == "" {
+			keyPath = configuredKey
+		}
+		if keyPath == "" {
+			return nil, errors.New("no SSH signing key: set user.signingkey or pass a signing key")
+		}
+		if strings.HasPrefix(keyPath, "key::") {
+			return nil, errors.New("go-git needs the SSH private key file, not a literal public key in user.signingkey")
+		}
+		return sshSigner(keyPath, signing.Passphrase)
+	default:
+		return nil, fmt.Errorf("gpg.format %q is not supported by go-git; use system git", format)
+	}
+}
+
+// openPGPSigner signs with the first secret key in an armored key file
+func openPGPSigner(keyPath, passphrase string) (tagSigner, error) {
+	f, err := os.Open(expandHome(keyPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open signing key: %w", err)
+	}
+	defer f.Close()
+
+	keyRing, err := openpgp.ReadArmoredKeyRing(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OpenPGP key %s: %w", keyPath, err)
+	}
+
+	var entity *openpgp.Entity
+	for _, candidate := range keyRing {
+		if candidate.PrivateKey != nil {
+			entity = candidate
+			break
+		}
+	}
+	if entity == nil {
+		return nil, fmt.Errorf("%s contains no OpenPGP secret key", keyPath)
+	}
+	if entity.PrivateKey.Encrypted {
+		if passphrase == "" {
+			return nil, fmt.Errorf("OpenPGP key %s is encrypted and no passphrase was given", keyPath)
+		}
+		if err := entity.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+			return nil, fmt.Errorf("failed to unlock OpenPGP key %s: %w", keyPath, err)
+		}
+	}
+
+	return func(payload []byte) (string, error) {
+		var sig bytes.Buffer
+		if err := openpgp.ArmoredDetachSign(&sig, entity, bytes.NewReader(payload), nil); err != nil {
+			return "", fmt.Errorf("failed to create OpenPGP signature: %w", err)
+		}
+		return sig.String() + "\n", nil
+	}, nil
+}
+
+// sshSigner signs in git's SSHSIG format. A .pub path is mapped to its private key
+func sshSigner(keyPath, passphrase string) (tagSigner, error) {
+	keyPath = strings.TrimSuffix(expandHome(keyPath), ".pub")
+	pemBytes, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read SSH signing key: %w", err)
+	}
+
+	signer, err := ssh.ParsePrivateKey(pemBytes)
+	var missing *ssh.PassphraseMissingError
+	if errors.As(err, &missing) {
+		if passphrase == "" {
+			return nil, fmt.Errorf("SSH key %s is encrypted and no passphrase was given (go-git does not use ssh-agent)", keyPath)
+		}
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(passphrase))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse SSH key %s: %w", keyPath, err)
+	}
+
+	return func(payload []byte) (string, error) {
+		return sshSignature(signer, payload)
+	}, nil
+}
+
+// sshSignature creates an armored SSHSIG signature in the "git" namespace
+func sshSignature(signer ssh.Signer, payload []byte) (string, error) {
+	const namespace, hashAlgorithm = "git", "sha512"
+	digest := sha512.Sum512(payload)
+
+	signedData := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Namespace string
+		Reserved  string
+		HashAlg   string
+		Hash      string
+	}{namespace, "", hashAlgorithm, string(digest[:])})...)
+
+	var sig *ssh.Signature
+	var err error
+	if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok && signer.PublicKey().Type() == ssh.KeyAlgoRSA {
+		sig, err = algorithmSigner.SignWithAlgorithm(rand.Reader, signedData, ssh.KeyAlgoRSASHA512)
+	} else {
+		sig, err = signer.Sign(rand.Reader, signedData)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create SSH signature: %w", err)
+	}
+
+	blob := append([]byte("SSHSIG"), ssh.Marshal(struct {
+		Version   uint32
+		PublicKey string
+		Namespace string
+		Reserved  string
+		HashAlg   string
+		Signature string
+	}{1, string(signer.PublicKey().Marshal()), namespace, "", hashAlgorithm, string(ssh.Marshal(sig))})...)
+
+	encoded := base64.StdEncoding.EncodeToString(blob)
+	var armored strings.Builder
+	armored.WriteString("-----BEGIN SSH SIGNATURE-----\n")
+	for len(encoded) > 70 {
+		armored.WriteString(encoded[:70] + "\n")
+		encoded = encoded[70:]
+	}
+	armored.WriteString(encoded + "\n-----END SSH SIGNATURE-----\n")
+	return armored.String(), nil
+}
+
+// expandHome resolves a leading ~/ the way git does for user.signingkey
+func expandHome(path string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	return path
+}
@@ -1,10 +1,17 @@
 package gitType
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 	"version-generator/versionSchemes"
 )
 
@@ -30,79 +37,367 @@ func NewSystemGitHandler(repoPath string) (*SystemGitHandler, error) {
 
 // runGitCommand executes a git command and returns the output
 func (s *SystemGitHandler) runGitCommand(args ...string) (string, error) {
-	cmd := exec.Command("git", args...)
+	var output string
+	err := retryLocked(s.options, func() error {
+		var err error
+		output, err = s.runGitCommandOnce(args...)
+		return err
+	})
+	return output, err
+}
+
+// runGitCommandOnce runs a git command, reporting a lock held by another process as a LockError
+func (s *SystemGitHandler) runGitCommandOnce(args ...string) (string, error) {
+	if s.options.NoReplaceObjects {
+		args = append([]string{"--no-replace-objects"}, args...)
+	}
+	ctx := s.options.baseContext()
+	if !s.options.Deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, s.options.Deadline)
+		defer cancel()
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = s.repoPath
+	// Without optional locks, git status does not refresh and rewrite the index
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0")
 
 	output, err := cmd.Output()
+	if ctx.Err() != nil {
+		if err := s.options.interrupted(); err != nil && !errors.Is(err, ErrTimeout) {
+			return "", fmt.Errorf("git %s: %w", args[0], err)
+		}
+		return "", fmt.Errorf("%w during git %s", ErrTimeout, args[0])
+	}
 	if err != nil {
+		if lockErr := lockErrorFrom(s.repoPath, gitStderr(err), err); lockErr != nil {
+			return "", fmt.Errorf("git %s failed: %w", args[0], lockErr)
+		}
 		return "", fmt.Errorf("git command failed: %w", err)
 	}
 
 	return strings.TrimSpace(string(output)), nil
 }
 
+// exitCode returns the exit status of a failed git command, or -1
+func exitCode(err error) int {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode()
+	}
+	return -1
+}
+
+// gitStderr returns the trimmed stderr of a failed git command, falling back to the error text
+func gitStderr(err error) string {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return strings.TrimSpace(string(exitErr.Stderr))
+	}
+	return err.Error()
+}
+
 // GenerateVersionInfo generates version information using system git
 func (s *SystemGitHandler) GenerateVersionInfo(dockerFormat bool) (*VersionInfo, error) {
+	// A freshly initialized repository has no commits to version yet
+	if branchName, unborn, err := s.unbornBranch(); err != nil || unborn {
+		if err != nil {
+			return nil, err
+		}
+		return s.unbornVersionInfo(branchName, versionSchemes.VersioningOptions{})
+	}
+
 	// Get current branch
-	branchName, err := s.GetCurrentBranch()
+	resolvers := s.resolversFor(s)
+	branchName, err := resolvers.Branch.GetCurrentBranch()
 	if err != nil {
 		return nil, err
 	}
 
+	// Orphan branches may switch to Calendar Versioning
+	if orphan, err := s.isOrphanCalVer(branchName); err != nil {
+		return nil, err
+	} else if orphan {
+		return s.GenerateVersionInfoWithOptions(versionSchemes.VersioningOptions{Scheme: versionSchemes.SchemeCalVer})
+	}
+
 	// Get short hash
 	shortHash, err := s.GetShortHash()
 	if err != nil {
 		return nil, err
 	}
 
+	// Get full hash
+	commit, err := s.GetFullHash()
+	if err != nil {
+		return nil, err
+	}
+
 	// Find the last tag
-	lastTag, err := s.GetLastTag(branchName)
+	lastTag, found, err := resolvers.Tag.GetLastTag(branchName)
 	if err != nil {
 		return nil, err
 	}
 
 	// Count commits since last tag
-	commitsSince, err := s.GetCommitsSinceTag(lastTag)
+	commitsSince, err := resolvers.Counter.GetCommitsSinceTag(lastTag)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply the policy for repositories without tags
+	lastTag, err = s.applyNoTagsPolicy(s.displayTag(lastTag), found)
 	if err != nil {
 		return nil, err
 	}
 
 	// Use base handler to generate version info
-	return s.GenerateVersionInfoFromComponents(branchName, shortHash, lastTag, commitsSince, dockerFormat), nil
+	return s.applyAlways(s.GenerateVersionInfoFromComponents(branchName, commit, shortHash, lastTag, commitsSince, dockerFormat), found), nil
 }
 
 // GenerateVersionInfoWithOptions generates version information using system git with custom options
 func (s *SystemGitHandler) GenerateVersionInfoWithOptions(options versionSchemes.VersioningOptions) (*VersionInfo, error) {
+	// A freshly initialized repository has no commits to version yet
+	if branchName, unborn, err := s.unbornBranch(); err != nil || unborn {
+		if err != nil {
+			return nil, err
+		}
+		return s.unbornVersionInfo(branchName, options)
+	}
+
 	// Get current branch
-	branchName, err := s.GetCurrentBranch()
+	resolvers := s.resolversFor(s)
+	branchName, err := resolvers.Branch.GetCurrentBranch()
 	if err != nil {
 		return nil, err
 	}
 
+	// Orphan branches may switch to Calendar Versioning
+	if orphan, err := s.isOrphanCalVer(branchName); err != nil {
+		return nil, err
+	} else if orphan {
+		options.Scheme = versionSchemes.SchemeCalVer
+	}
+
 	// Get short hash
 	shortHash, err := s.GetShortHash()
 	if err != nil {
 		return nil, err
 	}
 
+	// Get full hash
+	commit, err := s.GetFullHash()
+	if err != nil {
+		return nil, err
+	}
+
 	// Find the last tag
-	lastTag, err := s.GetLastTag(branchName)
+	lastTag, found, err := resolvers.Tag.GetLastTag(branchName)
 	if err != nil {
 		return nil, err
 	}
 
 	// Count commits since last tag
-	commitsSince, err := s.GetCommitsSinceTag(lastTag)
+	commitsSince, err := resolvers.Counter.GetCommitsSinceTag(lastTag)
 	if err != nil {
 		return nil, err
 	}
 
+	// Count the commits of the current CalVer period when the counter resets
+	if periodStart, ok := options.CalVerPeriodStart(options.Now()); ok {
+		periodCommits, err := resolvers.Counter.GetCommitsSinceTagAfter(lastTag, periodStart)
+		if err != nil {
+			return nil, err
+		}
+		options.PeriodCommits = &periodCommits
+	}
+
+	// Apply the policy for repositories without tags
+	lastTag, err = s.applyNoTagsPolicy(s.displayTag(lastTag), found)
+	if err != nil {
+		return nil, err
+	}
+
+	// Describe uncommitted changes when the scheme uses them
+	if options.NeedsWorktreeState() && s.options.Revision == "" {
+		state, err := s.GetWorktreeState()
+		if err != nil {
+			return nil, err
+		}
+		options.Worktree = &state
+	}
+
 	// Use base handler to generate version info with options
-	return s.GenerateVersionInfoFromComponentsWithOptions(branchName, shortHash, lastTag, commitsSince, options), nil
+	return s.applyAlways(s.GenerateVersionInfoFromComponentsWithOptions(branchName, commit, shortHash, lastTag, commitsSince, options), found), nil
+}
+
+// GetTagDate returns the tagger date of an annotated tag or the commit date of a lightweight one
+func (s *SystemGitHandler) GetTagDate(tagName string) (time.Time, bool, error) {
+	tagRef, err := s.resolveTagRef(tagName)
+	if err != nil {
+		return time.Time{}, false, nil
+	}
+	output, err := s.runGitCommand("for-each-ref", "--count=1", "--format=%(creatordate:unix)", tagRef)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to read date of %s: %w", tagName, err)
+	}
+	date, err := strconv.ParseInt(output, 10, 64)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to parse date of %s: %w", tagName, err)
+	}
+	return time.Unix(date, 0), true, nil
+}
+
+// GetBranches lists the local or remote-tracking branches, sorted by name
+func (s *SystemGitHandler) GetBranches(remote bool) ([]Branch, error) {
+	prefix := "refs/heads/"
+	if remote {
+		prefix = "refs/remotes/"
+	}
+	output, err := s.runGitCommand("for-each-ref", "--format=%(refname)%00%(symref)", prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	var branches []Branch
+	for _, line := range strings.Split(output, "\n") {
+		ref, symref, _ := strings.Cut(line, "\x00")
+		// Skip symbolic refs such as refs/remotes/origin/HEAD
+		if ref == "" || symref != "" {
+			continue
+		}
+		branches = append(branches, Branch{Name: strings.TrimPrefix(ref, prefix), Ref: ref})
+	}
+	return branches, nil
+}
+
+// GetReleaseTags lists the tags reachable from HEAD, oldest first
+func (s *SystemGitHandler) GetReleaseTags() ([]ReleaseTag, error) {
+	// creatordate is the tagger date of annotated tags and the commit date of lightweight ones
+	output, err := s.runGitCommand("for-each-ref", "--merged="+s.head(), "--format=%(creatordate:unix) %(*objecttype)%(objecttype) %(refname)", "refs/tags")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	type refTag struct {
+		ref string
+		ReleaseTag
+	}
+	var tags []refTag
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || !strings.HasPrefix(fields[1], "commit") {
+			continue // Skip tags pointing at non-commit objects
+		}
+		date, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		name := strings.TrimPrefix(fields[2], "refs/tags/")
+		if s.tagMatches(name) {
+			tags = append(tags, refTag{ref: fields[2], ReleaseTag: ReleaseTag{Name: s.displayTag(name), Date: time.Unix(date, 0)}})
+		}
+	}
+	sort.SliceStable(tags, func(i, j int) bool {
+		if !tags[i].Date.Equal(tags[j].Date) {
+			return tags[i].Date.Before(tags[j].Date)
+		}
+		return tags[i].Name < tags[j].Name
+	})
+
+	releases := make([]ReleaseTag, len(tags))
+	for i, tag := range tags {
+		args := []string{"rev-list", "--count", tag.ref}
+		if i > 0 {
+			args = append(args, "^"+tags[i-1].ref)
+		}
+		count, err := s.runGitCommand(s.traversalArgs(s.pathArgs(args...)...)...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count commits of %s: %w", tag.Name, err)
+		}
+		if tag.Commits, err = strconv.Atoi(count); err != nil {
+			return nil, fmt.Errorf("failed to parse commit count: %w", err)
+		}
+		releases[i] = tag.ReleaseTag
+	}
+	return releases, nil
+}
+
+// GetTagNames lists every tag, reachable or not, without TagPrefix and sorted
+func (s *SystemGitHandler) GetTagNames() ([]string, error) {
+	output, err := s.runGitCommand("for-each-ref", "--format=%(refname:lstrip=2)", "refs/tags")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	var names []string
+	for _, name := range strings.Split(output, "\n") {
+		if name != "" && s.tagMatches(name) {
+			names = append(names, s.displayTag(name))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetWarnings reports a shallow clone and a detached HEAD
+func (s *SystemGitHandler) GetWarnings(info *VersionInfo) ([]Warning, error) {
+	var warnings []Warning
+	shallow, err := s.runGitCommand("rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for a shallow clone: %w", err)
+	}
+	if shallow == "true" {
+		warnings = append(warnings, shallowCloneWarning())
+	}
+
+	// symbolic-ref exits with 1 when HEAD is detached; a Revision is versioned as given
+	if s.options.Revision != "" {
+		return warnings, nil
+	}
+	if _, err := s.runGitCommand("symbolic-ref", "--quiet", "HEAD"); exitCode(err) == 1 {
+		output, err := s.runGitCommand("branch", "--contains", "HEAD", "--format=%(refname:short)")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list branches containing HEAD: %w", err)
+		}
+		var containing []string
+		for _, line := range strings.Split(output, "\n") {
+			// Skip the "(HEAD detached at ...)" entry
+			if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "(") {
+				containing = append(containing, line)
+			}
+		}
+		warnings = append(warnings, detachedHeadWarnings(info.Branch, containing)...)
+	}
+	return warnings, nil
+}
+
+// unbornBranch reports whether HEAD points at a branch without commits, and its name
+func (s *SystemGitHandler) unbornBranch() (string, bool, error) {
+	if revision := s.options.Revision; revision != "" {
+		if _, err := s.runGitCommand("rev-parse", "--verify", "--quiet", revision+"^{commit}"); err != nil {
+			return "", false, fmt.Errorf("failed to resolve %s: not a commit", revision)
+		}
+		return "", false, nil
+	}
+	_, err := s.runGitCommand("rev-parse", "--verify", "--quiet", "HEAD")
+	if err == nil {
+		return "", false, nil
+	}
+	if exitCode(err) != 1 {
+		return "", false, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	branchName, err := s.runGitCommand("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", false, fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return branchName, true, nil
 }
 
 // GetCurrentBranch returns the current branch name
 func (s *SystemGitHandler) GetCurrentBranch() (string, error) {
+	if s.options.Branch != "" {
+		return s.options.Branch, nil
+	}
 	output, err := s.runGitCommand("rev-parse", "--abbrev-ref", "HEAD")
 	if err != nil {
 		return "", fmt.Errorf("failed to get current branch: %w", err)
@@ -138,62 +433,707 @@ func (s *SystemGitHandler) GetCurrentBranch() (string, error) {
 
 // GetShortHash returns the short hash of current commit
 func (s *SystemGitHandler) GetShortHash() (string, error) {
-	output, err := s.runGitCommand("rev-parse", "--short", "HEAD")
+	output, err := s.runGitCommand("rev-parse", "--short", s.head())
 	if err != nil {
 		return "", fmt.Errorf("failed to get short hash: %w", err)
 	}
 	return output, nil
 }
 
+// Describe returns the output of git describe --tags --dirty --always
+func (s *SystemGitHandler) Describe() (string, error) {
+	args := []string{"describe", "--tags", "--dirty", "--always"}
+	if s.options.Revision != "" {
+		// --dirty describes the working tree, which does not apply to another revision
+		args = []string{"describe", "--tags", "--always", s.options.Revision}
+	}
+	output, err := s.runGitCommand(args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to describe HEAD: %w", err)
+	}
+	return output, nil
+}
+
+// GetFullHash returns the full hash of current commit
+func (s *SystemGitHandler) GetFullHash() (string, error) {
+	output, err := s.runGitCommand("rev-parse", s.head())
+	if err != nil {
+		return "", fmt.Errorf("failed to get full hash: %w", err)
+	}
+	return output, nil
+}
+
+// GetCommitDate returns the committer date of the current commit
+func (s *SystemGitHandler) GetCommitDate() (time.Time, error) {
+	output, err := s.runGitCommand("show", "-s", "--format=%ct", s.head())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get commit date: %w", err)
+	}
+	date, err := strconv.ParseInt(output, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to parse commit date: %w", err)
+	}
+	return time.Unix(date, 0), nil
+}
+
+// GetRemoteURL returns the fetch URL of a remote
+func (s *SystemGitHandler) GetRemoteURL(name string) (string, bool, error) {
+	output, err := s.runGitCommand("config", "--get", "remote."+name+".url")
+	if exitCode(err) == 1 {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read the URL of %s: %w", name, err)
+	}
+	return output, true, nil
+}
+
+// GetRepoRoot returns the top-level directory of the working tree
+func (s *SystemGitHandler) GetRepoRoot() (string, error) {
+	output, err := s.runGitCommand("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("failed to get repository root: %w", err)
+	}
+	return output, nil
+}
+
+// IsIgnored reports whether a repository-relative path matches a gitignore rule
+func (s *SystemGitHandler) IsIgnored(relPath string) (bool, error) {
+	root, err := s.GetRepoRoot()
+	if err != nil {
+		return false, err
+	}
+
+	_, err = s.runGitCommand("-C", root, "check-ignore", "-q", "--no-index", "--", relPath)
+	if err == nil {
+		return true, nil
+	}
+	if exitCode(err) == 1 {
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to check ignore rules: %w", err)
+}
+
+// IsTracked reports whether a repository-relative path is in the index
+func (s *SystemGitHandler) IsTracked(relPath string) (bool, error) {
+	root, err := s.GetRepoRoot()
+	if err != nil {
+		return false, err
+	}
+
+	output, err := s.runGitCommand("-C", root, "ls-files", "--", relPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to list tracked files: %w", err)
+	}
+	return output != "", nil
+}
+
+// GetCommitLog lists the commits since the specified tag, newest first
+func (s *SystemGitHandler) GetCommitLog(tagName string) ([]CommitEntry, error) {
+	// Records end with \x1e since bodies span several lines
+	args := []string{"log", "--format=%H%x1f%an%x1f%ae%x1f%ct%x1f%s%x1f%b%x1e", s.head()}
+	if tagName != "" {
+		tagRef, err := s.resolveTagRef(tagName)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, "^"+tagRef)
+	}
+
+	output, err := s.runGitCommand(s.traversalArgs(s.pathArgs(args...)...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commit log: %w", err)
+	}
+
+	var entries []CommitEntry
+	for _, record := range strings.Split(output, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x1f", 6)
+		if len(fields) != 6 {
+			continue
+		}
+		timestamp, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse commit time: %w", err)
+		}
+		entries = append(entries, CommitEntry{
+			Hash:    fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			When:    time.Unix(timestamp, 0),
+			Subject: fields[4],
+			Body:    strings.TrimSpace(fields[5]),
+		})
+	}
+	return entries, nil
+}
+
+// CreateTag creates an annotated tag at HEAD, keeping the message verbatim, and
+// signs it with the configured key when signing is not nil
+func (s *SystemGitHandler) CreateTag(name, message string, signing *SigningOptions) error {
+	args := []string{"tag", "-a", "--cleanup=verbatim", "-m", message}
+	if signing != nil {
+		args = append(args, "-s")
+		if signing.Key != "" {
+			args = append(args, "-u", signing.Key)
+		}
+	}
+
+	if _, err := s.runGitCommand(append(args, name, "HEAD")...); err != nil {
+		if signing != nil {
+			return fmt.Errorf("failed to sign tag %s: %s (check user.signingkey, gpg.format and that gpg-agent or ssh-agent can use the key)", name, gitStderr(err))
+		}
+		return fmt.Errorf("failed to create tag %s: %w", name, err)
+	}
+	return nil
+}
+
+// CreateLightweightTag creates a lightweight tag at HEAD
+func (s *SystemGitHandler) CreateLightweightTag(name string) error {
+	if _, err := s.runGitCommand("tag", name, "HEAD"); err != nil {
+		return fmt.Errorf("failed to create tag %s: %s", name, gitStderr(err))
+	}
+	return nil
+}
+
+// MoveTag points an annotated tag at HEAD, replacing any tag of that name
+func (s *SystemGitHandler) MoveTag(name, message string) error {
+	if _, err := s.runGitCommand("tag", "-a", "-f", "--cleanup=verbatim", "-m", message, name, "HEAD"); err != nil {
+		return fmt.Errorf("failed to move tag %s: %s", name, gitStderr(err))
+	}
+	return nil
+}
+
+// FetchHistory fetches the history and tags a shallow clone is missing from origin
+func (s *SystemGitHandler) FetchHistory() (bool, error) {
+	shallow, err := s.runGitCommand("rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, fmt.Errorf("failed to check for a shallow clone: %w", err)
+	}
+	if shallow != "true" {
+		return false, nil
+	}
+	if _, err := s.runGitCommand("fetch", "--quiet", "--unshallow", "--tags", "origin"); err != nil {
+		return false, fmt.Errorf("failed to fetch from origin: %s", RedactURL(gitStderr(err)))
+	}
+	return true, nil
+}
+
+// AppendNote appends a line to the git note of HEAD under refs/notes/<notesRef>
+func (s *SystemGitHandler) AppendNote(notesRef, line string) error {
+	note, _, err := s.ReadNote(notesRef, "HEAD")
+	if err != nil {
+		return err
+	}
+	if note != "" {
+		note += "\n"
+	}
+
+	// notes append would separate entries with blank lines, so rewrite the whole note
+	if _, err := s.runGitCommand("notes", "--ref="+notesRef, "add", "--force", "-m", note+line, "HEAD"); err != nil {
+		return fmt.Errorf("failed to write note: %s", gitStderr(err))
+	}
+	return nil
+}
+
+// ReadNote returns the git note of a revision under refs/notes/<notesRef>
+func (s *SystemGitHandler) ReadNote(notesRef, revision string) (string, bool, error) {
+	commit, err := s.runGitCommand("rev-parse", "--verify", "--quiet", revision+"^{commit}")
+	if err != nil {
+		return "", false, fmt.Errorf("failed to resolve %s: not a commit", revision)
+	}
+
+	note, err := s.runGitCommand("notes", "--ref="+notesRef, "show", commit)
+	if err != nil {
+		if exitCode(err) == 1 {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to read note: %s", gitStderr(err))
+	}
+	return note, true, nil
+}
+
+// GetWorktreeState reports uncommitted changes to tracked files and when the index last changed
+func (s *SystemGitHandler) GetWorktreeState() (versionSchemes.WorktreeState, error) {
+	var state versionSchemes.WorktreeState
+
+	status, err := s.runGitCommand("status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return state, fmt.Errorf("failed to get worktree status: %w", err)
+	}
+	state.Dirty = status != ""
+
+	indexPath, err := s.runGitCommand("rev-parse", "--path-format=absolute", "--git-path", "index")
+	if err != nil {
+		return state, fmt.Errorf("failed to locate index: %w", err)
+	}
+	if info, err := os.Stat(indexPath); err == nil {
+		state.IndexTime = info.ModTime()
+	}
+	return state, nil
+}
+
+// CommitFiles stages and commits the given paths, leaving other staged changes alone
+func (s *SystemGitHandler) CommitFiles(relPaths []string, message string, amend bool) error {
+	root, err := s.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+
+	addArgs := append([]string{"-C", root, "add", "--"}, relPaths...)
+	if _, err := s.runGitCommand(addArgs...); err != nil {
+		return fmt.Errorf("failed to stage files: %w", err)
+	}
+
+	commitArgs := []string{"-C", root, "commit", "--quiet"}
+	if amend {
+		commitArgs = append(commitArgs, "--amend", "--no-edit")
+	} else {
+		commitArgs = append(commitArgs, "-m", message)
+	}
+	commitArgs = append(append(commitArgs, "--"), relPaths...)
+	if _, err := s.runGitCommand(commitArgs...); err != nil {
+		return fmt.Errorf("failed to commit files: %w", err)
+	}
+	return nil
+}
+
 // GetLastTag finds the last reachable tag
-func (s *SystemGitHandler) GetLastTag(branchName string) (string, error) {
-	// For non-main/master branches, find tags from the merge-base with main/master
-	if branchName != "main" && branchName != "master" {
+func (s *SystemGitHandler) GetLastTag(branchName string) (string, bool, error) {
+	// For non-main/master branches, find tags from the merge-base with main/master;
+	// maintenance branches carry their own release tags
+	match, maintenance := s.tagMatchFor(branchName)
+	if !isMainline(branchName) && !maintenance {
 		return s.findTagFromRebasePoint(branchName)
 	}
 
 	// For main/master branches, find the most recent tag
-	output, err := s.runGitCommand("describe", "--tags", "--abbrev=0")
-	if err != nil {
+	return s.describeTag(s.head(), match)
+}
+
+// describeTag finds the nearest tag reachable from start that matches match in
+// place of TagMatch, considering remote tags as well when IncludeRemoteTags is set
+func (s *SystemGitHandler) describeTag(start, match string) (string, bool, error) {
+	// With a distance limit, only the tags found within it are candidates
+	var nearby []string
+	if s.options.MaxTagDistance > 0 {
+		var exceeded bool
+		var err error
+		nearby, exceeded, err = s.tagsWithinDistance(start, match)
+		if err != nil {
+			return "", false, err
+		}
+		if len(nearby) == 0 {
+			if exceeded {
+				return s.tagDistanceExceeded()
+			}
+			return "", false, nil
+		}
+	}
+
+	if s.options.Traversal == TraversalAuthorDate {
+		return s.newestTagByAuthorDate(start, match, nearby)
+	}
+
+	args := []string{"describe", "--tags", "--abbrev=0"}
+	for _, glob := range describeGlobs("", s.tagGlob(match), nearby) {
+		args = append(args, "--match", glob)
+	}
+	for _, excluded := range s.options.ExcludeTags {
+		args = append(args, "--exclude", excluded)
+	}
+	tagName, err := s.runGitCommand(s.traversalArgs(append(args, start)...)...)
+	found := err == nil && s.tagMatchesGlob(tagName, match)
+
+	if s.options.IncludeRemoteTags {
+		remoteTag, remoteDistance, ok := s.describeRemoteTag(start, match, nearby)
+		if ok && !found {
+			tagName, found = remoteTag, true
+		} else if ok {
+			localDistance, err := s.countRange("refs/tags/"+tagName, start)
+			if err != nil {
+				return "", false, fmt.Errorf("failed to measure tag distance: %w", err)
+			}
+			if remoteDistance < localDistance {
+				tagName = remoteTag
+			}
+		}
+	}
+
+	if !found {
 		// No tags found
-		return "v0.0.0", nil
+		return "", false, nil
 	}
+	return tagName, true, nil
+}
 
-	return output, nil
+// describeGlobs returns the --match patterns of git describe: the given tag
+// names when a distance limit selected them, otherwise glob, each under prefix
+func describeGlobs(prefix, glob string, names []string) []string {
+	if names == nil {
+		if glob == "" {
+			return nil
+		}
+		return []string{prefix + glob}
+	}
+	globs := make([]string, len(names))
+	for i, name := range names {
+		globs[i] = prefix + name
+	}
+	return globs
 }
 
-// findTagFromRebasePoint finds tags from the rebase point for feature branches
-func (s *SystemGitHandler) findTagFromRebasePoint(branchName string) (string, error) {
-	// Try to find main or master branch and get merge-base
-	var mergeBase string
-	var err error
+// describeRemoteTag finds the nearest refs/remotes/<remote>/tags/* ref reachable
+// from start that matches match, or is one of nearby when that is set, and
+// returns its bare tag name and distance
+func (s *SystemGitHandler) describeRemoteTag(start, match string, nearby []string) (string, int, bool) {
+	// With --all, describe matches remote refs by their <remote>/<path> name
+	glob := s.tagGlob(match)
+	if glob == "" {
+		glob = "*"
+	}
+	args := []string{"describe", "--all", "--long"}
+	for _, glob := range describeGlobs("*/tags/", glob, nearby) {
+		args = append(args, "--match", glob)
+	}
+	for _, excluded := range s.options.ExcludeTags {
+		args = append(args, "--exclude", "*/tags/"+excluded)
+	}
+	output, err := s.runGitCommand(s.traversalArgs(append(args, start)...)...)
+	if err != nil || !strings.HasPrefix(output, "remotes/") {
+		return "", 0, false
+	}
+
+	// Output looks like remotes/origin/tags/v1.2.3-4-gabc1234
+	hashIndex := strings.LastIndex(output, "-g")
+	if hashIndex < 0 {
+		return "", 0, false
+	}
+	name := output[:hashIndex]
+	countIndex := strings.LastIndex(name, "-")
+	if countIndex < 0 {
+		return "", 0, false
+	}
+	distance, err := strconv.Atoi(name[countIndex+1:])
+	if err != nil {
+		return "", 0, false
+	}
+
+	_, tagName, ok := strings.Cut(strings.TrimPrefix(name[:countIndex], "remotes/"), "/tags/")
+	return tagName, distance, ok && tagName != "" && s.tagMatchesGlob(tagName, match)
+}
+
+// newestTagByAuthorDate picks the reachable tag matching match, and among
+// nearby when that is set, whose commit has the newest author date
+func (s *SystemGitHandler) newestTagByAuthorDate(start, match string, nearby []string) (string, bool, error) {
+	patterns := []string{"refs/tags"}
+	if s.options.IncludeRemoteTags {
+		patterns = append(patterns, "refs/remotes/*/tags/*")
+	}
+
+	// Annotated tags report the commit's date through *authordate, lightweight ones through authordate
+	args := append([]string{"for-each-ref", "--merged=" + start, "--format=%(*authordate:unix)%(authordate:unix) %(refname)"}, patterns...)
+	output, err := s.runGitCommand(args...)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	// A local tag shadows remote tags of the same name
+	dates := make(map[string]int64)
+	local := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		dateField, refName, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		date, err := strconv.ParseInt(dateField, 10, 64)
+		if err != nil {
+			continue // Skip tags pointing at non-commit objects
+		}
+
+		name, isLocal := strings.CutPrefix(refName, "refs/tags/")
+		if !isLocal {
+			_, name, _ = strings.Cut(strings.TrimPrefix(refName, "refs/remotes/"), "/tags/")
+		}
+		if !s.tagMatchesGlob(name, match) || (nearby != nil && !slices.Contains(nearby, name)) {
+			continue
+		}
+		if _, seen := dates[name]; !seen || (isLocal && !local[name]) {
+			dates[name], local[name] = date, isLocal
+		}
+	}
+
+	tagName, newest := "", int64(-1)
+	for name, date := range dates {
+		if date > newest || (date == newest && name > tagName) {
+			tagName, newest = name, date
+		}
+	}
 
-	// Try main first
-	mergeBase, err = s.runGitCommand("merge-base", "HEAD", "main")
+	if tagName == "" {
+		return "", false, nil
+	}
+	return tagName, true, nil
+}
+
+// traversalArgs adds --first-parent to a history-walking git command when
+// TraversalFirstParent is selected
+func (s *SystemGitHandler) traversalArgs(args ...string) []string {
+	if s.options.Traversal == TraversalFirstParent {
+		return append(args[:1:1], append([]string{"--first-parent"}, args[1:]...)...)
+	}
+	return args
+}
+
+// GetAheadBehind counts the commits HEAD and main/master have that the other lacks
+func (s *SystemGitHandler) GetAheadBehind() (string, int, int, error) {
+	mainline, ok := s.mainlineBranch()
+	if !ok {
+		return "", 0, 0, ErrNoMainline
+	}
+	output, err := s.runGitCommand("rev-list", "--left-right", "--count", s.head()+"..."+mainline)
 	if err != nil {
-		// Try master
-		mergeBase, err = s.runGitCommand("merge-base", "HEAD", "master")
+		return "", 0, 0, fmt.Errorf("failed to compare with %s: %w", mainline, err)
+	}
+	var ahead, behind int
+	if _, err := fmt.Sscanf(output, "%d %d", &ahead, &behind); err != nil {
+		return "", 0, 0, fmt.Errorf("failed to parse commit counts: %w", err)
+	}
+	return mainline, ahead, behind, nil
+}
+
+// head returns the commit being versioned: HEAD, or GitOptions.Revision
+func (s *SystemGitHandler) head() string {
+	if s.options.Revision != "" {
+		return s.options.Revision
+	}
+	return "HEAD"
+}
+
+// GetCommitsSinceRevision counts commits since any revision: a tag, branch or commit
+func (s *SystemGitHandler) GetCommitsSinceRevision(revision string) (int, error) {
+	if _, err := s.runGitCommand("rev-parse", "--verify", "--quiet", revision+"^{commit}"); err != nil {
+		return 0, fmt.Errorf("failed to resolve %s: not a commit", revision)
+	}
+
+	output, err := s.runGitCommand(s.traversalArgs(s.pathArgs("rev-list", "--count", s.head(), "^"+revision)...)...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count commits since %s: %w", revision, err)
+	}
+	return strconv.Atoi(output)
+}
+
+// pathArgs appends the Paths and ExcludePaths pathspecs to a commit-listing git command
+func (s *SystemGitHandler) pathArgs(args ...string) []string {
+	if !s.hasPathFilter() {
+		return args
+	}
+
+	args = append(args, "--")
+	if len(s.options.Paths) == 0 {
+		args = append(args, ":/")
+	}
+	for _, path := range s.options.Paths {
+		// ":/" alone means the whole tree; ":/." unexpectedly matches nothing
+		if path = filepath.ToSlash(filepath.Clean(path)); path == "." {
+			args = append(args, ":/")
+		} else {
+			args = append(args, ":/"+path)
+		}
+	}
+	for _, path := range s.options.ExcludePaths {
+		args = append(args, ":(top,exclude)"+path)
+	}
+	return args
+}
+
+// resolveTagRef returns the full ref name for a tag, preferring refs/tags over remote tags
+func (s *SystemGitHandler) resolveTagRef(tagName string) (string, error) {
+	if _, err := s.runGitCommand("rev-parse", "--verify", "--quiet", "refs/tags/"+tagName); err == nil {
+		return "refs/tags/" + tagName, nil
+	}
+
+	if s.options.IncludeRemoteTags {
+		output, err := s.runGitCommand("for-each-ref", "--count=1", "--format=%(refname)", "refs/remotes/*/tags/"+tagName)
+		if err == nil && output != "" {
+			return output, nil
+		}
+	}
+	return "", fmt.Errorf("failed to get tag reference: %s not found", tagName)
+}
+
+// countRange counts commits reachable from to but not from from
+func (s *SystemGitHandler) countRange(from, to string) (int, error) {
+	output, err := s.runGitCommand(s.traversalArgs("rev-list", "--count", from+".."+to)...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(output)
+}
+
+// tagsWithinDistance lists the tags matching match that are at most
+// MaxTagDistance commits behind start, counted like the commits since the tag
+// in the version. Every such tag is on the first MaxTagDistance+1 commits of
+// start in topological order, so only those are searched; exceeded reports
+// that history goes on beyond them, making a missing tag too far rather than absent.
+func (s *SystemGitHandler) tagsWithinDistance(start, match string) ([]string, bool, error) {
+	limit := s.options.MaxTagDistance
+	output, err := s.runGitCommand(s.traversalArgs("rev-list", "--topo-order", "--max-count="+strconv.Itoa(limit+2), start)...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list commits: %w", err)
+	}
+	window := make(map[string]bool)
+	for _, hash := range strings.Fields(output) {
+		window[hash] = true
+	}
+	exceeded := len(window) > limit+1
+
+	patterns := []string{"refs/tags"}
+	if s.options.IncludeRemoteTags {
+		patterns = append(patterns, "refs/remotes/*/tags/*")
+	}
+	output, err = s.runGitCommand(append([]string{"for-each-ref", "--format=%(refname) %(objectname) %(*objectname)"}, patterns...)...)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	// A local tag shadows remote tags of the same name
+	commits := make(map[string]string)
+	local := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		name, isLocal := strings.CutPrefix(fields[0], "refs/tags/")
+		if !isLocal {
+			_, name, _ = strings.Cut(strings.TrimPrefix(fields[0], "refs/remotes/"), "/tags/")
+		}
+		if !s.tagMatchesGlob(name, match) || (local[name] && !isLocal) {
+			continue
+		}
+		commits[name], local[name] = fields[len(fields)-1], isLocal
+	}
+
+	var nearby []string
+	for name, commit := range commits {
+		if !window[commit] {
+			continue
+		}
+		distance, err := s.countRange(commit, start)
 		if err != nil {
-			// If no main/master branch found, fall back to current branch logic
-			return s.GetLastTag("main") // This will use the regular logic
+			return nil, false, fmt.Errorf("failed to measure tag distance: %w", err)
+		}
+		if distance <= limit {
+			nearby = append(nearby, name)
 		}
 	}
+	sort.Strings(nearby)
+	return nearby, exceeded, nil
+}
+
+// findTagFromRebasePoint finds tags from the rebase point for feature branches
+func (s *SystemGitHandler) findTagFromRebasePoint(branchName string) (string, bool, error) {
+	mergeBase, hasMainline, err := s.mergeBaseWithMainline()
+	if err != nil {
+		return "", false, err
+	}
+	if !hasMainline {
+		// If no main/master branch found, fall back to current branch logic
+		return s.describeTag(s.head(), s.options.TagMatch)
+	}
+	if mergeBase == "" {
+		// An orphan branch (e.g. gh-pages) can only be versioned from its own tags
+		if err := s.checkOrphanPolicy(branchName); err != nil {
+			return "", false, err
+		}
+		return s.describeTag(s.head(), s.options.TagMatch)
+	}
 
 	// Find the most recent tag reachable from the merge-base
-	output, err := s.runGitCommand("describe", "--tags", "--abbrev=0", mergeBase)
+	return s.describeTag(mergeBase, s.options.TagMatch)
+}
+
+// mergeBaseWithMainline returns the merge-base of HEAD and main, or master when
+// there is no main. hasMainline is false when neither exists; an empty base
+// with hasMainline set means HEAD is on an orphan branch.
+func (s *SystemGitHandler) mergeBaseWithMainline() (base string, hasMainline bool, err error) {
+	mainline, ok := s.mainlineBranch()
+	if !ok {
+		return "", false, nil
+	}
+	base, err = s.runGitCommand("merge-base", s.head(), mainline)
+	if exitCode(err) == 1 {
+		return "", true, nil
+	}
 	if err != nil {
-		// No tags found
-		return "v0.0.0", nil
+		return "", true, fmt.Errorf("failed to find merge-base with %s: %w", mainline, err)
 	}
+	return base, true, nil
+}
 
-	return output, nil
+// mainlineBranch returns main, or master when there is no main
+func (s *SystemGitHandler) mainlineBranch() (string, bool) {
+	for _, mainline := range []string{"main", "master"} {
+		if _, err := s.runGitCommand("rev-parse", "--verify", "--quiet", mainline+"^{commit}"); err == nil {
+			return mainline, true
+		}
+	}
+	return "", false
+}
+
+// unmergedTags lists the tags whose commits are not ancestors of main/master
+func (s *SystemGitHandler) unmergedTags() ([]string, error) {
+	mainline, ok := s.mainlineBranch()
+	if !ok {
+		return nil, ErrNoMainline
+	}
+	args := []string{"for-each-ref", "--format=%(refname)", "--no-merged=refs/heads/" + mainline, "refs/tags"}
+	if s.options.IncludeRemoteTags {
+		args = append(args, "refs/remotes/*/tags/*")
+	}
+	output, err := s.runGitCommand(args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags not merged into %s: %w", mainline, err)
+	}
+
+	var tags []string
+	for _, refName := range strings.Fields(output) {
+		name, isLocal := strings.CutPrefix(refName, "refs/tags/")
+		if !isLocal {
+			_, name, _ = strings.Cut(strings.TrimPrefix(refName, "refs/remotes/"), "/tags/")
+		}
+		if name != "" {
+			tags = append(tags, name)
+		}
+	}
+	return tags, nil
+}
+
+// isOrphanCalVer reports whether HEAD is on an orphan branch versioned with OrphanCalVer
+func (s *SystemGitHandler) isOrphanCalVer(branchName string) (bool, error) {
+	if s.options.OrphanPolicy != OrphanCalVer || isMainline(branchName) {
+		return false, nil
+	}
+	base, hasMainline, err := s.mergeBaseWithMainline()
+	return hasMainline && base == "", err
 }
 
 // GetCommitsSinceTag counts commits since the specified tag
 func (s *SystemGitHandler) GetCommitsSinceTag(tagName string) (int, error) {
-	if tagName == "v0.0.0" {
+	return s.countCommitsSinceTag(tagName)
+}
+
+// GetCommitsSinceTagAfter counts commits since the specified tag made at or after a time
+func (s *SystemGitHandler) GetCommitsSinceTagAfter(tagName string, after time.Time) (int, error) {
+	return s.countCommitsSinceTag(tagName, "--since="+after.Format(time.RFC3339))
+}
+
+// countCommitsSinceTag runs rev-list --count since the tag with extra commit filters
+func (s *SystemGitHandler) countCommitsSinceTag(tagName string, filters ...string) (int, error) {
+	countArgs := append([]string{"rev-list", "--count"}, filters...)
+	if tagName == "" {
 		// Count all commits if no tag exists
-		output, err := s.runGitCommand("rev-list", "--count", "HEAD")
+		output, err := s.runGitCommand(s.traversalArgs(s.pathArgs(append(countArgs, s.head())...)...)...)
 		if err != nil {
 			return 0, fmt.Errorf("failed to count all commits: %w", err)
 		}
@@ -207,12 +1147,17 @@ func (s *SystemGitHandler) GetCommitsSinceTag(tagName string) (int, error) {
 	}
 
 	// Check if we're exactly on the tag
-	currentHash, err := s.runGitCommand("rev-parse", "HEAD")
+	currentHash, err := s.runGitCommand("rev-parse", s.head())
 	if err != nil {
 		return 0, fmt.Errorf("failed to get current commit hash: %w", err)
 	}
 
-	tagHash, err := s.runGitCommand("rev-parse", tagName+"^{commit}")
+	tagRef, err := s.resolveTagRef(tagName)
+	if err != nil {
+		return 0, err
+	}
+
+	tagHash, err := s.runGitCommand("rev-parse", tagRef+"^{commit}")
 	if err != nil {
 		return 0, fmt.Errorf("failed to get tag commit hash: %w", err)
 	}
@@ -222,7 +1167,7 @@ func (s *SystemGitHandler) GetCommitsSinceTag(tagName string) (int, error) {
 	}
 
 	// Count commits since tag
-	output, err := s.runGitCommand("rev-list", "--count", "HEAD", "^"+tagName)
+	output, err := s.runGitCommand(s.traversalArgs(s.pathArgs(append(countArgs, s.head(), "^"+tagRef)...)...)...)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count commits since tag: %w", err)
 	}
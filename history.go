@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+)
+
+// HistoryCmd shows what the release tags would be called under CalVer
+type HistoryCmd struct {
+	VersionFlags `kong:"embed"`
+
+	JSON bool `kong:"name='json',help='Print the report as JSON'"`
+}
+
+// historyRelease is one row of the history report
+type historyRelease struct {
+	Tag    string    `json:"tag"`
+	Date   time.Time `json:"date"`
+	CalVer string    `json:"calver"`
+}
+
+// runHistory renders every release tag reachable from HEAD as a CalVer
+// version dated by the tag itself: the tagger date of annotated tags and the
+// commit date of lightweight ones, rather than today
+func runHistory(cli *CLI) {
+	gitHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", gitOptionsFor(cli))
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+	}
+	releases, err := gitHandler.GetReleaseTags()
+	if err != nil {
+		fatalf(ErrorGit, "Failed to read release tags: %v", err)
+	}
+	options, err := versioningOptionsFor(cli)
+	if err != nil {
+		fatalf(ErrorUsage, "Invalid versioning options: %v", err)
+	}
+	report := calVerHistory(releases, options)
+
+	if cli.History.JSON {
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			fatalf(ErrorInternal, "Failed to encode release history: %v", err)
+		}
+		fmt.Println(string(encoded))
+		return
+	}
+	if len(report) == 0 {
+		fmt.Println("No release tags reachable from HEAD")
+		return
+	}
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "TAG\tDATE\tCALVER")
+	for _, row := range report {
+		fmt.Fprintf(writer, "%s\t%s\t%s\n", row.Tag, row.Date.Format("2006-01-02"), row.CalVer)
+	}
+	writer.Flush()
+}
+
+// calVerHistory names releases, ordered oldest first, with the --calver-format
+// fields of their UTC tag date. The first release of a period gets the date
+// alone, as the generator prints on a tagged commit; later releases in the
+// same period are numbered 1, 2, ... so no two tags share a CalVer version.
+func calVerHistory(releases []gittype.ReleaseTag, options versionSchemes.VersioningOptions) []historyRelease {
+	options.Scheme = versionSchemes.SchemeCalVer
+	options.Hash, options.Variant = false, ""
+	options.CalVerDirty, options.Worktree, options.PeriodCommits = versionSchemes.CalVerDirtyNone, nil, nil
+
+	vg := versionSchemes.NewVersionGenerator()
+	report := make([]historyRelease, 0, len(releases))
+	inPeriod := make(map[string]int)
+	for _, release := range releases {
+		date := release.Date.UTC()
+		period := versionSchemes.FormatCalVer(options.CalVerFormat, date)
+		options.Clock = versionSchemes.FixedClock(date)
+		report = append(report, historyRelease{
+			Tag:    release.Name,
+			Date:   date,
+			CalVer: vg.GenerateVersion(release.Name, inPeriod[period], "", "main", options),
+		})
+		inPeriod[period]++
+	}
+	return report
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	gittype "version-generator/gitType"
+
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+// InitCmd writes a starter config file at the repository root
+type InitCmd struct {
+	VersionFlags `kong:"embed"`
+	OutputFlags  `kong:"embed"`
+
+	Overwrite bool `kong:"help='Replace an existing config file'"`
+	DryRun    bool `kong:"help='Print the config instead of writing it'"`
+}
+
+// initSkippedFlags are never written to the scaffolded defaults
+var initSkippedFlags = []string{"help", "version", "config", "profile", "overwrite", "dry-run"}
+
+// initExamples follows the defaults of a scaffolded config, showing the other
+// sections commented out
+const initExamples = `
+# Flag values applied on the first branch matching each glob, over the defaults
+# branches:
+#   - match: release/*
+#     scheme: semver
+#   - match: feature/*
+#     hash: true
+
+# Named sets of flag values, selected with --profile NAME
+# profiles:
+#   ci:
+#     output-format: json
+#     checksums: sha256
+
+# Components versioned separately with --components
+# components:
+#   - name: api
+#     path: services/api
+#     tag_prefix: api/
+#     depends_on: [lib]
+`
+
+// runInit scaffolds a config file whose defaults are the flags set on this
+// command line, e.g. version-generator init --scheme semver --go
+func runInit(cli *CLI, ctx *kong.Context) {
+	var document yaml.Node
+	if err := document.Encode(map[string]any{"defaults": initDefaults(ctx)}); err != nil {
+		fatalf(ErrorConfig, "Failed to encode config: %v", err)
+	}
+	document.HeadComment = "version-generator configuration: keys are flag names without --, and\nflags given on the command line or as VG_* variables override them"
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		fatalf(ErrorConfig, "Failed to encode config: %v", err)
+	}
+	content := append(buffer.Bytes(), initExamples...)
+
+	if cli.Init.DryRun {
+		fmt.Print(string(content))
+		return
+	}
+	configPath, err := initConfigPath(cli)
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to find repository root: %v", err)
+	}
+	if _, err := os.Stat(configPath); err == nil && !cli.Init.Overwrite {
+		fatalf(ErrorConfig, "%s already exists (use --overwrite to replace it)", configPath)
+	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+		fatalf(ErrorConfig, "Failed to check config: %v", err)
+	}
+	if err := os.WriteFile(configPath, content, 0644); err != nil {
+		fatalf(ErrorConfig, "Failed to write config: %v", err)
+	}
+	fmt.Printf("Wrote %s\n", configPath)
+}
+
+// initConfigPath returns --config or the config file at the repository root
+func initConfigPath(cli *CLI) (string, error) {
+	if cli.Config != "" {
+		return cli.Config, nil
+	}
+	gitHandler, err := gittype.GetGitHandler(cli.InBuiltGit, ".")
+	if err != nil {
+		return "", err
+	}
+	repoRoot, err := gitHandler.GetRepoRoot()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(repoRoot, defaultConfigFile), nil
+}
+
+// initDefaults collects the values of the global and init flags given on the
+// command line or through their environment variables, keyed by flag name,
+// always including the scheme
+func initDefaults(ctx *kong.Context) map[string]any {
+	given := map[string]bool{"scheme": true}
+	for _, element := range ctx.Path {
+		if element.Flag != nil {
+			given[element.Flag.Name] = true
+		}
+	}
+	defaults := map[string]any{}
+	for _, flag := range ctx.Flags() {
+		for _, env := range flag.Envs {
+			if _, set := os.LookupEnv(env); set {
+				given[flag.Name] = true
+			}
+		}
+		if !given[flag.Name] || slices.Contains(initSkippedFlags, flag.Name) {
+			continue
+		}
+		value := flag.Target.Interface()
+		if duration, ok := value.(time.Duration); ok {
+			value = duration.String()
+		}
+		defaults[flag.Name] = value
+	}
+	return defaults
+}
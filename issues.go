@@ -0,0 +1,34 @@
+package main
+
+import (
+	"regexp"
+
+	gittype "version-generator/gitType"
+)
+
+// issuePatternFor compiles --issue-pattern
+func issuePatternFor(cli *CLI) *regexp.Regexp {
+	pattern, err := regexp.Compile(cli.IssuePattern)
+	if err != nil {
+		fatalf(ErrorUsage, "Invalid --issue-pattern: %v", err)
+	}
+	return pattern
+}
+
+// issueKeys returns the issue keys matched in the subjects and bodies of
+// commits, each once, in the order they were first referenced (oldest commit first)
+func issueKeys(pattern *regexp.Regexp, commits []gittype.CommitEntry) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for i := len(commits) - 1; i >= 0; i-- {
+		for _, text := range []string{commits[i].Subject, commits[i].Body} {
+			for _, key := range pattern.FindAllString(text, -1) {
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+	}
+	return keys
+}
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"os"
+	"path"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	filetype "version-generator/fileType"
 	gittype "version-generator/gitType"
+	"version-generator/pkg/versiongen"
 	"version-generator/versionSchemes"
 
 	"github.com/alecthomas/kong"
@@ -20,29 +26,210 @@ var (
 	BuildDate = "unknown" // Set via -ldflags at build time
 )
 
-type VersionInfo struct {
-	Branch       string
-	LastTag      string
-	CommitsSince int
-	ShortHash    string
-	Version      string
-}
+// startedAt is when the command started running; --timeout counts from it
+var startedAt time.Time
 
+// CLI is the command line. Only the repository and git selection flags are
+// global; the flags shaping the version and its outputs belong to the commands
+// that use them, and useCommandFlags copies those of the selected command to
+// the embedded groups, so commands read them as cli.Scheme or cli.Go.
 type CLI struct {
-	Version    kong.VersionFlag `kong:"short='v',help='Show version information'"`
-	Semver     bool             `kong:"help='Use Semantic Versioning format'"`
-	CalVer     bool             `kong:"help='Use Calendar Versioning format'"`
-	Simple     bool             `kong:"help='Use simple version format (no branch info)'"`
-	Hash       bool             `kong:"help='Include short hash in version'"`
-	InBuiltGit bool             `kong:"short='i',help='Use built-in go-git library instead of system git'"`
-	Go         bool             `kong:"short='g',help='Generate Go format version file'"`
-	GoPath     string           `kong:"help='Path for Go file (default: version.go)',placeholder='PATH'"`
-	Cpp        bool             `kong:"short='c',help='Generate C++ format version file'"`
-	CppPath    string           `kong:"help='Path for C++ file (default: version.h)',placeholder='PATH'"`
-	Yaml       bool             `kong:"short='y',help='Generate YAML format version file'"`
-	YamlPath   string           `kong:"help='Path for YAML file (default: version.yaml)',placeholder='PATH'"`
-	File       bool             `kong:"short='f',help='Write version to file'"`
-	FilePath   string           `kong:"help='Path for file (default: .VERSION)',placeholder='PATH'"`
+	Version               kong.VersionFlag `kong:"short='v',env='-',help='Show version information'"`
+	OutputFormat          string           `kong:"enum='text,json',default='text',help='Print the version, warnings and errors as text or JSON'" json:"-"`
+	InBuiltGit            bool             `kong:"short='i',help='Use built-in go-git library instead of system git'"`
+	SSHKey                string           `kong:"name='ssh-key',help='Private key for ssh remotes of the built-in git backend (default: ssh-agent, then ~/.ssh/id_*); its passphrase is read from VG_SSH_KEY_PASSPHRASE',placeholder='FILE'" json:"-"`
+	TokenEnv              string           `kong:"default='GIT_TOKEN',help='Environment variable with a token for HTTPS remotes of the built-in git backend',placeholder='NAME'" json:"-"`
+	Netrc                 string           `kong:"help='netrc file with HTTPS credentials for the built-in git backend (default: $NETRC or ~/.netrc)',placeholder='FILE'" json:"-"`
+	MaxTagDistance        int              `kong:"help='Maximum number of commits to walk back looking for a tag (0 for unlimited)',default='0',placeholder='N'"`
+	OnTagDistance         string           `kong:"help='When no tag is within --max-tag-distance: zero (use v0.0.0) or error',enum='zero,error',default='zero'"`
+	OnNoTags              string           `kong:"help='When no tag exists: zero (v0.0.0), initial (--initial-version) or error',enum='zero,initial,error',default='zero'"`
+	Timeout               time.Duration    `kong:"help='Give up generating the version after this long (e.g. 30s, 2m; 0 for no limit)',default='0',placeholder='DURATION'"`
+	LockTimeout           time.Duration    `kong:"help='Keep retrying while another process holds a git lock such as index.lock (0 to fail at once)',default='10s',placeholder='DURATION'" json:"-"`
+	OnOrphan              string           `kong:"help='Branch without history in common with main/master: own-tags, calver or error',enum='own-tags,calver,error',default='own-tags'"`
+	Always                bool             `kong:"help='Without a reachable tag, report g<hash> (or <initial>+<count>.g<hash> with --initial-version)'"`
+	InitialVersion        string           `kong:"help='Baseline version used when no tag exists (implies --on-no-tags=initial)',placeholder='VERSION'"`
+	RemoteTags            bool             `kong:"help='Also consider tags stored under refs/remotes/<remote>/tags'"`
+	MergedOnly            bool             `kong:"help='Only consider tags whose commits are ancestors of main/master'"`
+	NoReplaceObjects      bool             `kong:"help='Ignore replace refs and grafts when walking history'"`
+	NoMaintenanceBranches bool             `kong:"help='Do not limit tag lookups on maintenance branches such as 1.4.x to their release line (v1.4.*)'"`
+	Traversal             string           `kong:"enum='all,first-parent,author-date',default='all',help='History traversal: all, first-parent or author-date'"`
+	TagPrefix             string           `kong:"help='Only consider tags starting with this prefix, which is left out of the version (e.g. release-)',placeholder='PREFIX'"`
+	TagMatch              string           `kong:"help='Only consider tags matching this glob, prefix included (e.g. v[0-9]*)',placeholder='GLOB'"`
+	Sanitize              string           `kong:"enum='semver,lower',default='semver',help='How branch names are cleaned for versions: semver (letters, digits and hyphens) or lower (the same, lower-cased)'"`
+	Progress              bool             `kong:"help='Show a progress line on stderr while the built-in git backend walks long histories (not in CI or when redirected)'" json:"-"`
+	Config                string           `kong:"type='existingfile',help='Configuration file (default: .version-generator.yaml at the repository root)',placeholder='PATH'"`
+	Profile               string           `kong:"help='Apply the flag values of a profile from the config file',placeholder='NAME'"`
+	ReadOnly              bool             `kong:"help='Refuse operations that write to the repository and fail if anything under .git changed during the run'" json:"-"`
+	Offline               bool             `kong:"help='Never use the network: fail at once when a requested operation needs it, such as fetching history or querying a registry'" json:"-"`
+	NotesRef              string           `kong:"default='versions',help='Notes ref used by --note and notes (under refs/notes/)',placeholder='REF'" json:"-"`
+
+	VersionFlags `kong:"-"`
+	OutputFlags  `kong:"-"`
+
+	Generate      GenerateCmd      `kong:"cmd,default='withargs',help='Generate version and print it or write the selected file (default)'" json:"-"`
+	Restore       RestoreCmd       `kong:"cmd,help='Restore output files from their backup copies'" json:"-"`
+	Stamp         StampCmd         `kong:"cmd,help='Regenerate the output files and commit them'" json:"-"`
+	Print         PrintCmd         `kong:"cmd,help='Print the version or one output format without writing any file'" json:"-"`
+	Validate      ValidateCmd      `kong:"cmd,help='Check the flags, config and repository state, and that the selected output files are up to date'" json:"-"`
+	TagRelease    TagReleaseCmd    `kong:"cmd,aliases='tag',help='Create an annotated release tag with a templated message'" json:"-"`
+	Bump          BumpCmd          `kong:"cmd,help='Tag HEAD with the next major, minor or patch release after the last tag'" json:"-"`
+	Changed       ChangedCmd       `kong:"cmd,help='List configured components changed since a revision'" json:"-"`
+	Notes         NotesCmd         `kong:"cmd,help='Print the versions recorded with --note for a commit'" json:"-"`
+	Stats         StatsCmd         `kong:"cmd,help='Report release cadence and commits per release from the tag history'" json:"-"`
+	History       HistoryCmd       `kong:"cmd,help='Show the CalVer version each release tag would have had, dated by the tag'" json:"-"`
+	CheckRegistry CheckRegistryCmd `kong:"cmd,help='Compare the version with the latest one published to a Docker, Go module or npm registry'" json:"-"`
+	MigrateConfig MigrateConfigCmd `kong:"cmd,help='Replace deprecated flags in a config file or command line'" json:"-"`
+	Nightly       NightlyCmd       `kong:"cmd,help='Generate a date-stamped nightly version of the next release and optionally move the nightly tag'" json:"-"`
+	Action        ActionCmd        `kong:"cmd,help='Run as a GitHub Action: read INPUT_* flags, fetch shallow history and write GITHUB_OUTPUT'" json:"-"`
+	Breaking      BreakingCmd      `kong:"cmd,help='Report breaking changes since the last tag and fail on branches that forbid them'" json:"-"`
+	Branches      BranchesCmd      `kong:"cmd,help='Print the version each local or remote-tracking branch would produce'" json:"-"`
+	Resolve       ResolveCmd       `kong:"cmd,help='Find the commit a generated version was built from and print its hash'" json:"-"`
+	Init          InitCmd          `kong:"cmd,help='Write a .version-generator.yaml with the flags set on this command line as defaults'" json:"-"`
+	Pin           PinCmd           `kong:"cmd,help='Record the version of HEAD and its options in a lockfile for --from-pin'" json:"-"`
+}
+
+// VersionFlags select the scheme of the version and what it is derived from
+type VersionFlags struct {
+	Scheme              string   `kong:"enum='default,semver,calver,simple',default='default',help='Version scheme: default, semver, calver or simple'"`
+	Semver              bool     `kong:"help='Use Semantic Versioning format (deprecated: use --scheme semver)'"`
+	CalVer              bool     `kong:"help='Use Calendar Versioning format (deprecated: use --scheme calver)'"`
+	CalVerDirty         string   `kong:"enum='none,bump,dev',default='none',help='CalVer with uncommitted changes: none, bump (micro + 1) or dev (.devN)'"`
+	CalVerFormat        string   `kong:"name='calver-format',default='YYYY.0M',help='CalVer date fields: YYYY, YY, 0Y, MM, 0M, WW, 0W (ISO week), DD, 0D, GGGG (ISO week-year)',placeholder='FORMAT'"`
+	Format              string   `kong:"help='Go text/template of the version, e.g. {{.Tag}}+{{.CommitsSince}}; fields: Tag, CommitsSince, Branch, Main, ShortHash and Date \"2006.01\"',placeholder='TEMPLATE'"`
+	CalVerReset         string   `kong:"name='calver-reset',enum='none,week,month,year',default='none',help='Restart the CalVer counter each week, month or year instead of counting since the last tag'"`
+	DescribeCompat      bool     `kong:"help='Print the version exactly as git describe --tags --dirty --always would'"`
+	Variant             string   `kong:"help='Build flavor (e.g. debug, asan, enterprise) added to the version and generated files',placeholder='NAME'"`
+	Channel             string   `kong:"enum='none,stable,beta,rc,nightly',default='none',help='Build channel: stable (the tag), beta (<tag>-beta.N), rc (<next>-rc.N, one above the last rc tag) or nightly (<date>+<hash>), also recorded in generated files'"`
+	CandidateBump       string   `kong:"enum='patch,minor,major',default='patch',help='Release --channel rc leads up to after a release tag: patch, minor or major'"`
+	Meta                []string `kong:"sep='none',help='Build metadata key=value appended to the + section (repeatable)',placeholder='KEY=VALUE'"`
+	Simple              bool     `kong:"help='Use simple version format, no branch info (deprecated: use --scheme simple)'"`
+	DockerTag           bool     `kong:"help='Use Docker tag format: <tag>-<count> or <tag>-<branch>-<count>, without +'"`
+	DockerImage         string   `kong:"help='With --docker-tag, refuse to emit a tag that already exists for this image (e.g. ghcr.io/org/app)',placeholder='IMAGE'"`
+	DockerRegistry      string   `kong:"help='Registry base URL for --docker-image (default: derived from the image name)',placeholder='URL'"`
+	Hash                bool     `kong:"help='Include short hash in version'"`
+	Dirty               bool     `kong:"help='Append --dirty-suffix to the version when tracked files have uncommitted changes'"`
+	DirtySuffix         string   `kong:"default='-dirty',help='Suffix --dirty appends to the version',placeholder='SUFFIX'"`
+	MaxLength           int      `kong:"help='Shorten the branch name, adding a digest, so the version fits in N characters (0 for unlimited)',default='0',placeholder='N'"`
+	CountSeparator      string   `kong:"help='Separator before the commit count: +, ., - or _ (default: the scheme\\'s own)',placeholder='SEP'"`
+	HashSeparator       string   `kong:"help='Separator before the short hash: +, ., - or _ (default: +)',placeholder='SEP'"`
+	MaxAge              string   `kong:"help='Warn when the last tag is older than this (e.g. 30d, 2w, 72h)',placeholder='DURATION'"`
+	Conventional        bool     `kong:"help='Version untagged commits as the release their Conventional Commits lead to: major for breaking changes, minor for feat, patch otherwise'"`
+	RequireConventional bool     `kong:"help='Check that the commits since the last tag follow Conventional Commits'"`
+	OnUnconventional    string   `kong:"help='When --require-conventional finds other commits: error, or unverified to add an -unverified pre-release label',enum='error,unverified',default='error'"`
+	OnMaxAge            string   `kong:"help='When the last tag is older than --max-age: warn or error',enum='warn,error',default='warn'"`
+	OnTimeout           string   `kong:"help='When --timeout runs out: error, or hash to report g<hash> only',enum='error,hash',default='error'"`
+	AtTag               string   `kong:"help='Generate the version of a release tag as if HEAD were its commit, e.g. to rebuild v1.2.0 without checking it out; the working tree is ignored',placeholder='TAG'"`
+	FromPin             string   `kong:"type='existingfile',help='Regenerate the version and outputs recorded by pin, using its options instead of the config file',placeholder='FILE'" json:"-"`
+}
+
+// OutputFlags select the output files and the details written to them
+type OutputFlags struct {
+	AutoOutput     bool   `kong:"help='Select the output files for the project files found in the current directory: go.mod (-g), CMakeLists.txt (-c), Chart.yaml (appVersion) and package.json (--json-file)'"`
+	Go             bool   `kong:"short='g',help='Generate Go format version file'"`
+	GoPath         string `kong:"help='Path for Go file (default: version.go)',placeholder='PATH'"`
+	GoFormat       string `kong:"help='Shape of the Go file: const, or map for a BuildInfo map and Build struct with commit, branch, date, channel and variant',enum='const,map',default='const'"`
+	GoExpvar       bool   `kong:"help='Generate Go file publishing the build info as the expvar build, with the Go version and VCS settings of debug.ReadBuildInfo'"`
+	GoExpvarPath   string `kong:"help='Path for Go expvar file (default: version_expvar.go)',placeholder='PATH'"`
+	Cpp            bool   `kong:"short='c',help='Generate C++ format version file'"`
+	CppPath        string `kong:"help='Path for C++ file (default: version.h)',placeholder='PATH'"`
+	Yaml           bool   `kong:"short='y',help='Generate YAML format version file'"`
+	YamlPath       string `kong:"help='Path for YAML file (default: version.yaml)',placeholder='PATH'"`
+	YamlMerge      bool   `kong:"help='Merge version into an existing YAML file instead of overwriting it'"`
+	YamlKey        string `kong:"help='Dotted key path for the version in YAML file (default: version)',placeholder='KEY'"`
+	File           bool   `kong:"short='f',help='Write version to file'"`
+	FilePath       string `kong:"help='Path for file (default: .VERSION)',placeholder='PATH'"`
+	FileFormat     string `kong:"help='Shape of the version file: bare, keyvalue or layout',enum='bare,keyvalue,layout',default='bare'"`
+	FileLayout     string `kong:"help='Line layout for --file-format=layout (%v version, %t tag, %c count, %h hash, %H full hash, %b branch, %a variant)',placeholder='LAYOUT'"`
+	Tfvars         bool   `kong:"help='Generate Terraform variables file'"`
+	TfvarsPath     string `kong:"help='Path for Terraform file (default: version.auto.tfvars)',placeholder='PATH'"`
+	Packer         bool   `kong:"help='Generate Packer JSON variables file'"`
+	PackerPath     string `kong:"help='Path for Packer file (default: version.auto.pkrvars.json)',placeholder='PATH'"`
+	JSONFile       bool   `kong:"name='json-file',help='Generate JSON file with the version and how it was derived'"`
+	JSONPath       string `kong:"name='json-path',help='Path for JSON file (default: version.json)',placeholder='PATH'"`
+	CycloneDX      bool   `kong:"name='cyclonedx',help='Generate CycloneDX BOM fragment with the metadata.component name, version, commit and VCS URL'"`
+	CycloneDXPath  string `kong:"name='cyclonedx-path',help='Path for CycloneDX file (default: version.cdx.json)',placeholder='PATH'"`
+	CycloneDXName  string `kong:"name='cyclonedx-name',help='Component name in the CycloneDX file (default: the repository name of the origin remote)',placeholder='NAME'"`
+	Nix            bool   `kong:"help='Generate Nix attribute set file'"`
+	NixPath        string `kong:"help='Path for Nix file (default: version.nix)',placeholder='PATH'"`
+	Shell          bool   `kong:"help='Generate shell script snippet'"`
+	ShellPath      string `kong:"help='Path for shell file (default: version.sh)',placeholder='PATH'"`
+	PowerShell     bool   `kong:"name='powershell',help='Generate PowerShell script snippet'"`
+	PowerShellPath string `kong:"name='powershell-path',help='Path for PowerShell file (default: version.ps1)',placeholder='PATH'"`
+	Ini            bool   `kong:"help='Generate INI format version file'"`
+	IniPath        string `kong:"help='Path for INI file (default: version.ini)',placeholder='PATH'"`
+	Rc             bool   `kong:"help='Generate Windows resource script with VERSIONINFO'"`
+	RcPath         string `kong:"help='Path for resource script (default: version.rc)',placeholder='PATH'"`
+	Jenkins        bool   `kong:"help='Generate Java properties file with VERSION* variables for Jenkins'"`
+	JenkinsPath    string `kong:"help='Path for Jenkins properties file (default: version.properties)',placeholder='PATH'"`
+	TeamCity       bool   `kong:"name='teamcity',help='Print TeamCity service messages setting the build number and env.VERSION* parameters instead of the version'"`
+	NoNewline      bool   `kong:"help='Omit the trailing newline in generated files'"`
+	Bom            bool   `kong:"help='Prepend a UTF-8 byte order mark to generated files'"`
+	LineEnding     string `kong:"help='Line ending for generated files: lf or crlf',enum='lf,crlf',default='lf'"`
+	Provenance     bool   `kong:"help='Embed command line, options hash and commit in a generated-file header'"`
+	Diff           bool   `kong:"help='Show a unified diff of the output file instead of writing it'" json:"-"`
+	Backup         bool   `kong:"help='Keep a copy of an existing output file before overwriting it'"`
+	BackupSuffix   string `kong:"help='Suffix for backup copies',default='.bak',placeholder='SUFFIX'"`
+	Force          bool   `kong:"help='Allow writing files outside the repository root or into .git'"`
+	AtomicOutputs  bool   `kong:"help='Write all output files to temporary files first and replace them only when every one succeeded'"`
+	Checksums      string `kong:"enum='none,sha256,sha512',default='none',help='Write a <file>.<algorithm> checksum next to each output file and a combined SHA256SUMS (or SHA512SUMS): none, sha256 or sha512'"`
+	SignOutput     string `kong:"enum='none,gpg,cosign',default='none',help='Write a detached signature next to each output file and SUMS file: none, gpg (<file>.asc) or cosign (<file>.sigstore.json, keyless unless --sign-output-key)'"`
+	SignOutputKey  string `kong:"help='Key for --sign-output: a gpg key ID, or a cosign key reference',placeholder='KEY'"`
+	Shortlog       bool   `kong:"help='List the commits since the last tag (hash, author, subject) in JSON and YAML output'"`
+	MaxCommits     int    `kong:"default='100',help='Most commits listed by --shortlog, newest first (0 for all)',placeholder='N'"`
+	IssueKeys      bool   `kong:"help='List the issue keys referenced by commit messages since the last tag in JSON and YAML output'"`
+	IssueFlags     `kong:"embed"`
+	Contributors   bool   `kong:"help='List the authors and Co-authored-by trailers of the commits since the last tag in JSON and YAML output'"`
+	URLTemplate    string `kong:"name='url-template',help='Link the commit in generated files: a layout like https://git.example.com/repo/commit/%H, or auto to derive it from the GitHub, GitLab or Bitbucket origin remote',placeholder='TEMPLATE'"`
+	AheadBehind    bool   `kong:"help='Count the commits ahead of and behind main (or master) in JSON and YAML output'"`
+	IntoArchive    string `kong:"help='Add the output file (or the bare version) to an existing .zip, .tar or .tar.gz instead of writing it, e.g. dist.tar.gz:VERSION',placeholder='ARCHIVE:MEMBER'"`
+	Gitignore      string `kong:"help='Keep the output file ignored (ignore) or assert it is committed (track)',enum='none,ignore,track',default='none'"`
+	Note           bool   `kong:"help='Record the generated version and build metadata as a git note on HEAD'" json:"-"`
+}
+
+// IssueFlags find issue keys in commit messages, for --issue-keys and the
+// tag-release changelog
+type IssueFlags struct {
+	IssuePattern string `kong:"default='[A-Z][A-Z0-9]+-[0-9]+',help='Regular expression matching issue keys, used by --issue-keys and the tag-release changelog',placeholder='REGEX'"`
+}
+
+// GenerateCmd generates the version and prints it or writes the selected output files
+type GenerateCmd struct {
+	VersionFlags `kong:"embed"`
+	OutputFlags  `kong:"embed"`
+
+	AllSchemes    bool   `kong:"help='Print the version under every scheme (default, semver, calver, simple, docker, pep440)'"`
+	SchemesFormat string `kong:"enum='table,json',default='table',help='Output of --all-schemes: table or json'"`
+	Modules       bool   `kong:"help='Version every go.work module separately, writing its Go file and printing a JSON summary'"`
+	Components    bool   `kong:"help='Version every component declared in the config, cascading changes to dependent components'"`
+	Recursive     bool   `kong:"help='Run the generator in every directory below the current one that has a .version-generator.yaml, with that file as its config, and print a JSON summary'" json:"-"`
+	ReportFile    string `kong:"hidden,help='Write the version as JSON for the --recursive run that started this one'" json:"-"`
+	SummaryFormat string `kong:"enum='json,jsonl',default='json',help='Summary of --modules, --components and --recursive: one JSON array (json) or one JSON object per line as each entry completes (jsonl)'"`
+}
+
+// useCommandFlags copies the flag groups embedded in the selected command to
+// cli. The flags of generate are accepted before any command name, since it is
+// the default command, so when another command follows them they are rejected.
+func useCommandFlags(cli *CLI, ctx *kong.Context) error {
+	command := ctx.Selected()
+	if command == nil {
+		return nil
+	}
+	flags := ctx.Flags()
+	for _, element := range ctx.Path {
+		if element.Flag != nil && !slices.Contains(flags, element.Flag) {
+			return fmt.Errorf("--%s is not a flag of %s", element.Flag.Name, command.Name)
+		}
+	}
+	if group := command.Target.FieldByName("VersionFlags"); group.IsValid() {
+		cli.VersionFlags = group.Interface().(VersionFlags)
+	}
+	if group := command.Target.FieldByName("OutputFlags"); group.IsValid() {
+		cli.OutputFlags = group.Interface().(OutputFlags)
+	}
+	if group := command.Target.FieldByName("IssueFlags"); group.IsValid() {
+		cli.IssueFlags = group.Interface().(IssueFlags)
+	}
+	return nil
 }
 
 // getAppVersion returns the version of the application
@@ -72,94 +259,630 @@ func main() {
 	// Get version for help display
 	version := getAppVersion()
 
-	kong.Parse(&cli,
+	options := []kong.Option{
 		kong.Name("version-generator"),
 		kong.Description(fmt.Sprintf("Git Version Generator - Generate version numbers from git repository state\n\nVersion: %s", version)),
 		kong.Vars{"version": version},
+		kong.DefaultEnvars("VG"),
 		kong.UsageOnError(),
 		kong.ConfigureHelp(kong.HelpOptions{
 			Compact: true,
 		}),
-	)
+	}
+	ctx := parseCommandLine(&cli, options...)
+	if ctx.Command() == "action" {
+		trustWorkspace()
+	}
+
+	// Config defaults and profiles supply flag values, so parse again with them as
+	// resolvers; init scaffolds a config from the command line alone, and a pin
+	// replaces the config with the options it recorded
+	var resolvers []kong.Resolver
+	switch {
+	case ctx.Command() == "init":
+	case cli.FromPin != "":
+		pin, err := readPin(cli.FromPin)
+		if err != nil {
+			fatalf(ErrorConfig, "Failed to read pin: %v", err)
+		}
+		resolvers = append(resolvers, &flagValues{source: cli.FromPin, values: pin.Options})
+	default:
+		var err error
+		resolvers, err = flagResolvers(&cli)
+		if err != nil {
+			fatalf(ErrorConfig, "Failed to load configuration: %v", err)
+		}
+	}
+	if ctx.Command() == "action" {
+		resolvers = append(resolvers, actionInputs{})
+	}
+	if len(resolvers) > 0 {
+		cli = CLI{}
+		ctx = parseCommandLine(&cli, append(options, kong.Resolvers(resolvers...))...)
+	}
+	if ctx.Command() != "migrate-config" {
+		warnDeprecated(ctx)
+	}
+	if _, err := path.Match(cli.TagMatch, ""); err != nil {
+		fatalf(ErrorUsage, "Invalid --tag-match %q: %v", cli.TagMatch, err)
+	}
+
+	if cli.AutoOutput {
+		applyAutoOutput(&cli)
+	}
+
+	startedAt = time.Now()
+	var verifyReadOnly func()
+	if cli.ReadOnly {
+		verifyReadOnly = guardReadOnly(&cli, ctx.Command())
+	}
+	if cli.Offline {
+		guardOffline(&cli, ctx.Command())
+	}
+
+	switch ctx.Command() {
+	case "restore", "restore <paths>":
+		runRestore(&cli)
+	case "stamp":
+		runStamp(&cli)
+	case "print", "print <format>":
+		runPrint(&cli)
+	case "validate":
+		runValidate(&cli)
+	case "tag-release <tag>":
+		runTagRelease(&cli)
+	case "bump <part>":
+		runBump(&cli)
+	case "changed":
+		runChanged(&cli)
+	case "notes", "notes <revision>":
+		runNotes(&cli)
+	case "stats":
+		runStats(&cli)
+	case "history":
+		runHistory(&cli)
+	case "check-registry":
+		runCheckRegistry(&cli)
+	case "migrate-config":
+		runMigrateConfig(&cli)
+	case "nightly":
+		runNightly(&cli)
+	case "action":
+		runAction(&cli)
+	case "breaking":
+		runBreaking(&cli)
+	case "branches":
+		runBranches(&cli)
+	case "resolve <version>":
+		runResolve(&cli)
+	case "init":
+		runInit(&cli, ctx)
+	case "pin", "pin <path>":
+		runPin(&cli, ctx)
+	default:
+		runGenerate(&cli)
+	}
+	if verifyReadOnly != nil {
+		verifyReadOnly()
+	}
+}
 
+// runGenerate generates the version and prints it or writes the selected output file
+func runGenerate(cli *CLI) {
+	if cli.FromPin != "" && (cli.Generate.Modules || cli.Generate.Components) {
+		fatalf(ErrorUsage, "--from-pin records a single version and cannot be combined with --modules or --components")
+	}
+	if cli.Generate.Recursive && cli.Generate.ReportFile == "" {
+		runRecursive(cli)
+		return
+	}
+	if cli.Generate.Modules {
+		runModules(cli)
+		return
+	}
+	if cli.Generate.Components {
+		runComponents(cli)
+		return
+	}
+
+	gitHandler, versionInfo := generateVersion(cli)
+	if cli.Generate.AllSchemes {
+		printAllSchemes(cli, versionInfo)
+		return
+	}
+	emitVersion(cli, gitHandler, versionInfo)
+}
+
+// emitVersion prints the version or writes the selected output files, and records the git note
+func emitVersion(cli *CLI, gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) {
+	outputs := selectOutputs(cli)
+	addVersionDetails(cli, gitHandler, versionInfo)
+
+	// Print only the version string (unless file type format is used)
+	if cli.Generate.ReportFile != "" {
+		// The --recursive run that started this one prints it in its summary
+		writeReport(cli.Generate.ReportFile, versionInfo)
+	} else if cli.TeamCity {
+		printTeamCity(versionInfo)
+	} else if len(outputs) == 0 && cli.OutputFormat == "json" {
+		printVersionJSON(versionInfo)
+	} else if len(outputs) == 0 {
+		fmt.Println(versionInfo.Version)
+	}
+
+	switch {
+	case cli.IntoArchive != "":
+		writeIntoArchive(cli, gitHandler, versionInfo, outputs)
+	case cli.Diff && len(outputs) > 0:
+		for _, output := range outputs {
+			err := printDiff(output.fileType, output.path, versionInfo, writeOptionsFor(cli, versionInfo))
+			if err != nil {
+				fatalf(ErrorOutput, "Failed to diff version file %s: %v", output.path, err)
+			}
+		}
+		return
+	default:
+		writer := newOutputWriter(cli)
+		for _, output := range outputs {
+			writer.write(gitHandler, versionInfo, output)
+		}
+		writer.commit()
+	}
+
+	if cli.Note {
+		recordNote(cli, gitHandler, versionInfo)
+	}
+}
+
+// addVersionDetails adds the generator build and the details selected by --go-format=map,
+// --cyclonedx, --url-template, --ahead-behind, --shortlog, --issue-keys and --contributors
+func addVersionDetails(cli *CLI, gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) {
+	versionInfo.Generator = &gittype.GeneratorInfo{Version: Version, Commit: GitCommit, Options: optionsFingerprint(cli)}
+	addCommitDate(cli, gitHandler, versionInfo)
+	if cli.CycloneDX || cli.Print.Format == "cyclonedx" {
+		addRepository(gitHandler, versionInfo)
+	}
+	if cli.URLTemplate != "" {
+		addSourceURL(cli, gitHandler, versionInfo)
+	}
+	if cli.AheadBehind {
+		addAheadBehind(gitHandler, versionInfo)
+	}
+	if cli.Shortlog || cli.IssueKeys || cli.Contributors {
+		commits := commitsSinceLastTag(gitHandler, versionInfo)
+		if cli.Shortlog {
+			addShortlog(cli, commits, versionInfo)
+		}
+		if cli.IssueKeys {
+			versionInfo.Issues = issueKeys(issuePatternFor(cli), commits)
+		}
+		if cli.Contributors {
+			versionInfo.Contributors = contributors(commits)
+		}
+	}
+}
+
+// addCommitDate adds the date of HEAD for --go-format=map
+func addCommitDate(cli *CLI, gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) {
+	if cli.GoFormat != filetype.GoFormatMap || versionInfo.Commit == "" {
+		return
+	}
+	date, err := gitHandler.GetCommitDate()
+	if err != nil {
+		fatalf(ErrorGit, "Failed to read the commit date: %v", err)
+	}
+	versionInfo.CommitDate = date
+}
+
+// generateVersion opens the repository with the selected backend and computes the version
+func generateVersion(cli *CLI) (gittype.GitHandler, *gittype.VersionInfo) {
 	// Get git handler based on inBuiltGit flag
-	gitHandler, err := gittype.GetGitHandler(cli.InBuiltGit, ".")
+	gitHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", gitOptionsFor(cli))
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+	}
+	if cli.FromPin != "" && cli.AtTag != "" {
+		fatalf(ErrorUsage, "--at-tag cannot be combined with --from-pin, which regenerates the pinned commit")
+	}
+	if cli.FromPin != "" {
+		return gitHandler, pinnedVersionInfo(cli, gitHandler)
+	}
+	if cli.AtTag != "" {
+		if !namingFor(cli).HasTagPrefix(cli.AtTag) {
+			fatalf(ErrorUsage, "--at-tag %s does not start with --tag-prefix %s", cli.AtTag, cli.TagPrefix)
+		}
+		if _, found, err := gitHandler.GetTagDate(cli.AtTag); err != nil || !found {
+			fatalf(ErrorUsage, "No tag %s to generate the version at", cli.AtTag)
+		}
+	}
+
+	versionInfo, err := versionInfoFor(cli, gitHandler)
+	if err != nil {
+		fatalf(ErrorGit, "Failed to generate version info: %v", err)
+	}
+
+	return gitHandler, versionInfo
+}
+
+// versionInfoFor computes the version, falling back to the commit alone when
+// --timeout runs out and --on-timeout=hash
+func versionInfoFor(cli *CLI, gitHandler gittype.GitHandler) (*gittype.VersionInfo, error) {
+	versionInfo, err := schemeVersionInfo(cli, gitHandler)
+	if errors.Is(err, gittype.ErrTimeout) && cli.OnTimeout == "hash" {
+		return timeoutVersionInfo(cli)
+	}
+	return versionInfo, err
+}
+
+// timeoutVersionInfo reports the commit as g<hash>, like --always, with a
+// warning. A fresh handler without the deadline reads it, since that only
+// needs HEAD.
+func timeoutVersionInfo(cli *CLI) (*gittype.VersionInfo, error) {
+	options := gitOptionsFor(cli)
+	options.Deadline = time.Time{}
+	gitHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", options)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := gitHandler.GetFullHash()
 	if err != nil {
-		log.Fatalf("Failed to initialize git handler: %v", err)
+		return nil, err
 	}
+	shortHash, err := gitHandler.GetShortHash()
+	if err != nil {
+		return nil, err
+	}
+
+	warning := gittype.Warning{
+		Code:    gittype.WarningTimeout,
+		Message: fmt.Sprintf("version generation exceeded --timeout %s; reporting the commit only", cli.Timeout),
+	}
+	printWarning(warning)
+	return &gittype.VersionInfo{
+		Version:   "g" + shortHash,
+		ShortHash: shortHash,
+		Commit:    commit,
+		Warnings:  []gittype.Warning{warning},
+	}, nil
+}
 
-	// Determine versioning options
+// versioningOptionsFor builds the versioning options from the CLI flags
+func versioningOptionsFor(cli *CLI) (versionSchemes.VersioningOptions, error) {
+	scheme, err := schemeFor(cli)
+	if err != nil {
+		return versionSchemes.VersioningOptions{}, err
+	}
 	options := versionSchemes.VersioningOptions{
-		Semver: cli.Semver,
-		CalVer: cli.CalVer,
-		Simple: cli.Simple,
+		Scheme: scheme,
 		Hash:   cli.Hash,
+
+		CalVerDirty:  cli.CalVerDirty,
+		CalVerFormat: cli.CalVerFormat,
+		CalVerReset:  cli.CalVerReset,
+		Template:     cli.Format,
+		Variant:      cli.Variant,
+
+		CountSeparator: cli.CountSeparator,
+		HashSeparator:  cli.HashSeparator,
+
+		Naming: namingFor(cli),
+	}
+	if err := versionSchemes.ValidateCalVerFormat(options.CalVerFormat); err != nil {
+		return options, err
+	}
+	if cli.Format != "" {
+		if scheme != versionSchemes.SchemeDefault {
+			return options, fmt.Errorf("--format conflicts with --scheme %s", scheme)
+		}
+		if err := versionSchemes.ValidateTemplate(cli.Format); err != nil {
+			return options, err
+		}
+		options.Scheme = versionSchemes.SchemeTemplate
+	}
+	for _, separator := range []string{options.CountSeparator, options.HashSeparator} {
+		if err := versionSchemes.ValidateSeparator(separator); err != nil {
+			return options, err
+		}
+	}
+	if versionSchemes.SanitizeIdentifier(options.Variant) != options.Variant {
+		return options, fmt.Errorf("invalid variant %q: use letters, digits and hyphens", options.Variant)
 	}
+	return options, nil
+}
 
-	// Generate version information based on options
-	var versionInfo *gittype.VersionInfo
-	if options.Semver || options.CalVer || options.Simple || options.Hash {
-		versionInfo, err = gitHandler.GenerateVersionInfoWithOptions(options)
-	} else {
-		// Fallback to original method for backward compatibility
-		versionInfo, err = gitHandler.GenerateVersionInfo(false)
+// schemeVersionInfo computes the version with the scheme selected on the command line
+func schemeVersionInfo(cli *CLI, gitHandler gittype.GitHandler) (*gittype.VersionInfo, error) {
+	options, err := versioningOptionsFor(cli)
+	if err != nil {
+		return nil, withCode(ErrorUsage, err)
+	}
+	scheme := options.SelectedScheme()
+	if cli.AtTag != "" {
+		// Dated versions of a rebuilt release keep the date of its tag
+		date, _, err := gitHandler.GetTagDate(cli.AtTag)
+		if err != nil {
+			return nil, err
+		}
+		options.Clock = versionSchemes.FixedClock(date.UTC())
 	}
+
+	metadata, err := buildMetadataFor(cli)
 	if err != nil {
-		log.Fatalf("Failed to generate version info: %v", err)
+		return nil, withCode(ErrorUsage, err)
 	}
 
-	// Determine output file and file type
-	var filename string
-	var fileTypeHandler filetype.FileType
+	generateOptions := versiongen.Options{
+		Versioning:    options,
+		Channel:       cli.Channel,
+		CandidateBump: cli.CandidateBump,
+		Metadata:      metadata,
+		MaxLength:     cli.MaxLength,
+	}
+	if cli.Dirty && !cli.DescribeCompat && cli.AtTag == "" {
+		generateOptions.DirtySuffix = cli.DirtySuffix
+	}
+
+	// The formats only the command has; the rest are versiongen's
+	customSeparators := options.CountSeparator != "" || options.HashSeparator != ""
+	var versionInfo *gittype.VersionInfo
+	switch {
+	case cli.DockerTag && (cli.DescribeCompat || scheme != versionSchemes.SchemeDefault || options.Hash || options.Variant != "" || len(metadata) > 0 || customSeparators):
+		return nil, withCode(ErrorUsage, fmt.Errorf("--docker-tag cannot be combined with another version format, --variant, --meta or separators"))
+	case cli.DockerImage != "" && !cli.DockerTag:
+		return nil, withCode(ErrorUsage, fmt.Errorf("--docker-image requires --docker-tag"))
+	case cli.Conventional && (cli.DescribeCompat || cli.Channel != "none" || scheme == versionSchemes.SchemeCalVer):
+		return nil, withCode(ErrorUsage, fmt.Errorf("--conventional cannot be combined with --describe-compat, --channel or the calver scheme"))
+	case cli.Channel != "none" && (cli.DockerTag || cli.DescribeCompat):
+		return nil, withCode(ErrorUsage, fmt.Errorf("--channel cannot be combined with --docker-tag or --describe-compat"))
+	case cli.Conventional:
+		versionInfo, err = conventionalVersionInfo(cli, options, gitHandler)
+	case cli.DescribeCompat:
+		versionInfo, err = describeVersionInfo(cli, gitHandler)
+	case cli.DockerTag:
+		versionInfo, err = gitHandler.GenerateVersionInfo(true)
+	default:
+		versionInfo, err = versiongen.SchemeVersionInfo(gitHandler, generateOptions)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cli.DockerImage != "" {
+		if err := checkDockerTagCollision(cli, versionInfo); err != nil {
+			return nil, err
+		}
+	}
+	var unconventional *gittype.Warning
+	if cli.RequireConventional {
+		if unconventional, err = requireConventional(cli, gitHandler, versionInfo); err != nil {
+			return nil, err
+		}
+	}
 
-	// Helper function to determine final path
-	getFilePath := func(providedPath, defaultFilename string) string {
-		if providedPath == "" {
-			return defaultFilename
+	if err := versiongen.Complete(gitHandler, versionInfo, generateOptions); err != nil {
+		return nil, err
+	}
+	if cli.MaxAge != "" {
+		stale, err := staleTagWarning(cli, gitHandler, versionInfo)
+		if err != nil {
+			return nil, err
 		}
-		// Check if provided path is a directory (ends with /)
-		if strings.HasSuffix(providedPath, "/") {
-			return providedPath + defaultFilename
+		if stale != nil {
+			versionInfo.Warnings = append(versionInfo.Warnings, *stale)
 		}
-		return providedPath
 	}
+	if unconventional != nil {
+		versionInfo.Warnings = append(versionInfo.Warnings, *unconventional)
+	}
+	for _, warning := range versionInfo.Warnings {
+		printWarning(warning)
+	}
+	return versionInfo, nil
+}
 
-	// Determine file type based on flags
+// schemeFor returns the scheme selected by --scheme or, for compatibility, by
+// the deprecated --cal-ver, --semver and --simple flags, in that precedence
+func schemeFor(cli *CLI) (versionSchemes.Scheme, error) {
+	var legacy versionSchemes.Scheme
+	legacyFlag := ""
+	switch {
+	case cli.CalVer:
+		legacy, legacyFlag = versionSchemes.SchemeCalVer, "--cal-ver"
+	case cli.Semver:
+		legacy, legacyFlag = versionSchemes.SchemeSemVer, "--semver"
+	case cli.Simple:
+		legacy, legacyFlag = versionSchemes.SchemeSimple, "--simple"
+	}
 	switch {
-	case cli.Go:
-		fileTypeHandler = &filetype.GoType{}
-		filename = getFilePath(cli.GoPath, "version.go")
-	case cli.Cpp:
-		fileTypeHandler = &filetype.CPPType{}
-		filename = getFilePath(cli.CppPath, "version.h")
-	case cli.Yaml:
-		fileTypeHandler = &filetype.YAMLFile{}
-		filename = getFilePath(cli.YamlPath, "version.yaml")
-	case cli.File:
-		fileTypeHandler = &filetype.BasicFile{}
-		filename = getFilePath(cli.FilePath, ".VERSION")
+	case legacy == "":
+		return versionSchemes.ParseScheme(cli.Scheme)
+	case cli.Scheme == string(versionSchemes.SchemeDefault) || cli.Scheme == string(legacy):
+		return legacy, nil
+	default:
+		return "", fmt.Errorf("--scheme %s conflicts with %s", cli.Scheme, legacyFlag)
 	}
+}
 
-	// Print only the version string (unless file type format is used)
-	if fileTypeHandler == nil {
-		fmt.Println(versionInfo.Version)
+// staleTagWarning checks the age of the last tag against --max-age, failing
+// instead of warning with --on-max-age=error. Untagged repositories are not checked.
+func staleTagWarning(cli *CLI, gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) (*gittype.Warning, error) {
+	maxAge, err := parseAge(cli.MaxAge)
+	if err != nil {
+		return nil, withCode(ErrorUsage, err)
+	}
+	tagDate, found, err := gitHandler.GetTagDate(namingFor(cli).Tag(versionInfo.LastTag))
+	if err != nil || !found {
+		return nil, err
 	}
+	age := time.Since(tagDate)
+	if age <= maxAge {
+		return nil, nil
+	}
+
+	message := fmt.Sprintf("last tag %s is %d days old, older than --max-age %s", versionInfo.LastTag, int(age.Hours()/24), cli.MaxAge)
+	if cli.OnMaxAge == "error" {
+		return nil, withCode(ErrorStaleTag, errors.New(message))
+	}
+	return &gittype.Warning{Code: gittype.WarningStaleTag, Message: message}, nil
+}
+
+// parseAge parses a duration that may also use d (days) and w (weeks) units
+func parseAge(value string) (time.Duration, error) {
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.ParseFloat(number, 64)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			return time.Duration(n * float64(unit)), nil
+		}
+	}
+	age, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q: use e.g. 30d, 2w or 72h", value)
+	}
+	return age, nil
+}
+
+// describeVersionInfo replaces the generated version with git describe output.
+// Nothing is added to it, so scripts can diff it against git describe directly.
+func describeVersionInfo(cli *CLI, gitHandler gittype.GitHandler) (*gittype.VersionInfo, error) {
+	if cli.Variant != "" || len(cli.Meta) > 0 {
+		return nil, withCode(ErrorUsage, fmt.Errorf("--describe-compat cannot be combined with --variant or --meta"))
+	}
+	versionInfo, err := gitHandler.GenerateVersionInfo(false)
+	if err != nil {
+		return nil, err
+	}
+	if versionInfo.Version, err = gitHandler.Describe(); err != nil {
+		return nil, err
+	}
+	return versionInfo, nil
+}
 
-	// Write to file if requested or file type format is specified
-	if filename != "" && fileTypeHandler != nil {
-		err := fileTypeHandler.WriteVersion(filename, versionInfo.Version)
+// buildMetadataFor parses the --meta flags
+func buildMetadataFor(cli *CLI) ([]versionSchemes.BuildMetadata, error) {
+	var metadata []versionSchemes.BuildMetadata
+	for _, entry := range cli.Meta {
+		meta, err := versionSchemes.ParseBuildMetadata(entry)
 		if err != nil {
-			log.Fatalf("Failed to write version to file %s: %v", filename, err)
+			return nil, err
 		}
-	} else if filename != "" {
-		// Fallback to basic file writing
-		err := writeVersionToFile(filename, versionInfo.Version)
+		metadata = append(metadata, meta)
+	}
+	return metadata, nil
+}
+
+// namingFor builds the tag prefix and branch name sanitization that the git
+// handlers and the schemes share
+func namingFor(cli *CLI) versionSchemes.Naming {
+	return versionSchemes.Naming{TagPrefix: cli.TagPrefix, Sanitize: versionSchemes.SanitizeProfile(cli.Sanitize)}
+}
+
+// gitOptionsFor builds the git handler options from the CLI flags
+func gitOptionsFor(cli *CLI) gittype.GitOptions {
+	if cli.InitialVersion != "" && cli.OnNoTags == gittype.NoTagsZero {
+		cli.OnNoTags = gittype.NoTagsInitial
+	}
+	var deadline time.Time
+	if cli.Timeout > 0 {
+		deadline = startedAt.Add(cli.Timeout)
+	}
+	options := gittype.GitOptions{
+		MaxTagDistance:        cli.MaxTagDistance,
+		FailOnTagDistance:     cli.OnTagDistance == "error",
+		NoTagsPolicy:          cli.OnNoTags,
+		InitialVersion:        cli.InitialVersion,
+		OrphanPolicy:          cli.OnOrphan,
+		Always:                cli.Always,
+		IncludeRemoteTags:     cli.RemoteTags,
+		MergedOnly:            cli.MergedOnly,
+		NoReplaceObjects:      cli.NoReplaceObjects,
+		NoMaintenanceBranches: cli.NoMaintenanceBranches,
+		Traversal:             cli.Traversal,
+		Naming:                namingFor(cli),
+		TagMatch:              cli.TagMatch,
+		ExcludeTags:           []string{nightlyTag},
+		Deadline:              deadline,
+		LockTimeout:           cli.LockTimeout,
+		Progress:              progressOutput(cli),
+		Auth: gittype.AuthOptions{
+			SSHKey:           cli.SSHKey,
+			SSHKeyPassphrase: os.Getenv("VG_SSH_KEY_PASSPHRASE"),
+			TokenEnv:         cli.TokenEnv,
+			Netrc:            cli.Netrc,
+		},
+	}
+	if cli.AtTag != "" {
+		gitHandler, err := gittype.GetGitHandler(cli.InBuiltGit, ".")
 		if err != nil {
-			log.Fatalf("Failed to write version to file %s: %v", filename, err)
+			fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+		}
+		if options.Branch, err = atTagBranch(gitHandler); err != nil {
+			fatalf(ErrorGit, "Failed to list branches: %v", err)
+		}
+		options.Revision = "refs/tags/" + cli.AtTag + "^{commit}"
+		options.Resolvers.Tag = atTagResolver{tag: cli.AtTag}
+	}
+	return options
+}
+
+// atTagBranch is the branch --at-tag versions a tag on, whatever is checked
+// out: main, or master when there is no main, or HEAD in a repository with neither
+func atTagBranch(gitHandler gittype.GitHandler) (string, error) {
+	branches, err := gitHandler.GetBranches(false)
+	if err != nil {
+		return "", err
+	}
+	for _, mainline := range []string{"main", "master"} {
+		for _, branch := range branches {
+			if branch.Name == mainline {
+				return mainline, nil
+			}
 		}
 	}
+	return "HEAD", nil
+}
+
+// atTagResolver reports the --at-tag tag as the last tag, which HEAD at a
+// commit with several tags would not necessarily pick
+type atTagResolver struct {
+	tag string
+}
+
+func (r atTagResolver) GetLastTag(string) (string, bool, error) {
+	return r.tag, true, nil
 }
 
-func writeVersionToFile(filename, version string) error {
-	return os.WriteFile(filename, []byte(version+"\n"), 0644)
+// progressOutput returns stderr when --progress is set and a person is watching:
+// stderr is a terminal, no CI provider is detected and output is not JSON
+func progressOutput(cli *CLI) io.Writer {
+	if !cli.Progress || jsonErrors || ciMetadataFromEnv().Provider != "" {
+		return nil
+	}
+	if info, err := os.Stderr.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return os.Stderr
+}
+
+// writeOutput validates, backs up and writes the output file, then applies the gitignore policy
+func writeOutput(cli *CLI, gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo, fileTypeHandler filetype.FileType, filename string) {
+	if !cli.Force {
+		if err := validateOutputPath(gitHandler, filename); err != nil {
+			fatalf(ErrorUsage, "Refusing to write version file: %v", err)
+		}
+	}
+	if cli.Backup {
+		if err := filetype.Backup(filename, cli.BackupSuffix); err != nil {
+			fatalf(ErrorOutput, "Failed to back up file %s: %v", filename, err)
+		}
+	}
+	err := filetype.WriteFile(fileTypeHandler, filename, versionInfo, writeOptionsFor(cli, versionInfo))
+	if err != nil {
+		fatalf(ErrorOutput, "Failed to write version to file %s: %v", filename, err)
+	}
+	if err := applyGitignorePolicy(gitHandler, filename, cli.Gitignore); err != nil {
+		fatalf(ErrorOutput, "Gitignore check failed for %s: %v", filename, err)
+	}
 }
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	filetype "version-generator/fileType"
+
+	"github.com/alecthomas/kong"
+)
+
+// runGit runs git in dir with a fixed identity and dates, failing the test on error
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	command := exec.Command("git", args...)
+	command.Dir = dir
+	command.Env = append(os.Environ(),
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+		"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com", "GIT_AUTHOR_DATE=2024-05-01T12:00:00Z",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com", "GIT_COMMITTER_DATE=2024-05-01T12:00:00Z",
+	)
+	if output, err := command.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+}
+
+// chdir changes the working directory for the rest of the test
+func chdir(t *testing.T, dir string) {
+	t.Helper()
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(previous) })
+}
+
+// parseArgs parses args as the command line, with the defaults of every flag
+func parseArgs(args ...string) (*CLI, error) {
+	var cli CLI
+	parser, err := kong.New(&cli, kong.Name("version-generator"))
+	if err != nil {
+		return nil, err
+	}
+	ctx, err := parser.Parse(args)
+	if err != nil {
+		return nil, err
+	}
+	return &cli, useCommandFlags(&cli, ctx)
+}
+
+// parseCLI parses args as the command line, failing the test on error
+func parseCLI(t *testing.T, args ...string) *CLI {
+	t.Helper()
+	cli, err := parseArgs(args...)
+	if err != nil {
+		t.Fatalf("parse %v: %v", args, err)
+	}
+	return cli
+}
+
+// TestAtTagIgnoresCheckout rebuilds a release from a feature branch and from
+// main, which must give the same version files
+func TestAtTagIgnoresCheckout(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "feat: release")
+	runGit(t, dir, "tag", "v1.2.0")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "fix: after the release")
+	runGit(t, dir, "checkout", "-q", "-b", "feature/x")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "feat: on the branch")
+	chdir(t, dir)
+
+	render := func(backend string) []byte {
+		args := []string{"--at-tag", "v1.2.0", "--scheme", "semver", "--hash", "--go-format", "map"}
+		if backend == "go-git" {
+			args = append(args, "--in-built-git")
+		}
+		cli := parseCLI(t, args...)
+		cli.Go = true
+		gitHandler, versionInfo := generateVersion(cli)
+		addVersionDetails(cli, gitHandler, versionInfo)
+
+		var out bytes.Buffer
+		for _, fileType := range []filetype.FileType{&filetype.JSONType{}, &filetype.GoType{Format: filetype.GoFormatMap}} {
+			data, err := fileType.Render(filepath.Join(dir, "out"), versionInfo)
+			if err != nil {
+				t.Fatalf("render: %v", err)
+			}
+			out.Write(data)
+		}
+		return out.Bytes()
+	}
+
+	for _, backend := range []string{"git", "go-git"} {
+		t.Run(backend, func(t *testing.T) {
+			runGit(t, dir, "checkout", "-q", "feature/x")
+			onFeature := render(backend)
+			runGit(t, dir, "checkout", "-q", "main")
+			onMain := render(backend)
+			if !bytes.Equal(onFeature, onMain) {
+				t.Errorf("--at-tag output depends on the checkout:\nfeature/x:\n%s\nmain:\n%s", onFeature, onMain)
+			}
+			if !bytes.Contains(onMain, []byte(`"version": "v1.2.0+`)) || !bytes.Contains(onMain, []byte(`"branch": "main"`)) {
+				t.Errorf("unexpected --at-tag output:\n%s", onMain)
+			}
+		})
+	}
+}
+
+// TestAtTagChannelDate dates a nightly rebuilt at a tag by the tag, not today
+func TestAtTagChannelDate(t *testing.T) {
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q", "-b", "main")
+	runGit(t, dir, "commit", "-q", "--allow-empty", "-m", "feat: release")
+	runGit(t, dir, "tag", "-a", "v0.9.0", "-m", "v0.9.0")
+	chdir(t, dir)
+
+	for _, backend := range []string{"git", "go-git"} {
+		t.Run(backend, func(t *testing.T) {
+			args := []string{"--at-tag", "v0.9.0", "--channel", "nightly"}
+			if backend == "go-git" {
+				args = append(args, "--in-built-git")
+			}
+			_, versionInfo := generateVersion(parseCLI(t, args...))
+			if !strings.HasPrefix(versionInfo.Version, "2024.05.01+") {
+				t.Errorf("nightly at v0.9.0 is %q, want the tag date 2024.05.01", versionInfo.Version)
+			}
+		})
+	}
+}
+
+// TestOutputRollback puts back the outputs moved before a failed rename
+func TestOutputRollback(t *testing.T) {
+	dir := t.TempDir()
+	existing, created, failed := filepath.Join(dir, "VERSION"), filepath.Join(dir, "version.json"), filepath.Join(dir, "version.yaml")
+	for path, data := range map[string]string{existing: "v1.0.0\n", failed: "version: v1.0.0\n"} {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := newOutputWriter(parseCLI(t, "--atomic-outputs"))
+	for _, path := range []string{existing, created, failed} {
+		temp := filepath.Join(dir, "."+filepath.Base(path)+".tmp")
+		if err := os.WriteFile(temp, []byte("v2.0.0\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		w.staged = append(w.staged, stagedOutput{path: path, tempPath: temp})
+	}
+	// Move the first two into place as commit does, then fail on the third
+	for i, staged := range w.staged[:2] {
+		previousPath, err := keepPrevious(staged.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.staged[i].previousPath = previousPath
+		if err := os.Rename(staged.tempPath, staged.path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	w.rollback(2)
+
+	for path, want := range map[string]string{existing: "v1.0.0\n", failed: "version: v1.0.0\n"} {
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Errorf("%s is %q (%v) after rollback, want %q", filepath.Base(path), data, err, want)
+		}
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if strings.Join(names, " ") != "VERSION version.yaml" {
+		t.Errorf("files after rollback: %v, want VERSION and version.yaml only", names)
+	}
+}
+
+// TestCommandFlags parses the version and output flags only on the commands
+// that use them, and without a command name for generate
+func TestCommandFlags(t *testing.T) {
+	for _, args := range [][]string{
+		{"-g", "--scheme", "semver"},
+		{"generate", "-g", "--scheme", "semver"},
+		{"--scheme", "semver", "generate", "-g"},
+		{"stamp", "-g", "--scheme", "semver"},
+		{"--tag-prefix", "release-", "print", "go", "-g", "--scheme", "semver"},
+	} {
+		cli := parseCLI(t, args...)
+		if !cli.Go || cli.Scheme != "semver" {
+			t.Errorf("%v: --go %v, --scheme %q, want the flags of the command", args, cli.Go, cli.Scheme)
+		}
+	}
+
+	if cli := parseCLI(t, "tag-release", "v1.0.0", "--issue-pattern", "GH-[0-9]+"); cli.IssuePattern != "GH-[0-9]+" {
+		t.Errorf("tag-release: --issue-pattern %q, want GH-[0-9]+", cli.IssuePattern)
+	}
+	if cli := parseCLI(t, "--tag-prefix", "release-", "stats"); cli.TagPrefix != "release-" || cli.Scheme != "" {
+		t.Errorf("stats: --tag-prefix %q, --scheme %q, want only the global flags", cli.TagPrefix, cli.Scheme)
+	}
+
+	for _, args := range [][]string{
+		{"history", "--go-expvar"},
+		{"stats", "--scheme", "semver"},
+		{"restore", "--hash"},
+		{"--go", "history"},
+		{"-g", "notes"},
+	} {
+		if _, err := parseArgs(args...); err == nil {
+			t.Errorf("%v parsed, want an error", args)
+		}
+	}
+}
+
+func TestPrintFormatFlags(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"print"}, "version"},
+		{[]string{"print", "--yaml"}, "yaml"},
+		{[]string{"print", "-g", "--go-path", "cmd/version.go"}, "go"},
+		{[]string{"print", "json", "--json-file"}, "json"},
+	} {
+		if cli := parseCLI(t, test.args...); cli.Print.Format != test.want {
+			t.Errorf("%v: format %q, want %q", test.args, cli.Print.Format, test.want)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"print", "--go", "--yaml"},
+		{"print", "yaml", "--go"},
+		{"print", "version", "--tfvars"},
+		{"print", "--auto-output"},
+	} {
+		if _, err := parseArgs(args...); err == nil {
+			t.Errorf("%v parsed, want an error", args)
+		}
+	}
+}
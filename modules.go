@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	filetype "version-generator/fileType"
+	gittype "version-generator/gitType"
+
+	"golang.org/x/mod/modfile"
+)
+
+// workspaceModule is one module listed in go.work
+type workspaceModule struct {
+	Dir  string // Repository-relative directory, "." for the root
+	Path string // Module path from its go.mod
+}
+
+// moduleVersion is one entry of the --modules summary
+type moduleVersion struct {
+	Module       string            `json:"module"`
+	Dir          string            `json:"dir"`
+	Tag          string            `json:"tag"`
+	Version      string            `json:"version"`
+	CommitsSince int               `json:"commits_since"`
+	File         string            `json:"file"`
+	Warnings     []gittype.Warning `json:"warnings,omitempty"`
+}
+
+// runModules versions every module of the go.work workspace separately and
+// prints a JSON summary. Each module only counts commits touching its own
+// directory (excluding nested modules) and only considers tags named like Go
+// module tags, <dir>/vX.Y.Z, with plain vX.Y.Z for a module at the root.
+func runModules(cli *CLI) {
+	rootHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", gitOptionsFor(cli))
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+	}
+	repoRoot, err := rootHandler.GetRepoRoot()
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to find repository root: %v", err)
+	}
+
+	modules, err := workspaceModules(repoRoot)
+	if err != nil {
+		fatalf(ErrorConfig, "Failed to read workspace: %v", err)
+	}
+
+	goFile := cli.GoPath
+	if goFile == "" {
+		goFile = "version.go"
+	}
+
+	writer := newOutputWriter(cli)
+	summary := newSummaryPrinter(cli)
+	for _, module := range modules {
+		gitHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, repoRoot, moduleGitOptions(cli, module, modules))
+		if err != nil {
+			fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+		}
+		versionInfo, err := versionInfoFor(cli, gitHandler)
+		if err != nil {
+			fatalf(ErrorGit, "Failed to generate version info for %s: %v", module.Path, err)
+		}
+
+		addCommitDate(cli, gitHandler, versionInfo)
+
+		moduleDir := filepath.Join(repoRoot, filepath.FromSlash(module.Dir))
+		filename := filepath.Join(moduleDir, goFile)
+		fileTypeHandler := &filetype.GoType{Package: packageName(moduleDir), Format: cli.GoFormat}
+		if cli.Diff {
+			if err := printDiff(fileTypeHandler, filename, versionInfo, writeOptionsFor(cli, versionInfo)); err != nil {
+				fatalf(ErrorOutput, "Failed to diff version file %s: %v", filename, err)
+			}
+		} else {
+			writer.write(gitHandler, versionInfo, outputFile{fileType: fileTypeHandler, path: filename})
+		}
+
+		if cli.Diff {
+			continue
+		}
+		summary.add(moduleVersion{
+			Module:       module.Path,
+			Dir:          module.Dir,
+			Tag:          moduleTagPrefix(module.Dir) + versionInfo.LastTag,
+			Version:      versionInfo.Version,
+			CommitsSince: versionInfo.CommitsSince,
+			File:         path.Join(module.Dir, filepath.ToSlash(goFile)),
+			Warnings:     versionInfo.Warnings,
+		})
+	}
+
+	writer.commit()
+
+	if !cli.Diff {
+		summary.finish()
+	}
+}
+
+// workspaceModules lists the modules used by go.work at the repository root
+func workspaceModules(repoRoot string) ([]workspaceModule, error) {
+	workPath := filepath.Join(repoRoot, "go.work")
+	content, err := os.ReadFile(workPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read go.work: %w", err)
+	}
+	work, err := modfile.ParseWork(workPath, content, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse go.work: %w", err)
+	}
+
+	var modules []workspaceModule
+	for _, use := range work.Use {
+		dir := path.Clean(filepath.ToSlash(use.Path))
+		if path.IsAbs(dir) || dir == ".." || strings.HasPrefix(dir, "../") {
+			return nil, fmt.Errorf("module directory %s is outside the repository", use.Path)
+		}
+		goMod, err := os.ReadFile(filepath.Join(repoRoot, filepath.FromSlash(dir), "go.mod"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read go.mod of %s: %w", dir, err)
+		}
+		modules = append(modules, workspaceModule{Dir: dir, Path: modfile.ModulePath(goMod)})
+	}
+	return modules, nil
+}
+
+// moduleGitOptions restricts tags and commit counts to one workspace module
+func moduleGitOptions(cli *CLI, module workspaceModule, modules []workspaceModule) gittype.GitOptions {
+	options := gitOptionsFor(cli)
+	options.TagPrefix = moduleTagPrefix(module.Dir)
+	options.TagMatch = options.Tag("v[0-9]*")
+	options.Paths = []string{module.Dir}
+	for _, other := range modules {
+		if other.Dir != module.Dir && (module.Dir == "." || strings.HasPrefix(other.Dir, module.Dir+"/")) {
+			options.ExcludePaths = append(options.ExcludePaths, other.Dir)
+		}
+	}
+	return options
+}
+
+// moduleTagPrefix returns the Go module tag prefix for a module directory
+func moduleTagPrefix(dir string) string {
+	if dir == "." {
+		return ""
+	}
+	return dir + "/"
+}
+
+// packageName reads the package clause of the first non-test Go file in dir, defaulting to main
+func packageName(dir string) string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, match := range matches {
+		if strings.HasSuffix(match, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), match, nil, parser.PackageClauseOnly)
+		if err == nil {
+			return file.Name.Name
+		}
+	}
+	return "main"
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"version-generator/versionSchemes"
+)
+
+// nightlyTag is the tag moved to each nightly build by nightly --tag. It is
+// never used as the last tag, so versions stay anchored to releases.
+const nightlyTag = "nightly"
+
+// NightlyCmd generates a nightly version and optionally moves the nightly tag
+type NightlyCmd struct {
+	VersionFlags `kong:"embed"`
+	OutputFlags  `kong:"embed"`
+
+	Bump string `kong:"enum='patch,minor,major',default='patch',help='Release the nightly leads up to: patch, minor or major after the last tag'"`
+	Tag  bool   `kong:"help='Create or move the nightly tag to HEAD'"`
+}
+
+// runNightly prints or writes the nightly version of HEAD, e.g.
+// v1.4.0-nightly.20240815+abc1234, and moves the nightly tag when asked
+func runNightly(cli *CLI) {
+	gitHandler, versionInfo := generateVersion(cli)
+
+	generator := versionSchemes.NewVersionGenerator()
+	version, err := generator.GenerateNightly(versionInfo.LastTag, cli.Nightly.Bump, versionInfo.ShortHash, time.Now())
+	if err != nil {
+		fatalf(ErrorUsage, "Failed to generate nightly version: %v", err)
+	}
+	metadata := append([]versionSchemes.BuildMetadata{{Key: versionInfo.Variant}}, versionInfo.Metadata...)
+	versionInfo.Version = versionSchemes.AppendBuildMetadata(version, metadata)
+	versionInfo.Channel = versionSchemes.ChannelNightly
+	emitVersion(cli, gitHandler, versionInfo)
+
+	if cli.Nightly.Tag {
+		if err := gitHandler.MoveTag(nightlyTag, fmt.Sprintf("Nightly build %s\n", versionInfo.Version)); err != nil {
+			fatalf(ErrorGit, "Failed to tag nightly build: %v", err)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	gittype "version-generator/gitType"
+)
+
+// NotesCmd prints the versions recorded for a commit with --note
+type NotesCmd struct {
+	Revision string `kong:"arg,optional,default='HEAD',help='Commit to read the recorded versions of (default: HEAD)'"`
+}
+
+// versionNote is one build recorded in a git note, stored as a JSON line
+type versionNote struct {
+	Version      string            `json:"version"`
+	Tag          string            `json:"tag"`
+	CommitsSince int               `json:"commits_since"`
+	Branch       string            `json:"branch"`
+	Variant      string            `json:"variant,omitempty"`
+	Channel      string            `json:"channel,omitempty"`
+	GeneratedAt  string            `json:"generated_at"`
+	Generator    string            `json:"generator"`
+	CI           string            `json:"ci,omitempty"`
+	BuildID      string            `json:"build_id,omitempty"`
+	BuildURL     string            `json:"build_url,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	Warnings     []gittype.Warning `json:"warnings,omitempty"`
+}
+
+// recordNote appends the generated version and build metadata to the git note of HEAD
+func recordNote(cli *CLI, gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) {
+	ci := ciMetadataFromEnv()
+	var metadata map[string]string
+	for _, meta := range versionInfo.Metadata {
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[meta.Key] = meta.Value
+	}
+	entry, err := json.Marshal(versionNote{
+		Version:      versionInfo.Version,
+		Tag:          versionInfo.LastTag,
+		CommitsSince: versionInfo.CommitsSince,
+		Branch:       versionInfo.Branch,
+		Variant:      versionInfo.Variant,
+		Channel:      versionInfo.Channel,
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+		Generator:    "version-generator " + Version,
+		CI:           ci.Provider,
+		BuildID:      ci.BuildID,
+		BuildURL:     ci.BuildURL,
+		Metadata:     metadata,
+		Warnings:     versionInfo.Warnings,
+	})
+	if err != nil {
+		fatalf(ErrorInternal, "Failed to encode version note: %v", err)
+	}
+
+	if err := gitHandler.AppendNote(cli.NotesRef, string(entry)); err != nil {
+		fatalf(ErrorGit, "Failed to record version note: %v", err)
+	}
+}
+
+// runNotes prints the JSON lines recorded for a revision
+func runNotes(cli *CLI) {
+	gitHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", gitOptionsFor(cli))
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+	}
+
+	note, found, err := gitHandler.ReadNote(cli.NotesRef, cli.Notes.Revision)
+	if err != nil {
+		fatalf(ErrorGit, "Failed to read version notes: %v", err)
+	}
+	if !found {
+		fatalf(ErrorGit, "No versions recorded for %s in refs/notes/%s", cli.Notes.Revision, strings.TrimPrefix(cli.NotesRef, "refs/notes/"))
+	}
+
+	// Older entries may be separated by blank lines when notes were merged by git
+	for _, line := range strings.Split(note, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fmt.Println(line)
+		}
+	}
+}
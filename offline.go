@@ -0,0 +1,29 @@
+package main
+
+import "os"
+
+// networkOperation names the requested operation that needs the network, or
+// returns "" when the run works from the local repository alone
+func networkOperation(cli *CLI, command string) string {
+	switch {
+	case command == "check-registry":
+		return "check-registry"
+	case command == "action" && cli.Action.FetchHistory == "auto":
+		return "action --fetch-history=auto"
+	case cli.DockerImage != "":
+		return "--docker-image"
+	case cli.SignOutput == "cosign" && cli.SignOutputKey == "":
+		return "keyless --sign-output=cosign"
+	}
+	return ""
+}
+
+// guardOffline refuses operations that need the network and keeps system git
+// from reaching remotes, including the lazy fetches of partial clones
+func guardOffline(cli *CLI, command string) {
+	if operation := networkOperation(cli, command); operation != "" {
+		fatalf(ErrorOffline, "--offline does not allow %s, which needs the network", operation)
+	}
+	os.Setenv("GIT_ALLOW_PROTOCOL", "file")
+	os.Setenv("GIT_NO_LAZY_FETCH", "1")
+}
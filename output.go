@@ -0,0 +1,512 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	filetype "version-generator/fileType"
+	gittype "version-generator/gitType"
+)
+
+// printVersionJSON prints the version and how it was derived as a JSON object
+func printVersionJSON(versionInfo *gittype.VersionInfo) {
+	encoded, err := (&filetype.JSONType{}).Render("", versionInfo)
+	if err != nil {
+		fatalf(ErrorInternal, "Failed to encode version: %v", err)
+	}
+	fmt.Print(string(encoded))
+}
+
+// printTeamCity prints the TeamCity service messages for the version, which
+// TeamCity picks up from the build log
+func printTeamCity(versionInfo *gittype.VersionInfo) {
+	messages, err := (&filetype.TeamCityType{}).Render("", versionInfo)
+	if err != nil {
+		fatalf(ErrorInternal, "Failed to render TeamCity service messages: %v", err)
+	}
+	fmt.Print(string(messages))
+}
+
+// summaryPrinter prints the entries of a --modules or --components summary,
+// either collected into one JSON array or, with --summary-format=jsonl, each on
+// its own line as soon as it is added so callers can act on early entries
+type summaryPrinter struct {
+	stream  bool
+	entries []any
+}
+
+// newSummaryPrinter returns a printer for the summary format selected by the flags
+func newSummaryPrinter(cli *CLI) *summaryPrinter {
+	return &summaryPrinter{stream: cli.Generate.SummaryFormat == "jsonl", entries: []any{}}
+}
+
+// add prints a streamed entry or keeps it for the array
+func (p *summaryPrinter) add(entry any) {
+	if !p.stream {
+		p.entries = append(p.entries, entry)
+		return
+	}
+	encoded, err := json.Marshal(entry)
+	if err != nil {
+		fatalf(ErrorInternal, "Failed to encode summary entry: %v", err)
+	}
+	fmt.Println(string(encoded))
+}
+
+// finish prints the collected entries as a JSON array; streamed entries are already out
+func (p *summaryPrinter) finish() {
+	if p.stream {
+		return
+	}
+	encoded, err := json.MarshalIndent(p.entries, "", "  ")
+	if err != nil {
+		fatalf(ErrorInternal, "Failed to encode summary: %v", err)
+	}
+	fmt.Println(string(encoded))
+}
+
+// outputFile is an output file selected by the output flags
+type outputFile struct {
+	fileType filetype.FileType
+	path     string
+}
+
+// addAheadBehind counts the commits ahead of and behind main/master for the
+// JSON and YAML output. A repository without either branch reports no counts.
+func addAheadBehind(gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) {
+	mainline, ahead, behind, err := gitHandler.GetAheadBehind()
+	if errors.Is(err, gittype.ErrNoMainline) {
+		warning := gittype.Warning{Code: gittype.WarningNoMainline, Message: "no main or master branch to count commits ahead and behind against"}
+		versionInfo.Warnings = append(versionInfo.Warnings, warning)
+		printWarning(warning)
+		return
+	}
+	if err != nil {
+		fatalf(ErrorGit, "Failed to count commits ahead of and behind main: %v", err)
+	}
+	versionInfo.Mainline, versionInfo.AheadOfMain, versionInfo.BehindMain = mainline, ahead, behind
+}
+
+// commitsSinceLastTag lists the commits since the last tag, or all commits
+// when no tag is reachable
+func commitsSinceLastTag(gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) []gittype.CommitEntry {
+	tag, err := previousReleaseTag(gitHandler, versionInfo)
+	if err != nil {
+		fatalf(ErrorGit, "Failed to find the last tag: %v", err)
+	}
+	commits, err := gitHandler.GetCommitLog(tag)
+	if err != nil {
+		fatalf(ErrorGit, "Failed to read the commits since %s: %v", versionInfo.LastTag, err)
+	}
+	return commits
+}
+
+// addShortlog lists the commits since the last tag, at most --max-commits of
+// them, for the JSON and YAML output
+func addShortlog(cli *CLI, commits []gittype.CommitEntry, versionInfo *gittype.VersionInfo) {
+	if cli.MaxCommits > 0 && len(commits) > cli.MaxCommits {
+		commits = commits[:cli.MaxCommits]
+	}
+	for _, commit := range commits {
+		versionInfo.Shortlog = append(versionInfo.Shortlog, gittype.ShortlogEntry{
+			Hash:    commit.Hash[:min(len(commit.Hash), 7)],
+			Author:  commit.Author,
+			Subject: commit.Subject,
+		})
+	}
+}
+
+// outputFormat is an output file type, whether its flag selects it and where it is written
+type outputFormat struct {
+	name     string // Name of the format for print, e.g. go or tfvars
+	selected bool
+	outputFile
+}
+
+// outputFormats lists every output file type with the path its flags give it,
+// in the order the output flags are declared
+func outputFormats(cli *CLI) []outputFormat {
+	// Helper function to determine final path
+	getFilePath := func(providedPath, defaultFilename string) string {
+		if providedPath == "" {
+			return defaultFilename
+		}
+		// Check if provided path is a directory (ends with /)
+		if strings.HasSuffix(providedPath, "/") {
+			return providedPath + defaultFilename
+		}
+		return providedPath
+	}
+
+	var formats []outputFormat
+	add := func(name string, selected bool, fileType filetype.FileType, providedPath, defaultFilename string) {
+		formats = append(formats, outputFormat{name: name, selected: selected, outputFile: outputFile{fileType: fileType, path: getFilePath(providedPath, defaultFilename)}})
+	}
+	goType := &filetype.GoType{Format: cli.GoFormat}
+	if cli.AutoOutput {
+		// A detected module may keep a library, rather than a command, next to version.go
+		goType.Package = packageName(filepath.Dir(getFilePath(cli.GoPath, "version.go")))
+	}
+	add("go", cli.Go, goType, cli.GoPath, "version.go")
+	expvarType := &filetype.GoExpvarType{}
+	if cli.AutoOutput {
+		expvarType.Package = packageName(filepath.Dir(getFilePath(cli.GoExpvarPath, "version_expvar.go")))
+	}
+	add("go-expvar", cli.GoExpvar, expvarType, cli.GoExpvarPath, "version_expvar.go")
+	add("cpp", cli.Cpp, &filetype.CPPType{}, cli.CppPath, "version.h")
+	add("yaml", cli.Yaml, &filetype.YAMLFile{Merge: cli.YamlMerge, Key: cli.YamlKey}, cli.YamlPath, "version.yaml")
+	add("file", cli.File, &filetype.BasicFile{Format: cli.FileFormat, Layout: cli.FileLayout}, cli.FilePath, ".VERSION")
+	add("tfvars", cli.Tfvars, &filetype.TerraformType{}, cli.TfvarsPath, "version.auto.tfvars")
+	add("packer", cli.Packer, &filetype.PackerType{}, cli.PackerPath, "version.auto.pkrvars.json")
+	add("json", cli.JSONFile, &filetype.JSONType{}, cli.JSONPath, "version.json")
+	add("cyclonedx", cli.CycloneDX, &filetype.CycloneDXType{Name: cli.CycloneDXName}, cli.CycloneDXPath, "version.cdx.json")
+	add("nix", cli.Nix, &filetype.NixType{}, cli.NixPath, "version.nix")
+	add("shell", cli.Shell, &filetype.ShellType{}, cli.ShellPath, "version.sh")
+	add("powershell", cli.PowerShell, &filetype.PowerShellType{}, cli.PowerShellPath, "version.ps1")
+	add("ini", cli.Ini, &filetype.INIType{}, cli.IniPath, "version.ini")
+	add("rc", cli.Rc, &filetype.RCType{}, cli.RcPath, "version.rc")
+	add("jenkins", cli.Jenkins, &filetype.JenkinsType{}, cli.JenkinsPath, "version.properties")
+	return formats
+}
+
+// selectOutputs returns the output files selected by the output flags, in the
+// order the flags are declared, or none when the version should only be printed
+func selectOutputs(cli *CLI) []outputFile {
+	var outputs []outputFile
+	for _, format := range outputFormats(cli) {
+		if format.selected {
+			outputs = append(outputs, format.outputFile)
+		}
+	}
+	return outputs
+}
+
+// writeIntoArchive adds the selected output file, or the bare version when
+// none is selected, to the archive named by --into-archive
+func writeIntoArchive(cli *CLI, gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo, outputs []outputFile) {
+	separator := strings.LastIndex(cli.IntoArchive, ":")
+	if separator <= 0 || separator == len(cli.IntoArchive)-1 {
+		fatalf(ErrorUsage, "--into-archive needs ARCHIVE:MEMBER, e.g. dist.tar.gz:VERSION")
+	}
+	archivePath, member := cli.IntoArchive[:separator], cli.IntoArchive[separator+1:]
+
+	output := outputFile{fileType: &filetype.BasicFile{}, path: member}
+	switch len(outputs) {
+	case 0:
+	case 1:
+		output = outputFile{fileType: outputs[0].fileType, path: member}
+	default:
+		fatalf(ErrorUsage, "--into-archive takes a single output format, got %d", len(outputs))
+	}
+
+	if !cli.Force {
+		if err := validateOutputPath(gitHandler, archivePath); err != nil {
+			fatalf(ErrorUsage, "Refusing to update archive: %v", err)
+		}
+	}
+	if cli.Backup {
+		if err := filetype.Backup(archivePath, cli.BackupSuffix); err != nil {
+			fatalf(ErrorOutput, "Failed to back up file %s: %v", archivePath, err)
+		}
+	}
+	// Writers that update an existing file find none, since member is not on disk
+	data, err := filetype.Prepare(output.fileType, "", versionInfo, writeOptionsFor(cli, versionInfo))
+	if err != nil {
+		fatalf(ErrorOutput, "Failed to render %s: %v", member, err)
+	}
+	if err := filetype.WriteToArchive(archivePath, member, data, time.Now()); err != nil {
+		fatalf(ErrorOutput, "Failed to write %s into %s: %v", member, archivePath, err)
+	}
+}
+
+// stagedOutput is an output file rendered to a temporary file next to it
+type stagedOutput struct {
+	gitHandler   gittype.GitHandler
+	path         string
+	tempPath     string
+	previousPath string // Copy of the file it replaced, kept until the run succeeded
+}
+
+// outputWriter writes output files. With --atomic-outputs every file is first
+// written to a temporary file next to it, and the files are only replaced once
+// all of them were rendered and written, so a failed run changes none of them.
+type outputWriter struct {
+	cli     *CLI
+	staged  []stagedOutput
+	written []string // Paths written so far, for --checksums
+}
+
+// newOutputWriter returns a writer for the output files of one run
+func newOutputWriter(cli *CLI) *outputWriter {
+	return &outputWriter{cli: cli}
+}
+
+// write validates and writes an output file, or stages it with --atomic-outputs
+func (w *outputWriter) write(gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo, output outputFile) {
+	if !w.cli.AtomicOutputs {
+		writeOutput(w.cli, gitHandler, versionInfo, output.fileType, output.path)
+		w.written = append(w.written, output.path)
+		return
+	}
+	if !w.cli.Force {
+		if err := validateOutputPath(gitHandler, output.path); err != nil {
+			w.discard()
+			fatalf(ErrorUsage, "Refusing to write version file: %v", err)
+		}
+	}
+	tempPath, err := stageFile(output, versionInfo, writeOptionsFor(w.cli, versionInfo))
+	if err != nil {
+		w.discard()
+		fatalf(ErrorOutput, "Failed to write version to file %s: %v", output.path, err)
+	}
+	w.staged = append(w.staged, stagedOutput{gitHandler: gitHandler, path: output.path, tempPath: tempPath})
+}
+
+// commit moves the staged files into place in the order they were written,
+// then writes their checksums and signatures. When a file cannot be moved,
+// the files already moved are put back as they were.
+func (w *outputWriter) commit() {
+	for i, staged := range w.staged {
+		if w.cli.Backup {
+			if err := filetype.Backup(staged.path, w.cli.BackupSuffix); err != nil {
+				w.rollback(i)
+				fatalf(ErrorOutput, "Failed to back up file %s: %v", staged.path, err)
+			}
+		}
+		previousPath, err := keepPrevious(staged.path)
+		if err != nil {
+			w.rollback(i)
+			fatalf(ErrorOutput, "Failed to keep a copy of %s: %v", staged.path, err)
+		}
+		w.staged[i].previousPath = previousPath
+		if err := os.Rename(staged.tempPath, staged.path); err != nil {
+			w.rollback(i)
+			fatalf(ErrorOutput, "Failed to replace %s: %v", staged.path, err)
+		}
+		w.written = append(w.written, staged.path)
+	}
+	for _, staged := range w.staged {
+		if staged.previousPath != "" {
+			os.Remove(staged.previousPath)
+		}
+	}
+	for _, staged := range w.staged {
+		if err := applyGitignorePolicy(staged.gitHandler, staged.path, w.cli.Gitignore); err != nil {
+			fatalf(ErrorOutput, "Gitignore check failed for %s: %v", staged.path, err)
+		}
+	}
+	w.staged = nil
+
+	if len(w.written) == 0 {
+		return
+	}
+	signed := w.written
+	if w.cli.Checksums != "none" {
+		sumsPath, err := filetype.WriteChecksums(w.cli.Checksums, w.written, ".")
+		if err != nil {
+			fatalf(ErrorOutput, "Failed to write checksums: %v", err)
+		}
+		signed = append(slices.Clip(signed), sumsPath)
+	}
+	if w.cli.SignOutput != "none" {
+		signFiles(w.cli, signed)
+	}
+}
+
+// discard removes the staged files that were not moved into place
+func (w *outputWriter) discard() {
+	for _, staged := range w.staged {
+		os.Remove(staged.tempPath)
+		if staged.previousPath != "" {
+			os.Remove(staged.previousPath)
+		}
+	}
+	w.staged = nil
+}
+
+// rollback puts back the files of the first moved staged files, removing
+// those that did not exist before, and discards the rest
+func (w *outputWriter) rollback(moved int) {
+	for _, staged := range w.staged[:moved] {
+		if staged.previousPath == "" {
+			os.Remove(staged.path)
+		} else if err := os.Rename(staged.previousPath, staged.path); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: could not restore %s, its previous content is in %s: %v\n", staged.path, staged.previousPath, err)
+		}
+	}
+	w.staged = w.staged[moved:]
+	w.discard()
+}
+
+// keepPrevious keeps the current content of an output file under a temporary
+// name next to it, as a hard link or else a copy, and returns that name, or ""
+// when there is no file yet
+func keepPrevious(path string) (string, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	temp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.prev")
+	if err != nil {
+		return "", err
+	}
+	temp.Close()
+	os.Remove(temp.Name())
+	if err := os.Link(path, temp.Name()); err == nil {
+		return temp.Name(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = os.WriteFile(temp.Name(), data, info.Mode().Perm())
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return "", err
+	}
+	return temp.Name(), nil
+}
+
+// stageFile renders an output file to a temporary file in its directory,
+// with the permissions of the file it will replace
+func stageFile(output outputFile, versionInfo *gittype.VersionInfo, writeOptions filetype.WriteOptions) (string, error) {
+	data, err := filetype.Prepare(output.fileType, output.path, versionInfo, writeOptions)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Dir(output.path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	temp, err := os.CreateTemp(dir, "."+filepath.Base(output.path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(output.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	_, err = temp.Write(data)
+	if closeErr := temp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(temp.Name(), mode)
+	}
+	if err != nil {
+		os.Remove(temp.Name())
+		return "", err
+	}
+	return temp.Name(), nil
+}
+
+// writeOptionsFor builds the write options from the CLI flags
+func writeOptionsFor(cli *CLI, versionInfo *gittype.VersionInfo) filetype.WriteOptions {
+	writeOptions := filetype.WriteOptions{
+		NoTrailingNewline: cli.NoNewline,
+		BOM:               cli.Bom,
+		LineEnding:        cli.LineEnding,
+	}
+	if cli.Provenance {
+		writeOptions.Header = []string{
+			"command: " + commandLine(),
+			"options: " + optionsFingerprint(cli),
+			"commit: " + versionInfo.Commit,
+		}
+	}
+	return writeOptions
+}
+
+// validateOutputPath checks that filename stays inside the repository working tree
+func validateOutputPath(gitHandler gittype.GitHandler, filename string) error {
+	repoRoot, err := gitHandler.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	return filetype.ValidatePath(filename, repoRoot)
+}
+
+// applyGitignorePolicy makes sure the written file is ignored or tracked as configured
+func applyGitignorePolicy(gitHandler gittype.GitHandler, filename, policy string) error {
+	if policy == "" || policy == filetype.GitignoreNone {
+		return nil
+	}
+
+	repoRoot, err := gitHandler.GetRepoRoot()
+	if err != nil {
+		return err
+	}
+	relPath, err := filetype.RelativeToRoot(filename, repoRoot)
+	if err != nil {
+		return err
+	}
+
+	switch policy {
+	case filetype.GitignoreIgnore:
+		ignored, err := gitHandler.IsIgnored(relPath)
+		if err != nil || ignored {
+			return err
+		}
+		return filetype.AppendGitignore(repoRoot, relPath)
+	case filetype.GitignoreTrack:
+		tracked, err := gitHandler.IsTracked(relPath)
+		if err != nil {
+			return err
+		}
+		if !tracked {
+			return fmt.Errorf("%s is not tracked by git; commit it or use --gitignore=ignore", relPath)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown gitignore policy %q", policy)
+	}
+}
+
+// printDiff prints what the output file would become without writing it
+func printDiff(fileTypeHandler filetype.FileType, filename string, versionInfo *gittype.VersionInfo, writeOptions filetype.WriteOptions) error {
+	newData, err := filetype.Prepare(fileTypeHandler, filename, versionInfo, writeOptions)
+	if err != nil {
+		return err
+	}
+	oldData, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	fmt.Print(filetype.UnifiedDiff(filename, oldData, newData))
+	return nil
+}
+
+// commandLine returns the invocation as a shell-quoted string
+func commandLine() string {
+	args := make([]string, len(os.Args))
+	for i, arg := range os.Args {
+		if i == 0 {
+			arg = filepath.Base(arg)
+		}
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`*?") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		args[i] = arg
+	}
+	return strings.Join(args, " ")
+}
+
+// optionsFingerprint returns a short hash of the effective options
+func optionsFingerprint(cli *CLI) string {
+	data, err := json.Marshal(cli)
+	if err != nil {
+		return "unknown"
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])[:16]
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"reflect"
+	"slices"
+	"time"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+
+	"github.com/alecthomas/kong"
+	"gopkg.in/yaml.v3"
+)
+
+// PinCmd records the version of HEAD and the options that produced it in a
+// lockfile, so --from-pin can regenerate the same outputs later
+type PinCmd struct {
+	VersionFlags `kong:"embed"`
+	OutputFlags  `kong:"embed"`
+
+	Path string `kong:"arg,optional,default='version.pin.yaml',help='Lockfile to write (default: version.pin.yaml)'"`
+}
+
+// WarningPinGenerator is the code of the warning printed when --from-pin runs
+// with a different build of version-generator than the one that wrote the pin
+const WarningPinGenerator = "pin-generator"
+
+// pinSkippedFlags are never recorded in a pin, since they select the run itself
+var pinSkippedFlags = []string{"help", "version", "config", "profile", "from-pin"}
+
+// versionPin is the lockfile written by pin and replayed by --from-pin
+type versionPin struct {
+	Commit       string                         `yaml:"commit"`
+	ShortHash    string                         `yaml:"short_hash"`
+	Branch       string                         `yaml:"branch"`
+	Tag          string                         `yaml:"tag"`
+	CommitsSince int                            `yaml:"commits_since"`
+	Version      string                         `yaml:"version"`
+	Variant      string                         `yaml:"variant,omitempty"`
+	Channel      string                         `yaml:"channel,omitempty"`
+	Metadata     []versionSchemes.BuildMetadata `yaml:"metadata,omitempty"`
+	Warnings     []gittype.Warning              `yaml:"warnings,omitempty"`
+	Generator    gittype.GeneratorInfo          `yaml:"generator"`
+	Options      map[string]any                 `yaml:"options"` // Flag values that differ from their defaults
+}
+
+// runPin generates the version and writes it with the effective options to the lockfile
+func runPin(cli *CLI, ctx *kong.Context) {
+	if cli.FromPin != "" {
+		fatalf(ErrorUsage, "pin cannot be combined with --from-pin")
+	}
+	_, versionInfo := generateVersion(cli)
+	pin := versionPin{
+		Commit:       versionInfo.Commit,
+		ShortHash:    versionInfo.ShortHash,
+		Branch:       versionInfo.Branch,
+		Tag:          versionInfo.LastTag,
+		CommitsSince: versionInfo.CommitsSince,
+		Version:      versionInfo.Version,
+		Variant:      versionInfo.Variant,
+		Channel:      versionInfo.Channel,
+		Metadata:     versionInfo.Metadata,
+		Warnings:     versionInfo.Warnings,
+		Generator:    gittype.GeneratorInfo{Version: Version, Commit: GitCommit, Options: optionsFingerprint(cli)},
+		Options:      pinOptions(ctx),
+	}
+
+	var buffer bytes.Buffer
+	encoder := yaml.NewEncoder(&buffer)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(pin); err != nil {
+		fatalf(ErrorInternal, "Failed to encode pin: %v", err)
+	}
+	content := append([]byte("# Written by version-generator pin; rebuild with --from-pin "+cli.Pin.Path+"\n"), buffer.Bytes()...)
+	if err := os.WriteFile(cli.Pin.Path, content, 0644); err != nil {
+		fatalf(ErrorOutput, "Failed to write pin: %v", err)
+	}
+	fmt.Printf("Pinned %s at %s to %s\n", pin.Version, pin.ShortHash, cli.Pin.Path)
+}
+
+// pinOptions collects the global and pin flags whose values differ from their
+// defaults, wherever the value came from, keyed by flag name
+func pinOptions(ctx *kong.Context) map[string]any {
+	options := map[string]any{}
+	for _, flag := range ctx.Flags() {
+		if slices.Contains(pinSkippedFlags, flag.Name) {
+			continue
+		}
+		defaultValue := reflect.New(flag.Target.Type()).Elem()
+		if flag.HasDefault {
+			token := kong.Token{Type: kong.FlagValueToken, Value: flag.Default}
+			if err := flag.Parse(kong.ScanFromTokens(token), defaultValue); err != nil {
+				fatalf(ErrorInternal, "Failed to parse the default of --%s: %v", flag.Name, err)
+			}
+		}
+		if reflect.DeepEqual(flag.Target.Interface(), defaultValue.Interface()) {
+			continue
+		}
+		value := flag.Target.Interface()
+		if duration, ok := value.(time.Duration); ok {
+			value = duration.String()
+		}
+		options[flag.Name] = value
+	}
+	return options
+}
+
+// readPin reads a lockfile written by pin
+func readPin(path string) (*versionPin, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pin versionPin
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&pin); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if pin.Commit == "" || pin.Version == "" {
+		return nil, fmt.Errorf("%s records no commit or version", path)
+	}
+	return &pin, nil
+}
+
+// pinnedVersionInfo returns the version recorded by the --from-pin lockfile
+// instead of deriving it from the tags, once HEAD is confirmed to be the
+// pinned commit
+func pinnedVersionInfo(cli *CLI, gitHandler gittype.GitHandler) *gittype.VersionInfo {
+	pin, err := readPin(cli.FromPin)
+	if err != nil {
+		fatalf(ErrorConfig, "Failed to read pin: %v", err)
+	}
+	head, err := gitHandler.GetFullHash()
+	if err != nil {
+		fatalf(ErrorGit, "Failed to get HEAD commit: %v", err)
+	}
+	if head != pin.Commit {
+		fatalf(ErrorPinMismatch, "HEAD is %s, but %s pins %s at %s", head, cli.FromPin, pin.Version, pin.Commit)
+	}
+	if pin.Generator.Version != Version || pin.Generator.Commit != GitCommit {
+		printWarning(gittype.Warning{
+			Code: WarningPinGenerator,
+			Message: fmt.Sprintf("%s was written by version-generator %s (%s), this is %s (%s); generated files record the running build",
+				cli.FromPin, pin.Generator.Version, pin.Generator.Commit, Version, GitCommit),
+		})
+	}
+	return &gittype.VersionInfo{
+		Branch:       pin.Branch,
+		LastTag:      pin.Tag,
+		CommitsSince: pin.CommitsSince,
+		ShortHash:    pin.ShortHash,
+		Commit:       pin.Commit,
+		Version:      pin.Version,
+		Variant:      pin.Variant,
+		Channel:      pin.Channel,
+		Metadata:     pin.Metadata,
+		Warnings:     pin.Warnings,
+	}
+}
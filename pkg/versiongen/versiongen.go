@@ -0,0 +1,208 @@
+// Package versiongen generates versions as the version-generator command does,
+// for Go tools that embed it instead of running the binary:
+//
+//	info, err := versiongen.Generate(ctx, versiongen.Options{
+//		Versioning: versionSchemes.VersioningOptions{Scheme: versionSchemes.SchemeSemVer},
+//	})
+//
+// Output files, policies and the other command line features are not part of
+// it; fileType renders a VersionInfo in the formats the command writes.
+package versiongen
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+)
+
+// VersionInfo is the generated version and the repository state it was derived from
+type VersionInfo = gittype.VersionInfo
+
+// Warning is a soft problem found while generating a version
+type Warning = gittype.Warning
+
+// ErrInvalidOptions is matched, with errors.Is, by errors caused by the Options
+// rather than the repository
+var ErrInvalidOptions = errors.New("invalid options")
+
+// Options configures Generate. The zero value generates the default version
+// of the repository around the current directory with the git executable.
+type Options struct {
+	Dir        string                           // Directory in the repository (default: the current directory)
+	InBuiltGit bool                             // Use go-git instead of the git executable
+	Git        gittype.GitOptions               // Tag selection, commit counting and resolvers; Generate sets Context to its ctx
+	Versioning versionSchemes.VersioningOptions // Scheme, hash, variant, separators, template and Naming, which Git uses when it sets none
+
+	Channel       string                         // Build channel: stable, beta, rc or nightly (default: none)
+	CandidateBump string                         // Release the rc channel leads up to after a release tag: patch (default), minor or major
+	Metadata      []versionSchemes.BuildMetadata // Build metadata appended to the version, in order
+	DirtySuffix   string                         // Appended when tracked files have uncommitted changes (default: none)
+	MaxLength     int                            // Longest version allowed, shortening the branch name first (0: no limit)
+}
+
+// Generate opens the repository and generates its version. Canceling ctx
+// stops the git commands and history walks still running with its error, and
+// its deadline, if any, stops them with gittype.ErrTimeout.
+func Generate(ctx context.Context, options Options) (*VersionInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	gitOptions := options.Git
+	gitOptions.Context = ctx
+	if gitOptions.Naming == (versionSchemes.Naming{}) {
+		gitOptions.Naming = options.Versioning.Naming
+	}
+	if deadline, ok := ctx.Deadline(); ok && (gitOptions.Deadline.IsZero() || deadline.Before(gitOptions.Deadline)) {
+		gitOptions.Deadline = deadline
+	}
+	dir := options.Dir
+	if dir == "" {
+		dir = "."
+	}
+	handler, err := gittype.GetGitHandlerWithOptions(options.InBuiltGit, dir, gitOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository: %w", err)
+	}
+	return GenerateWith(handler, options)
+}
+
+// GenerateWith generates the version with an open handler; Dir, InBuiltGit
+// and Git of options are ignored
+func GenerateWith(handler gittype.GitHandler, options Options) (*VersionInfo, error) {
+	info, err := SchemeVersionInfo(handler, options)
+	if err != nil {
+		return nil, err
+	}
+	if err := Complete(handler, info, options); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// SchemeVersionInfo renders the version of the Channel or Versioning scheme of
+// options, before Complete applies the rest of them
+func SchemeVersionInfo(handler gittype.GitHandler, options Options) (*VersionInfo, error) {
+	versioning := options.Versioning
+	scheme := versioning.SelectedScheme()
+	separators := versioning.CountSeparator != "" || versioning.HashSeparator != ""
+	channel := options.Channel != "" && options.Channel != "none"
+
+	switch {
+	case channel && (scheme != versionSchemes.SchemeDefault || versioning.Hash || separators):
+		return nil, invalidOptions(fmt.Errorf("a channel cannot be combined with another version scheme, hash or separators"))
+	case channel:
+		return ChannelVersionInfo(handler, options.Channel, options.CandidateBump, versioning)
+	case scheme != versionSchemes.SchemeDefault || versioning.Hash || versioning.Variant != "" || separators:
+		return handler.GenerateVersionInfoWithOptions(versioning)
+	default:
+		return handler.GenerateVersionInfo(false)
+	}
+}
+
+// Complete applies the Metadata, DirtySuffix and MaxLength of options to info
+// and collects its warnings, for versions rendered by SchemeVersionInfo or by
+// the caller
+func Complete(handler gittype.GitHandler, info *VersionInfo, options Options) error {
+	info.Metadata = options.Metadata
+	info.Version = versionSchemes.AppendBuildMetadata(info.Version, options.Metadata)
+	if options.DirtySuffix != "" {
+		suffix, err := DirtySuffix(handler, options.DirtySuffix)
+		if err != nil {
+			return err
+		}
+		info.Version += suffix
+	}
+	var err error
+	if info.Version, err = options.Versioning.LimitLength(info.Version, info.Branch, options.MaxLength); err != nil {
+		return invalidOptions(err)
+	}
+	info.Warnings, err = Warnings(handler, info, options.Versioning.SelectedScheme())
+	return err
+}
+
+// ChannelVersionInfo renders the version of a build channel from the repository
+// state. Dated channels use the Clock of versioning, and its Variant is appended.
+func ChannelVersionInfo(handler gittype.GitHandler, channel, candidateBump string, versioning versionSchemes.VersioningOptions) (*VersionInfo, error) {
+	info, err := handler.GenerateVersionInfo(false)
+	if err != nil {
+		return nil, err
+	}
+	generator := versionSchemes.NewVersionGenerator()
+	if channel == versionSchemes.ChannelRC {
+		if candidateBump == "" {
+			candidateBump = versionSchemes.BumpPatch
+		}
+		tags, err := handler.GetTagNames()
+		if err != nil {
+			return nil, err
+		}
+		info.Version, err = generator.GenerateReleaseCandidate(info.LastTag, info.CommitsSince, candidateBump, tags)
+		if err != nil {
+			return nil, invalidOptions(err)
+		}
+	} else {
+		info.Version, err = generator.GenerateChannel(channel, info.LastTag, info.CommitsSince, info.ShortHash, versioning.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+	if versioning.Variant != "" {
+		info.Version = versionSchemes.AppendBuildMetadata(info.Version, []versionSchemes.BuildMetadata{{Key: versioning.Variant}})
+	}
+	info.Variant, info.Channel = versioning.Variant, channel
+	return info, nil
+}
+
+// dirtySuffixPattern matches the characters a dirty suffix may use
+var dirtySuffixPattern = regexp.MustCompile(`^[0-9A-Za-z._+-]+$`)
+
+// DirtySuffix returns suffix when tracked files have uncommitted changes, or
+// "" for a clean working tree. Untracked files do not count.
+func DirtySuffix(handler gittype.GitHandler, suffix string) (string, error) {
+	if !dirtySuffixPattern.MatchString(suffix) {
+		return "", invalidOptions(fmt.Errorf("invalid dirty suffix %q: use letters, digits, '.', '_', '+' and '-'", suffix))
+	}
+	state, err := handler.GetWorktreeState()
+	if err != nil {
+		return "", err
+	}
+	if !state.Dirty {
+		return "", nil
+	}
+	return suffix, nil
+}
+
+// Warnings collects the repository warnings and flags a last tag that scheme
+// cannot read as a semantic version
+func Warnings(handler gittype.GitHandler, info *VersionInfo, scheme versionSchemes.Scheme) ([]Warning, error) {
+	warnings, err := handler.GetWarnings(info)
+	if err != nil {
+		return nil, err
+	}
+	if scheme != versionSchemes.SchemeCalVer && info.LastTag != "" {
+		if _, err := versionSchemes.ParseSemVer(info.LastTag); err != nil {
+			warnings = append(warnings, Warning{
+				Code:    gittype.WarningMalformedTag,
+				Message: fmt.Sprintf("last tag %q is not a semantic version; it is used as is", info.LastTag),
+			})
+		}
+	}
+	return warnings, nil
+}
+
+// optionsError marks an error as caused by the Options, keeping its message
+type optionsError struct {
+	err error
+}
+
+func (e *optionsError) Error() string   { return e.err.Error() }
+func (e *optionsError) Unwrap() []error { return []error{e.err, ErrInvalidOptions} }
+
+// invalidOptions makes err match ErrInvalidOptions
+func invalidOptions(err error) error {
+	return &optionsError{err: err}
+}
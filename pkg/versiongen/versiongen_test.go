@@ -0,0 +1,171 @@
+package versiongen
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+)
+
+// stubHandler is a repository in a fixed state; methods GenerateWith does not
+// call panic through the nil embedded handler
+type stubHandler struct {
+	gittype.GitHandler
+	branch, lastTag, shortHash string
+	commitsSince               int
+	tags                       []string
+	dirty                      bool
+}
+
+func (s *stubHandler) GenerateVersionInfo(dockerFormat bool) (*VersionInfo, error) {
+	generator := versionSchemes.NewVersionGenerator()
+	return s.info(generator.GenerateLegacy(s.lastTag, s.commitsSince, s.shortHash, s.branch, dockerFormat)), nil
+}
+
+func (s *stubHandler) GenerateVersionInfoWithOptions(options versionSchemes.VersioningOptions) (*VersionInfo, error) {
+	generator := versionSchemes.NewVersionGenerator()
+	return s.info(generator.GenerateVersion(s.lastTag, s.commitsSince, s.shortHash, s.branch, options)), nil
+}
+
+func (s *stubHandler) info(version string) *VersionInfo {
+	return &VersionInfo{Version: version, Branch: s.branch, LastTag: s.lastTag, CommitsSince: s.commitsSince, ShortHash: s.shortHash}
+}
+
+func (s *stubHandler) GetTagNames() ([]string, error) { return s.tags, nil }
+
+func (s *stubHandler) GetWarnings(info *VersionInfo) ([]Warning, error) { return nil, nil }
+
+func (s *stubHandler) GetWorktreeState() (versionSchemes.WorktreeState, error) {
+	return versionSchemes.WorktreeState{Dirty: s.dirty}, nil
+}
+
+// releaseDay is the fixed clock of the tests
+var releaseDay = versionSchemes.FixedClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+
+func TestGenerateWith(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler stubHandler
+		options Options
+		want    string
+	}{
+		{"default", stubHandler{branch: "main", lastTag: "v1.2.0", commitsSince: 3, shortHash: "abc1234"},
+			Options{}, "v1.2.0+3"},
+		{"semver with hash", stubHandler{branch: "main", lastTag: "v1.2.0", commitsSince: 3, shortHash: "abc1234"},
+			Options{Versioning: versionSchemes.VersioningOptions{Scheme: versionSchemes.SchemeSemVer, Hash: true}}, "v1.2.0.3+abc1234"},
+		{"calver by the clock", stubHandler{branch: "main", lastTag: "v1.2.0", shortHash: "abc1234"},
+			Options{Versioning: versionSchemes.VersioningOptions{Scheme: versionSchemes.SchemeCalVer, Clock: releaseDay}}, "2024.05"},
+		{"nightly by the clock", stubHandler{branch: "main", lastTag: "v1.2.0", commitsSince: 3, shortHash: "abc1234"},
+			Options{Channel: versionSchemes.ChannelNightly, Versioning: versionSchemes.VersioningOptions{Clock: releaseDay}}, "2024.05.01+abc1234"},
+		{"release candidate", stubHandler{branch: "main", lastTag: "v1.2.0", commitsSince: 3, shortHash: "abc1234", tags: []string{"v1.2.0", "v1.2.1-rc.1"}},
+			Options{Channel: versionSchemes.ChannelRC}, "v1.2.1-rc.2"},
+		{"channel variant", stubHandler{branch: "main", lastTag: "v1.2.0", commitsSince: 3, shortHash: "abc1234"},
+			Options{Channel: versionSchemes.ChannelBeta, Versioning: versionSchemes.VersioningOptions{Variant: "debug"}}, "v1.2.0-beta.3+debug"},
+		{"metadata and dirty suffix", stubHandler{branch: "main", lastTag: "v1.2.0", commitsSince: 3, shortHash: "abc1234", dirty: true},
+			Options{Metadata: []versionSchemes.BuildMetadata{{Key: "run", Value: "42"}}, DirtySuffix: "-dirty"}, "v1.2.0+3.run.42-dirty"},
+		{"clean worktree", stubHandler{branch: "main", lastTag: "v1.2.0", commitsSince: 3, shortHash: "abc1234"},
+			Options{DirtySuffix: "-dirty"}, "v1.2.0+3"},
+		{"length limit", stubHandler{branch: "feature/long-branch-name", lastTag: "v1.2.0", commitsSince: 3, shortHash: "abc1234"},
+			Options{MaxLength: 24}, "v1.2.0-feature-ce9abdb+3"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			info, err := GenerateWith(&test.handler, test.options)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Version != test.want {
+				t.Errorf("version %q, want %q", info.Version, test.want)
+			}
+		})
+	}
+}
+
+func TestGenerateWithInvalidOptions(t *testing.T) {
+	handler := &stubHandler{branch: "main", lastTag: "v1.2.0", commitsSince: 3, shortHash: "abc1234"}
+	tests := []struct {
+		name    string
+		options Options
+	}{
+		{"channel and scheme", Options{Channel: versionSchemes.ChannelBeta, Versioning: versionSchemes.VersioningOptions{Scheme: versionSchemes.SchemeSemVer}}},
+		{"dirty suffix", Options{DirtySuffix: "dirty state"}},
+		{"length limit", Options{MaxLength: 3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := GenerateWith(handler, test.options); !errors.Is(err, ErrInvalidOptions) {
+				t.Errorf("error %v, want ErrInvalidOptions", err)
+			}
+		})
+	}
+}
+
+func TestWarningsMalformedTag(t *testing.T) {
+	handler := &stubHandler{branch: "main", lastTag: "release-7", shortHash: "abc1234"}
+	info, err := GenerateWith(handler, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Warnings) != 1 || info.Warnings[0].Code != gittype.WarningMalformedTag {
+		t.Errorf("warnings %v, want one %s", info.Warnings, gittype.WarningMalformedTag)
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"commit", "-q", "--allow-empty", "-m", "release"},
+		{"tag", "v1.0.0"},
+		{"commit", "-q", "--allow-empty", "-m", "fix"},
+	} {
+		command := exec.Command("git", args...)
+		command.Dir = dir
+		command.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1",
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com")
+		if output, err := command.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, output)
+		}
+	}
+
+	for _, inBuiltGit := range []bool{false, true} {
+		info, err := Generate(context.Background(), Options{Dir: dir, InBuiltGit: inBuiltGit})
+		if err != nil {
+			t.Fatalf("in-built git %v: %v", inBuiltGit, err)
+		}
+		if info.Version != "v1.0.0+1" {
+			t.Errorf("in-built git %v: version %q, want v1.0.0+1", inBuiltGit, info.Version)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Generate(ctx, Options{Dir: dir}); !errors.Is(err, context.Canceled) {
+		t.Errorf("error %v with a canceled context, want context.Canceled", err)
+	}
+
+	// Canceling after the repository is open stops the git operations left
+	for _, inBuiltGit := range []bool{false, true} {
+		ctx, cancel := context.WithCancel(context.Background())
+		options := Options{Dir: dir, InBuiltGit: inBuiltGit}
+		options.Git.Resolvers.Branch = cancelingBranch(cancel)
+		if _, err := Generate(ctx, options); !errors.Is(err, context.Canceled) {
+			t.Errorf("in-built git %v: error %v when canceled while generating, want context.Canceled", inBuiltGit, err)
+		}
+	}
+}
+
+// cancelingBranch is a branch resolver that cancels the generation it is part of
+type cancelingBranch context.CancelFunc
+
+func (c cancelingBranch) GetCurrentBranch() (string, error) {
+	c()
+	return "main", nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultPolicyFile is looked up at the repository root when --policy is not given
+const defaultPolicyFile = ".version-generator-policy.yaml"
+
+// Bump levels for ReleasePolicy.MinimumBump, smallest first
+var bumpLevels = []string{"patch", "minor", "major"}
+
+// ReleasePolicy restricts where and how release tags may be created
+type ReleasePolicy struct {
+	AllowedBranches []string `yaml:"allowed_branches"` // Branch globs releases may be cut from
+	RequireClean    bool     `yaml:"require_clean"`    // Refuse releases with uncommitted changes
+	RequireSigned   bool     `yaml:"require_signed"`   // Refuse unsigned tags
+	MinimumBump     string   `yaml:"minimum_bump"`     // Smallest allowed increase over the previous tag: patch, minor or major
+	ForbidBreaking  []string `yaml:"forbid_breaking"`  // Branch globs where the breaking command fails on breaking changes
+}
+
+// PolicyViolation is one failed policy rule
+type PolicyViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// releaseRequest describes the tag about to be written
+type releaseRequest struct {
+	Tag         string
+	PreviousTag string
+	Branch      string
+	Signed      bool
+}
+
+// loadPolicy reads the policy file. Without an explicit path the default file at
+// the repository root is used if it exists; nil means no policy applies.
+func loadPolicy(policyPath string, gitHandler gittype.GitHandler) (*ReleasePolicy, string, error) {
+	if policyPath == "" {
+		repoRoot, err := gitHandler.GetRepoRoot()
+		if err != nil {
+			return nil, "", err
+		}
+		policyPath = filepath.Join(repoRoot, defaultPolicyFile)
+		if _, err := os.Stat(policyPath); errors.Is(err, os.ErrNotExist) {
+			return nil, "", nil
+		}
+	}
+
+	content, err := os.ReadFile(policyPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read policy: %w", err)
+	}
+	var policy ReleasePolicy
+	if err := yaml.Unmarshal(content, &policy); err != nil {
+		return nil, "", fmt.Errorf("failed to parse policy %s: %w", policyPath, err)
+	}
+	if policy.MinimumBump != "" && bumpLevel(policy.MinimumBump) < 0 {
+		return nil, "", fmt.Errorf("invalid minimum_bump %q in %s: want patch, minor or major", policy.MinimumBump, policyPath)
+	}
+	return &policy, policyPath, nil
+}
+
+// Evaluate checks a release against every rule and returns the violations
+func (p *ReleasePolicy) Evaluate(release releaseRequest, gitHandler gittype.GitHandler) ([]PolicyViolation, error) {
+	var violations []PolicyViolation
+
+	if len(p.AllowedBranches) > 0 && !matchesAny(p.AllowedBranches, release.Branch) {
+		violations = append(violations, PolicyViolation{
+			Rule:    "allowed_branches",
+			Message: fmt.Sprintf("branch %q is not one of %v", release.Branch, p.AllowedBranches),
+		})
+	}
+
+	if p.RequireClean {
+		state, err := gitHandler.GetWorktreeState()
+		if err != nil {
+			return nil, err
+		}
+		if state.Dirty {
+			violations = append(violations, PolicyViolation{Rule: "require_clean", Message: "working tree has uncommitted changes"})
+		}
+	}
+
+	if p.RequireSigned && !release.Signed {
+		violations = append(violations, PolicyViolation{Rule: "require_signed", Message: "tag must be signed (pass --sign)"})
+	}
+
+	if p.MinimumBump != "" && release.PreviousTag != "" {
+		if message := checkBump(release.PreviousTag, release.Tag, p.MinimumBump); message != "" {
+			violations = append(violations, PolicyViolation{Rule: "minimum_bump", Message: message})
+		}
+	}
+
+	return violations, nil
+}
+
+// checkBump describes why tag is not at least a minimum bump over previous, or returns ""
+func checkBump(previous, tag, minimum string) string {
+	from, err := versionSchemes.ParseSemVer(previous)
+	if err != nil {
+		return fmt.Sprintf("previous tag %s is not a semantic version", previous)
+	}
+	to, err := versionSchemes.ParseSemVer(tag)
+	if err != nil {
+		return fmt.Sprintf("tag %s is not a semantic version", tag)
+	}
+	if to.Compare(from) <= 0 {
+		return fmt.Sprintf("tag %s does not increase on %s", tag, previous)
+	}
+
+	level := "patch"
+	switch {
+	case to.Major != from.Major:
+		level = "major"
+	case to.Minor != from.Minor:
+		level = "minor"
+	}
+	if bumpLevel(level) < bumpLevel(minimum) {
+		return fmt.Sprintf("tag %s is a %s bump on %s, policy requires at least %s", tag, level, previous, minimum)
+	}
+	return ""
+}
+
+// bumpLevel returns the rank of a bump level, or -1 for unknown levels
+func bumpLevel(level string) int {
+	for i, candidate := range bumpLevels {
+		if candidate == level {
+			return i
+		}
+	}
+	return -1
+}
+
+// matchesAny reports whether name matches one of the glob patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// enforcePolicy evaluates the release policy before a tag is written, reporting
+// violations as text or JSON. It returns an error when the release is refused.
+func enforcePolicy(policyPath, format string, release releaseRequest, gitHandler gittype.GitHandler) error {
+	policy, loadedFrom, err := loadPolicy(policyPath, gitHandler)
+	if err != nil || policy == nil {
+		return err
+	}
+
+	violations, err := policy.Evaluate(release, gitHandler)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate policy: %w", err)
+	}
+
+	if format == "json" {
+		report := struct {
+			Policy     string            `json:"policy"`
+			Tag        string            `json:"tag"`
+			Allowed    bool              `json:"allowed"`
+			Violations []PolicyViolation `json:"violations"`
+		}{loadedFrom, release.Tag, len(violations) == 0, violations}
+		if report.Violations == nil {
+			report.Violations = []PolicyViolation{}
+		}
+		encoded, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(encoded))
+	} else {
+		for _, violation := range violations {
+			fmt.Fprintf(os.Stderr, "policy violation (%s): %s\n", violation.Rule, violation.Message)
+		}
+	}
+
+	if len(violations) > 0 {
+		return fmt.Errorf("release %s refused by policy %s", release.Tag, loadedFrom)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/alecthomas/kong"
+
+	filetype "version-generator/fileType"
+)
+
+// PrintCmd prints the version or one output format on stdout without writing any file
+type PrintCmd struct {
+	VersionFlags `kong:"embed"`
+	OutputFlags  `kong:"embed"`
+
+	Format string `kong:"arg,optional,enum='version,go,go-expvar,cpp,yaml,file,tfvars,packer,json,cyclonedx,nix,shell,powershell,ini,rc,jenkins,teamcity',default='version',help='What to print: version (default), or the content of an output format such as go, yaml or tfvars'"`
+}
+
+// printFormatFlags maps the output flags that select a file to the format print
+// renders for them
+var printFormatFlags = map[string]string{
+	"go": "go", "go-expvar": "go-expvar", "cpp": "cpp", "yaml": "yaml", "file": "file",
+	"tfvars": "tfvars", "packer": "packer", "json-file": "json", "cyclonedx": "cyclonedx",
+	"nix": "nix", "shell": "shell", "powershell": "powershell", "ini": "ini", "rc": "rc",
+	"jenkins": "jenkins", "teamcity": "teamcity",
+}
+
+// Validate turns an output flag given on the command line into the format
+// argument, so print --yaml prints the YAML file. Print renders one format, so
+// several such flags, or one naming another format than the argument, are
+// rejected; the same flags set by a config or profile are ignored.
+func (p *PrintCmd) Validate(ctx *kong.Context) error {
+	var flags []string
+	argument := false
+	for _, element := range ctx.Path {
+		switch {
+		case element.Resolved:
+		case element.Positional != nil:
+			argument = true
+		case element.Flag != nil && element.Flag.Name == "auto-output":
+			return fmt.Errorf("--auto-output selects files to write, which print never does; pass the format to print")
+		case element.Flag != nil && printFormatFlags[element.Flag.Name] != "":
+			flags = append(flags, element.Flag.Name)
+		}
+	}
+	if len(flags) == 0 {
+		return nil
+	}
+	format := printFormatFlags[flags[0]]
+	if len(flags) > 1 {
+		return fmt.Errorf("--%s select different formats; print renders one, e.g. print %s", strings.Join(flags, " and --"), format)
+	}
+	if argument && p.Format != format {
+		return fmt.Errorf("--%s selects %s, not the %s format argument", flags[0], format, p.Format)
+	}
+	p.Format = format
+	return nil
+}
+
+// runPrint generates the version and prints it, or renders the selected
+// format as the matching output flag would write it, whatever output flags are set
+func runPrint(cli *CLI) {
+	gitHandler, versionInfo := generateVersion(cli)
+	addVersionDetails(cli, gitHandler, versionInfo)
+
+	switch cli.Print.Format {
+	case "version":
+		if cli.OutputFormat == "json" {
+			printVersionJSON(versionInfo)
+		} else {
+			fmt.Println(versionInfo.Version)
+		}
+		return
+	case "teamcity":
+		printTeamCity(versionInfo)
+		return
+	}
+	for _, format := range outputFormats(cli) {
+		if format.name != cli.Print.Format {
+			continue
+		}
+		data, err := filetype.Prepare(format.fileType, format.path, versionInfo, writeOptionsFor(cli, versionInfo))
+		if err != nil {
+			fatalf(ErrorOutput, "Failed to render %s: %v", format.name, err)
+		}
+		if _, err := os.Stdout.Write(data); err != nil {
+			fatalf(ErrorOutput, "Failed to print %s: %v", format.name, err)
+		}
+		return
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	gittype "version-generator/gitType"
+
+	"github.com/alecthomas/kong"
+)
+
+// flagResolvers returns the flag values configured for this run: the defaults
+// of the user and repository config files, then the first branch rule matching
+// the current branch, then the profile selected by cli.Profile, each overriding
+// the one before. Flags given on the command line or through their environment
+// variables take precedence over all of them.
+func flagResolvers(cli *CLI) ([]kong.Resolver, error) {
+	config, err := loadUserConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Outside a repository only the user config applies; the command reports that itself
+	gitHandler, err := gittype.GetGitHandler(cli.InBuiltGit, ".")
+	if err == nil && cli.Config == "" {
+		_, err = gitHandler.GetRepoRoot()
+	}
+	inRepository := err == nil
+	if inRepository {
+		repoConfig, err := loadConfig(cli.Config, gitHandler)
+		if err != nil {
+			return nil, err
+		}
+		config = mergeConfig(config, repoConfig)
+	}
+
+	var resolvers []kong.Resolver
+	if len(config.Defaults) > 0 {
+		resolvers = append(resolvers, &flagValues{source: "defaults", values: config.Defaults})
+	}
+	if len(config.Branches) > 0 && inRepository {
+		rule, found, err := matchBranchRule(config.Branches, cli, gitHandler)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			resolvers = append(resolvers, &flagValues{source: fmt.Sprintf("branch rule %q", rule.Match), values: rule.Values})
+		}
+	}
+	if cli.Profile != "" {
+		profile, found := config.Profiles[cli.Profile]
+		if !found {
+			names := make([]string, 0, len(config.Profiles))
+			for name := range config.Profiles {
+				names = append(names, name)
+			}
+			if len(names) == 0 {
+				return nil, fmt.Errorf("unknown profile %q: no profiles are configured", cli.Profile)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("unknown profile %q (configured: %s)", cli.Profile, strings.Join(names, ", "))
+		}
+		resolvers = append(resolvers, &flagValues{source: fmt.Sprintf("profile %q", cli.Profile), values: profile})
+	}
+	return resolvers, nil
+}
+
+// flagValues resolves flags from configured values keyed by flag name
+type flagValues struct {
+	source string
+	values map[string]any
+}
+
+// Validate rejects keys that are not flags, and the flags that select the configuration itself
+func (f *flagValues) Validate(app *kong.Application) error {
+	var flags []string
+	var collect func(node *kong.Node)
+	collect = func(node *kong.Node) {
+		for _, flag := range node.Flags {
+			flags = append(flags, flag.Name)
+		}
+		for _, child := range node.Children {
+			collect(child)
+		}
+	}
+	collect(app.Node)
+
+	for key := range f.values {
+		if key == "profile" || key == "config" || !slices.Contains(flags, key) {
+			return fmt.Errorf("%s: unknown or unsupported flag %q", f.source, key)
+		}
+	}
+	return nil
+}
+
+// Resolve returns the configured value for a flag, or nil when none is
+// configured or the flag's environment variable is set
+func (f *flagValues) Resolve(_ *kong.Context, _ *kong.Path, flag *kong.Flag) (any, error) {
+	for _, env := range flag.Envs {
+		if _, set := os.LookupEnv(env); set {
+			return nil, nil
+		}
+	}
+	return f.values[flag.Name], nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	gittype "version-generator/gitType"
+)
+
+// gitFileState is what --read-only compares for each file under the git directory
+type gitFileState struct {
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// mutatingOperation names the requested operation that writes to the
+// repository, or returns "" when the run only reads it
+func mutatingOperation(cli *CLI, command string) string {
+	switch {
+	case command == "stamp":
+		return "stamp"
+	case strings.HasPrefix(command, "tag-release"):
+		return "tag-release"
+	case strings.HasPrefix(command, "bump") && !cli.Bump.DryRun:
+		return "bump"
+	case command == "nightly" && cli.Nightly.Tag:
+		return "nightly --tag"
+	case command == "action" && cli.Action.FetchHistory == "auto":
+		return "action --fetch-history=auto"
+	case cli.Note:
+		return "--note"
+	}
+	return ""
+}
+
+// guardReadOnly refuses operations that write to the repository and records
+// the state of the git directory. The returned function fails the run when
+// any file under it was created, removed or had its size, permissions or
+// modification time changed.
+func guardReadOnly(cli *CLI, command string) func() {
+	if operation := mutatingOperation(cli, command); operation != "" {
+		fatalf(ErrorUsage, "--read-only does not allow %s, which writes to the repository", operation)
+	}
+
+	gitHandler, err := gittype.GetGitHandler(cli.InBuiltGit, ".")
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+	}
+	root, err := gitHandler.GetRepoRoot()
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to find repository root: %v", err)
+	}
+	gitDirs, err := gitDirectories(root)
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to locate git directory: %v", err)
+	}
+	before, err := snapshotGitDirs(gitDirs)
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to read git directory: %v", err)
+	}
+
+	return func() {
+		after, err := snapshotGitDirs(gitDirs)
+		if err != nil {
+			fatalf(ErrorRepository, "Failed to read git directory: %v", err)
+		}
+		if changed := changedGitFiles(before, after); len(changed) > 0 {
+			fatalf(ErrorRepositoryModified, "--read-only: the repository changed during the run: %s", strings.Join(changed, ", "))
+		}
+	}
+}
+
+// gitDirectories returns the git directory of the working tree at root and,
+// for a linked worktree, the common directory holding refs and objects
+func gitDirectories(root string) ([]string, error) {
+	gitDir := filepath.Join(root, ".git")
+	info, err := os.Stat(gitDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		// A linked worktree or submodule has a "gitdir: <path>" file instead
+		data, err := os.ReadFile(gitDir)
+		if err != nil {
+			return nil, err
+		}
+		target, ok := strings.CutPrefix(strings.TrimSpace(string(data)), "gitdir:")
+		if !ok {
+			return nil, fmt.Errorf("%s is not a git directory or gitdir file", gitDir)
+		}
+		gitDir = strings.TrimSpace(target)
+		if !filepath.IsAbs(gitDir) {
+			gitDir = filepath.Join(root, gitDir)
+		}
+	}
+
+	dirs := []string{gitDir}
+	if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(common))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		dirs = append(dirs, filepath.Clean(commonDir))
+	}
+	return dirs, nil
+}
+
+// snapshotGitDirs records the state of every file and directory below dirs
+func snapshotGitDirs(dirs []string) (map[string]gitFileState, error) {
+	snapshot := make(map[string]gitFileState)
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return err
+			}
+			state := gitFileState{mode: info.Mode(), modTime: info.ModTime()}
+			if !entry.IsDir() {
+				state.size = info.Size()
+			}
+			snapshot[path] = state
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return snapshot, nil
+}
+
+// changedGitFiles lists the paths that differ between two snapshots, sorted
+func changedGitFiles(before, after map[string]gitFileState) []string {
+	var changed []string
+	for path, state := range after {
+		if previous, ok := before[path]; !ok {
+			changed = append(changed, path+" (created)")
+		} else if previous.size != state.size || previous.mode != state.mode || !previous.modTime.Equal(state.modTime) {
+			changed = append(changed, path+" (modified)")
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path+" (removed)")
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
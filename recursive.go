@@ -0,0 +1,182 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	filetype "version-generator/fileType"
+	gittype "version-generator/gitType"
+)
+
+// projectVersion is one entry of the --recursive summary
+type projectVersion struct {
+	Dir          string            `json:"dir"`
+	Config       string            `json:"config"`
+	Version      string            `json:"version,omitempty"`
+	Tag          string            `json:"tag,omitempty"`
+	CommitsSince int               `json:"commits_since"`
+	Output       string            `json:"output,omitempty"`
+	Warnings     []gittype.Warning `json:"warnings,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	ErrorCode    string            `json:"error_code,omitempty"` // With --output-format=json
+}
+
+// runRecursive runs the generator once for every .version-generator.yaml
+// below the current directory, in that directory and with that file as
+// --config, and prints a summary. Projects run concurrently as separate
+// processes, so one failing project does not stop the others.
+func runRecursive(cli *CLI) {
+	if cli.Config != "" {
+		fatalf(ErrorUsage, "--recursive uses the config file of each project and cannot be combined with --config")
+	}
+	configs, err := projectConfigs(".")
+	if err != nil {
+		fatalf(ErrorConfig, "Failed to find project configs: %v", err)
+	}
+	if len(configs) == 0 {
+		fatalf(ErrorConfig, "No %s found below the current directory", defaultConfigFile)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		fatalf(ErrorInternal, "Failed to locate the generator executable: %v", err)
+	}
+	args := recursiveArgs(os.Args[1:])
+
+	summary := newSummaryPrinter(cli)
+	results := make([]chan projectVersion, len(configs))
+	limit := make(chan struct{}, runtime.GOMAXPROCS(0))
+	for i, config := range configs {
+		results[i] = make(chan projectVersion, 1)
+		go func() {
+			limit <- struct{}{}
+			defer func() { <-limit }()
+			results[i] <- runProject(executable, args, config)
+		}()
+	}
+
+	// Entries are printed in directory order, each as soon as it and the ones before it finished
+	failed := 0
+	for _, result := range results {
+		project := <-result
+		if project.Error != "" {
+			failed++
+		}
+		summary.add(project)
+	}
+	summary.finish()
+	if failed > 0 {
+		fatalf(ErrorProjectFailed, "%d of %d projects failed", failed, len(configs))
+	}
+}
+
+// projectConfigs lists the config files below root, sorted by path. Hidden
+// directories such as .git are not searched.
+func projectConfigs(root string) ([]string, error) {
+	var configs []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() && path != root && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if !entry.IsDir() && entry.Name() == defaultConfigFile {
+			configs = append(configs, path)
+		}
+		return nil
+	})
+	return configs, err
+}
+
+// recursiveArgs drops --recursive from the command line given to each project
+func recursiveArgs(args []string) []string {
+	var kept []string
+	for _, arg := range args {
+		if arg == "--recursive" || strings.HasPrefix(arg, "--recursive=") {
+			continue
+		}
+		kept = append(kept, arg)
+	}
+	return kept
+}
+
+// runProject runs the generator in the directory of config and collects the
+// version it reports. Its warnings are passed on to stderr, prefixed with the
+// directory; anything else it prints, such as --diff output, is kept in the summary.
+func runProject(executable string, args []string, config string) projectVersion {
+	dir := filepath.Dir(config)
+	project := projectVersion{Dir: filepath.ToSlash(dir), Config: filepath.ToSlash(config)}
+
+	report, err := os.CreateTemp("", "version-generator-report-*.json")
+	if err != nil {
+		project.Error = fmt.Sprintf("failed to create report file: %v", err)
+		return project
+	}
+	report.Close()
+	defer os.Remove(report.Name())
+
+	absConfig, err := filepath.Abs(config)
+	if err != nil {
+		project.Error = err.Error()
+		return project
+	}
+	command := exec.Command(executable, append([]string{"--config=" + absConfig, "--report-file=" + report.Name()}, args...)...)
+	command.Dir = dir
+	var stdout, stderr bytes.Buffer
+	command.Stdout, command.Stderr = &stdout, &stderr
+	runErr := command.Run()
+
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	if runErr != nil {
+		// The failure is reported last, as JSON with --output-format=json
+		var reported cliError
+		if err := json.Unmarshal([]byte(lines[len(lines)-1]), &reported); err == nil && reported.Code != "" {
+			project.Error, project.ErrorCode = reported.Message, reported.Code
+		} else if project.Error = strings.Join(lines, "\n"); project.Error == "" {
+			project.Error = runErr.Error()
+		}
+		return project
+	}
+	for _, line := range lines {
+		if line != "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", project.Dir, line)
+		}
+	}
+	project.Output = strings.TrimSpace(stdout.String())
+
+	// Runs that write no version, such as --components, leave the report empty
+	data, err := os.ReadFile(report.Name())
+	if err != nil || len(data) == 0 {
+		return project
+	}
+	var reported struct {
+		Version      string            `json:"version"`
+		Tag          string            `json:"tag"`
+		CommitsSince int               `json:"commits_since"`
+		Warnings     []gittype.Warning `json:"warnings"`
+	}
+	if err := json.Unmarshal(data, &reported); err != nil {
+		project.Error = fmt.Sprintf("failed to read the version report: %v", err)
+		return project
+	}
+	project.Version, project.Tag, project.CommitsSince, project.Warnings = reported.Version, reported.Tag, reported.CommitsSince, reported.Warnings
+	return project
+}
+
+// writeReport records the version for the --recursive run that started this one
+func writeReport(path string, versionInfo *gittype.VersionInfo) {
+	data, err := (&filetype.JSONType{}).Render("", versionInfo)
+	if err != nil {
+		fatalf(ErrorInternal, "Failed to encode version: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		fatalf(ErrorOutput, "Failed to write report file: %v", err)
+	}
+}
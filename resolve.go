@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+)
+
+// ResolveCmd finds the commit a generated version was built from
+type ResolveCmd struct {
+	VersionFlags `kong:"embed"`
+
+	Version string `kong:"arg,help='Generated version to look up, e.g. v1.2.3-feature-x+5'"`
+}
+
+// hashIdentifier matches an abbreviated commit hash in build metadata, with
+// the g prefix of git describe allowed
+var hashIdentifier = regexp.MustCompile(`^g?([0-9a-f]{7,40})$`)
+
+// resolveCandidate is a branch a version may have been generated on
+type resolveCandidate struct {
+	tag    string // Tag as it appears in the version
+	branch gittype.Branch
+	name   string // Branch name the version was generated for
+}
+
+// runResolve prints the full hash of the commit that generates the version
+// with the current flags. A hash in the build metadata is looked up directly;
+// otherwise the tag and branch are read from the version and the commits
+// since the tag on each matching branch are versioned until one matches.
+func runResolve(cli *CLI) {
+	version := cli.Resolve.Version
+	core, build, _ := strings.Cut(version, "+")
+
+	if hash, ok := resolveHashIdentifier(cli, build); ok {
+		fmt.Println(hash)
+		return
+	}
+
+	gitHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", gitOptionsFor(cli))
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+	}
+	candidates, err := resolveCandidates(gitHandler, namingFor(cli), core)
+	if err != nil {
+		fatalf(ErrorGit, "Failed to list branches: %v", err)
+	}
+	if len(candidates) == 0 {
+		fatalf(ErrorUsage, "No tag and branch match version %s", version)
+	}
+
+	// The commit count after the + narrows the search on linear history
+	count := 0
+	if identifiers := buildIdentifiers(build); len(identifiers) > 0 {
+		if n, err := strconv.Atoi(identifiers[0]); err == nil {
+			count = n
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		hash, found, err := resolveOnBranch(cli, candidate, version, count, seen)
+		if err != nil {
+			fatalf(ErrorGit, "Failed to search %s: %v", candidate.branch.Name, err)
+		}
+		if found {
+			fmt.Println(hash)
+			return
+		}
+	}
+	fatalf(ErrorGit, "No commit on %d matching branch(es) generates version %s", len(candidates), version)
+}
+
+// resolveHashIdentifier looks up a commit hash carried in build metadata, as
+// added by --hash
+func resolveHashIdentifier(cli *CLI, build string) (string, bool) {
+	for _, identifier := range buildIdentifiers(build) {
+		match := hashIdentifier.FindStringSubmatch(identifier)
+		if match == nil || strings.Trim(match[1], "0123456789") == "" {
+			continue
+		}
+		options := gitOptionsFor(cli)
+		options.Revision = match[1]
+		handler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", options)
+		if err != nil {
+			continue
+		}
+		if hash, err := handler.GetFullHash(); err == nil {
+			return hash, true
+		}
+	}
+	return "", false
+}
+
+// buildIdentifiers splits build metadata at the version separators
+func buildIdentifiers(build string) []string {
+	return strings.FieldsFunc(build, func(r rune) bool { return strings.ContainsRune(versionSchemes.Separators, r) })
+}
+
+// resolveCandidates splits the part of a version before the + into an
+// existing tag and the branch suffix after it, and lists the local and
+// remote-tracking branches with that suffix. Versions without a suffix come
+// from main, master or a detached HEAD.
+func resolveCandidates(gitHandler gittype.GitHandler, naming versionSchemes.Naming, core string) ([]resolveCandidate, error) {
+	local, err := gitHandler.GetBranches(false)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := gitHandler.GetBranches(true)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []resolveCandidate
+	for end := len(core); end > 0; end = strings.LastIndex(core[:end], "-") {
+		tag, suffix := core[:end], strings.TrimPrefix(core[end:], "-")
+		if _, found, err := gitHandler.GetTagDate(naming.Tag(tag)); err != nil || !found {
+			continue
+		}
+		for _, branch := range local {
+			if branchSuffix(naming, branch.Name) == suffix {
+				candidates = append(candidates, resolveCandidate{tag: tag, branch: branch, name: branch.Name})
+			}
+		}
+		for _, branch := range remote {
+			_, name, _ := strings.Cut(branch.Name, "/")
+			if branchSuffix(naming, name) == suffix {
+				candidates = append(candidates, resolveCandidate{tag: tag, branch: branch, name: name})
+			}
+		}
+	}
+	return candidates, nil
+}
+
+// branchSuffix returns what a branch adds after the tag in default versions
+func branchSuffix(naming versionSchemes.Naming, branchName string) string {
+	if branchName == "main" || branchName == "master" {
+		return ""
+	}
+	return naming.CleanBranch(branchName)
+}
+
+// resolveOnBranch versions the commits since the tag on a candidate branch
+// until one generates version. When count is known, the commit count positions
+// from the tag is tried first.
+func resolveOnBranch(cli *CLI, candidate resolveCandidate, version string, count int, seen map[string]bool) (string, bool, error) {
+	options := gitOptionsFor(cli)
+	options.Revision, options.Branch = candidate.branch.Ref, candidate.name
+	branchHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", options)
+	if err != nil {
+		return "", false, err
+	}
+	commits, err := branchHandler.GetCommitLog(options.Tag(candidate.tag))
+	if err != nil {
+		return "", false, err
+	}
+
+	// Newest first; the tagged commit itself is the version without a count
+	hashes := make([]string, 0, len(commits)+1)
+	if count > 0 && count <= len(commits) {
+		hashes = append(hashes, commits[len(commits)-count].Hash)
+	}
+	for _, commit := range commits {
+		hashes = append(hashes, commit.Hash)
+	}
+	if count == 0 {
+		hashes = append(hashes, options.Tag(candidate.tag)+"^{commit}")
+	}
+
+	for _, hash := range hashes {
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+		options.Revision = hash
+		handler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", options)
+		if err != nil {
+			return "", false, err
+		}
+		versionInfo, err := versionInfoFor(cli, handler)
+		if err != nil {
+			return "", false, err
+		}
+		if versionInfo.Version == version {
+			return versionInfo.Commit, true, nil
+		}
+	}
+	return "", false, nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+
+	filetype "version-generator/fileType"
+	gittype "version-generator/gitType"
+)
+
+// RestoreCmd restores output files from the copies kept by --backup
+type RestoreCmd struct {
+	OutputFlags `kong:"embed"`
+
+	Paths []string `kong:"arg,optional,help='Output files to restore (default: the files selected by the output flags)'"`
+}
+
+// runRestore moves backup copies back over the given or selected output files
+func runRestore(cli *CLI) {
+	paths := cli.Restore.Paths
+	if len(paths) == 0 {
+		for _, output := range selectOutputs(cli) {
+			paths = append(paths, output.path)
+		}
+	}
+	if len(paths) == 0 {
+		fatalf(ErrorUsage, "Nothing to restore: pass file paths or an output flag such as --go")
+	}
+
+	if !cli.Force {
+		gitHandler, err := gittype.GetGitHandler(cli.InBuiltGit, ".")
+		if err != nil {
+			fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+		}
+		for _, path := range paths {
+			if err := validateOutputPath(gitHandler, path); err != nil {
+				fatalf(ErrorUsage, "Refusing to restore file: %v", err)
+			}
+		}
+	}
+
+	for _, path := range paths {
+		if err := filetype.Restore(path, cli.BackupSuffix); err != nil {
+			fatalf(ErrorOutput, "Failed to restore %s: %v", path, err)
+		}
+		fmt.Printf("Restored %s from %s%s\n", path, path, cli.BackupSuffix)
+	}
+}
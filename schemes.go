@@ -0,0 +1,38 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+)
+
+// printAllSchemes prints how the repository state renders under every scheme,
+// as a table or, with --schemes-format=json, as a JSON object keyed by scheme
+func printAllSchemes(cli *CLI, versionInfo *gittype.VersionInfo) {
+	versions := versionSchemes.GenerateAll(versionSchemes.VersionInfo{
+		LastTag:      versionInfo.LastTag,
+		CommitsSince: versionInfo.CommitsSince,
+		ShortHash:    versionInfo.ShortHash,
+		Branch:       versionInfo.Branch,
+	})
+
+	if cli.Generate.SchemesFormat == "json" {
+		encoded, err := json.MarshalIndent(versions, "", "  ")
+		if err != nil {
+			fatalf(ErrorInternal, "Failed to encode versions: %v", err)
+		}
+		fmt.Println(string(encoded))
+		return
+	}
+
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "SCHEME\tVERSION")
+	for _, scheme := range versionSchemes.SchemeNames {
+		fmt.Fprintf(writer, "%s\t%s\n", scheme, versions[scheme])
+	}
+	writer.Flush()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+)
+
+// signFiles writes a detached signature for each file with the tool selected
+// by --sign-output: gpg writes <file>.asc, cosign writes a <file>.sigstore.json
+// bundle, signing keyless through Fulcio unless --sign-output-key is given
+func signFiles(cli *CLI, paths []string) {
+	for _, path := range paths {
+		var args []string
+		switch cli.SignOutput {
+		case "gpg":
+			args = []string{"gpg", "--batch", "--yes", "--armor", "--detach-sign", "--output", path + ".asc"}
+			if cli.SignOutputKey != "" {
+				args = append(args, "--local-user", cli.SignOutputKey)
+			}
+		case "cosign":
+			args = []string{"cosign", "sign-blob", "--yes", "--bundle", path + ".sigstore.json"}
+			if cli.SignOutputKey != "" {
+				args = append(args, "--key", cli.SignOutputKey)
+			}
+			if cli.Offline {
+				// Keep cosign from uploading to the Rekor transparency log
+				args = append(args, "--tlog-upload=false")
+			}
+		}
+		args = append(args, path)
+
+		var stderr bytes.Buffer
+		command := exec.Command(args[0], args[1:]...)
+		command.Stderr = &stderr
+		if err := command.Run(); err != nil {
+			message := strings.TrimSpace(stderr.String())
+			if message == "" {
+				message = err.Error()
+			}
+			fatalf(ErrorOutput, "Failed to sign %s with %s: %s", path, args[0], message)
+		}
+	}
+}
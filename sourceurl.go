@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	filetype "version-generator/fileType"
+	gittype "version-generator/gitType"
+)
+
+// addSourceURL renders --url-template, or with auto the commit page of the
+// origin remote, into the URL of the version
+func addSourceURL(cli *CLI, gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) {
+	template := cli.URLTemplate
+	if template == "auto" {
+		remote, found, err := gitHandler.GetRemoteURL("origin")
+		if err != nil {
+			fatalf(ErrorGit, "Failed to read the origin remote: %v", err)
+		}
+		if !found {
+			fatalf(ErrorUsage, "--url-template=auto needs an origin remote; pass a template instead")
+		}
+		if template, err = commitURLTemplate(remote); err != nil {
+			fatalf(ErrorUsage, "--url-template=auto: %v", err)
+		}
+	}
+
+	sourceURL, err := filetype.RenderLayout(template, versionInfo)
+	if err != nil {
+		fatalf(ErrorUsage, "Invalid --url-template: %v", err)
+	}
+	versionInfo.URL = sourceURL
+}
+
+// addRepository adds the web URL of the origin remote, without credentials,
+// for the VCS reference of --cyclonedx. Repositories without an origin, or
+// whose origin is a local path, get none.
+func addRepository(gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) {
+	remote, found, err := gitHandler.GetRemoteURL("origin")
+	if err != nil {
+		fatalf(ErrorGit, "Failed to read the origin remote: %v", err)
+	}
+	if !found {
+		return
+	}
+	if host, repoPath, err := parseRemoteURL(remote); err == nil {
+		versionInfo.Repository = "https://" + host + "/" + repoPath
+	}
+}
+
+// commitURLTemplate derives the commit page layout of a GitHub, GitLab or
+// Bitbucket remote from its SSH or HTTPS URL. Credentials are never kept.
+func commitURLTemplate(remote string) (string, error) {
+	host, repoPath, err := parseRemoteURL(remote)
+	if err != nil {
+		return "", err
+	}
+	base := "https://" + host + "/" + strings.ReplaceAll(repoPath, "%", "%%")
+	switch {
+	case strings.Contains(host, "github"):
+		return base + "/commit/%H", nil
+	case strings.Contains(host, "gitlab"):
+		return base + "/-/commit/%H", nil
+	case strings.Contains(host, "bitbucket"):
+		return base + "/commits/%H", nil
+	default:
+		return "", fmt.Errorf("cannot tell the commit URL of %s: pass a template instead", host)
+	}
+}
+
+// parseRemoteURL returns the host and repository path of a remote such as
+// git@github.com:org/repo.git, ssh://git@host:22/org/repo or https://host/org/repo.git
+func parseRemoteURL(remote string) (host, repoPath string, err error) {
+	if !strings.Contains(remote, "://") {
+		// scp-like syntax: [user@]host:path
+		hostPart, pathPart, ok := strings.Cut(remote, ":")
+		if !ok {
+			return "", "", fmt.Errorf("unsupported remote URL %q", remote)
+		}
+		remote = "ssh://" + hostPart + "/" + strings.TrimPrefix(pathPart, "/")
+	}
+	parsed, err := url.Parse(remote)
+	if err != nil || parsed.Hostname() == "" {
+		return "", "", fmt.Errorf("unsupported remote URL %q", remote)
+	}
+
+	host = parsed.Hostname()
+	// Keep the port of web URLs; SSH ports do not serve the web UI
+	if port := parsed.Port(); port != "" && (parsed.Scheme == "http" || parsed.Scheme == "https") {
+		host += ":" + port
+	}
+	repoPath = strings.TrimSuffix(strings.Trim(parsed.Path, "/"), ".git")
+	if repoPath == "" {
+		return "", "", fmt.Errorf("remote URL %q names no repository", remote)
+	}
+	return host, repoPath, nil
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	filetype "version-generator/fileType"
+)
+
+// StampCmd regenerates the selected output files and commits them
+type StampCmd struct {
+	VersionFlags `kong:"embed"`
+	OutputFlags  `kong:"embed"`
+
+	Amend   bool   `kong:"help='Amend the current commit instead of creating a follow-up commit'"`
+	Message string `kong:"help='Message for the follow-up commit',default='chore: update version files'"`
+}
+
+// runStamp writes the output files and records them in git. The generated
+// version describes HEAD before the commit is made, so an amended commit
+// carries the version computed for the commit it replaces.
+func runStamp(cli *CLI) {
+	outputs := selectOutputs(cli)
+	if len(outputs) == 0 {
+		fatalf(ErrorUsage, "stamp needs an output file: pass an output flag such as --go")
+	}
+
+	gitHandler, versionInfo := generateVersion(cli)
+
+	before := make([][]byte, len(outputs))
+	for i, output := range outputs {
+		before[i], _ = os.ReadFile(output.path)
+	}
+	writer := newOutputWriter(cli)
+	for _, output := range outputs {
+		writer.write(gitHandler, versionInfo, output)
+	}
+	writer.commit()
+
+	repoRoot, err := gitHandler.GetRepoRoot()
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to find repository root: %v", err)
+	}
+	var relPaths []string
+	upToDate := true
+	for i, output := range outputs {
+		after, err := os.ReadFile(output.path)
+		if err != nil {
+			fatalf(ErrorOutput, "Failed to read back %s: %v", output.path, err)
+		}
+		relPath, err := filetype.RelativeToRoot(output.path, repoRoot)
+		if err != nil {
+			fatalf(ErrorOutput, "Failed to resolve %s: %v", output.path, err)
+		}
+		tracked, err := gitHandler.IsTracked(relPath)
+		if err != nil {
+			fatalf(ErrorGit, "Failed to check %s: %v", relPath, err)
+		}
+		upToDate = upToDate && tracked && bytes.Equal(before[i], after)
+		relPaths = append(relPaths, relPath)
+	}
+	files := strings.Join(relPaths, ", ")
+	if upToDate {
+		fmt.Printf("%s is up to date at %s\n", files, versionInfo.Version)
+		return
+	}
+
+	if err := gitHandler.CommitFiles(relPaths, cli.Stamp.Message, cli.Stamp.Amend); err != nil {
+		fatalf(ErrorGit, "Failed to commit %s: %v", files, err)
+	}
+	if cli.Stamp.Amend {
+		fmt.Printf("Amended HEAD with %s at %s\n", files, versionInfo.Version)
+	} else {
+		fmt.Printf("Committed %s at %s\n", files, versionInfo.Version)
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	gittype "version-generator/gitType"
+	"version-generator/versionSchemes"
+)
+
+// StatsCmd reports release cadence and size from the tag history
+type StatsCmd struct {
+	JSON bool `kong:"name='json',help='Print the report as JSON'"`
+}
+
+// releaseStats summarizes the release tags reachable from HEAD
+type releaseStats struct {
+	Releases          int           `json:"releases"`
+	FirstRelease      string        `json:"first_release,omitempty"`
+	FirstDate         *time.Time    `json:"first_date,omitempty"`
+	LatestRelease     string        `json:"latest_release,omitempty"`
+	LatestDate        *time.Time    `json:"latest_date,omitempty"`
+	DaysSinceLatest   float64       `json:"days_since_latest"`
+	ReleasesPerMonth  float64       `json:"releases_per_month"`
+	DaysBetween       *distribution `json:"days_between_releases,omitempty"`
+	CommitsPerRelease *distribution `json:"commits_per_release,omitempty"`
+	UnreleasedCommits int           `json:"unreleased_commits"`
+}
+
+// distribution summarizes a series of measurements
+type distribution struct {
+	Average float64 `json:"average"`
+	Median  float64 `json:"median"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+}
+
+// daysPerMonth is the average length of a Gregorian month
+const daysPerMonth = 30.436875
+
+// runStats prints the release statistics of the repository. Everything is
+// computed from local tags; nothing leaves the machine.
+func runStats(cli *CLI) {
+	options := gitOptionsFor(cli)
+	gitHandler, err := gittype.GetGitHandlerWithOptions(cli.InBuiltGit, ".", options)
+	if err != nil {
+		fatalf(ErrorRepository, "Failed to initialize git handler: %v", err)
+	}
+	releases, err := gitHandler.GetReleaseTags()
+	if err != nil {
+		fatalf(ErrorGit, "Failed to read release tags: %v", err)
+	}
+	stats, err := computeReleaseStats(gitHandler, releases, options.Naming, time.Now())
+	if err != nil {
+		fatalf(ErrorGit, "Failed to compute release statistics: %v", err)
+	}
+
+	if cli.Stats.JSON {
+		encoded, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			fatalf(ErrorInternal, "Failed to encode release statistics: %v", err)
+		}
+		fmt.Println(string(encoded))
+		return
+	}
+	printReleaseStats(stats)
+}
+
+// computeReleaseStats derives the report from releases ordered oldest first.
+// The first release is left out of the commits per release, since it covers
+// all development before it.
+func computeReleaseStats(gitHandler gittype.GitHandler, releases []gittype.ReleaseTag, naming versionSchemes.Naming, now time.Time) (*releaseStats, error) {
+	stats := &releaseStats{Releases: len(releases)}
+	if len(releases) == 0 {
+		return stats, nil
+	}
+
+	first, latest := releases[0], releases[len(releases)-1]
+	stats.FirstRelease, stats.FirstDate = first.Name, &first.Date
+	stats.LatestRelease, stats.LatestDate = latest.Name, &latest.Date
+	stats.DaysSinceLatest = now.Sub(latest.Date).Hours() / 24
+
+	unreleased, err := gitHandler.GetCommitsSinceRevision("refs/tags/" + naming.Tag(latest.Name))
+	if err != nil {
+		return nil, err
+	}
+	stats.UnreleasedCommits = unreleased
+
+	if len(releases) < 2 {
+		return stats, nil
+	}
+	var intervals, commits []float64
+	for i := 1; i < len(releases); i++ {
+		intervals = append(intervals, releases[i].Date.Sub(releases[i-1].Date).Hours()/24)
+		commits = append(commits, float64(releases[i].Commits))
+	}
+	if span := latest.Date.Sub(first.Date).Hours() / 24; span > 0 {
+		stats.ReleasesPerMonth = float64(len(intervals)) / span * daysPerMonth
+	}
+	stats.DaysBetween = summarize(intervals)
+	stats.CommitsPerRelease = summarize(commits)
+	return stats, nil
+}
+
+// summarize computes the distribution of a non-empty series
+func summarize(values []float64) *distribution {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	sum := 0.0
+	for _, value := range sorted {
+		sum += value
+	}
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + median) / 2
+	}
+	return &distribution{
+		Average: sum / float64(len(sorted)),
+		Median:  median,
+		Min:     sorted[0],
+		Max:     sorted[len(sorted)-1],
+	}
+}
+
+// printReleaseStats prints the human-readable report
+func printReleaseStats(stats *releaseStats) {
+	if stats.Releases == 0 {
+		fmt.Println("No release tags reachable from HEAD")
+		return
+	}
+	const dateLayout = "2006-01-02"
+	fmt.Printf("Releases:             %d (%s to %s)\n", stats.Releases, stats.FirstRelease, stats.LatestRelease)
+	fmt.Printf("First release:        %s\n", stats.FirstDate.Format(dateLayout))
+	fmt.Printf("Latest release:       %s (%.0f days ago)\n", stats.LatestDate.Format(dateLayout), stats.DaysSinceLatest)
+	fmt.Printf("Unreleased commits:   %d\n", stats.UnreleasedCommits)
+	if stats.DaysBetween == nil {
+		return
+	}
+	fmt.Printf("Release cadence:      %.1f releases per month\n", stats.ReleasesPerMonth)
+	fmt.Printf("Days between:         average %.1f, median %.1f, shortest %.1f, longest %.1f\n",
+		stats.DaysBetween.Average, stats.DaysBetween.Median, stats.DaysBetween.Min, stats.DaysBetween.Max)
+	fmt.Printf("Commits per release:  average %.1f, median %.1f, fewest %.0f, most %.0f\n",
+		stats.CommitsPerRelease.Average, stats.CommitsPerRelease.Median, stats.CommitsPerRelease.Min, stats.CommitsPerRelease.Max)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"text/template"
+	"time"
+
+	gittype "version-generator/gitType"
+)
+
+// TagReleaseCmd creates an annotated release tag at HEAD
+type TagReleaseCmd struct {
+	VersionFlags `kong:"embed"`
+	IssueFlags   `kong:"embed"`
+
+	Tag          string `kong:"arg,help='Name of the tag to create, e.g. v1.3.0'"`
+	Template     string `kong:"type='existingfile',help='text/template file for the tag message (default: built-in changelog)'"`
+	DryRun       bool   `kong:"help='Print the tag message without creating the tag'"`
+	Sign         bool   `kong:"help='Sign the tag with the configured OpenPGP or SSH key'"`
+	SigningKey   string `kong:"help='Signing key: key ID or SSH key path for system git, armored OpenPGP or SSH private key file for go-git (implies --sign)'"`
+	Policy       string `kong:"type='existingfile',help='Release policy file (default: .version-generator-policy.yaml at the repository root, if present)'"`
+	PolicyOutput string `kong:"enum='text,json',default='text',help='Policy violation report format: text or json'"`
+}
+
+// signingPassphraseEnv names the variable holding the passphrase for an encrypted go-git signing key
+const signingPassphraseEnv = "VG_SIGNING_PASSPHRASE"
+
+// defaultTagTemplate renders the changelog, referenced issues, author and
+// co-author stats and CI metadata
+const defaultTagTemplate = `Release {{.Tag}}
+
+Changes since {{if .PreviousTag}}{{.PreviousTag}}{{else}}the initial commit{{end}}:
+{{range .Commits}}- {{.Subject}} ({{short .Hash}})
+{{else}}- no changes
+{{end}}{{with .Issues}}
+Issues:
+{{range .}}- {{.}}
+{{end}}{{end}}
+Authors:
+{{range .Authors}}- {{.Name}} <{{.Email}}>: {{.Commits}} commit{{if ne .Commits 1}}s{{end}}
+{{end}}{{with .CoAuthors}}
+Co-authors:
+{{range .}}- {{.Name}} <{{.Email}}>: {{.Commits}} commit{{if ne .Commits 1}}s{{end}}
+{{end}}{{end}}{{if .CI.Provider}}
+Built by {{.CI.Provider}}{{with .CI.BuildID}} build {{.}}{{end}}{{with .CI.BuildURL}}
+{{.}}{{end}}
+{{end}}`
+
+// tagTemplateData is the data available to tag message templates
+type tagTemplateData struct {
+	Tag          string
+	PreviousTag  string
+	Version      string
+	Branch       string
+	Commit       string
+	Date         time.Time
+	Commits      []gittype.CommitEntry
+	Issues       []string // Issue keys matched by --issue-pattern, first referenced first
+	Authors      []authorStat
+	CoAuthors    []authorStat          // Co-authored-by trailers, not counting a commit's own author
+	Contributors []gittype.Contributor // Authors and co-authors, each once, in order of first commit
+	CI           ciMetadata
+}
+
+// authorStat counts the commits of one author since the previous tag
+type authorStat struct {
+	Name    string
+	Email   string
+	Commits int
+}
+
+// ciMetadata describes the CI build the tag is created from, if any
+type ciMetadata struct {
+	Provider string
+	BuildID  string
+	BuildURL string
+}
+
+// runTagRelease renders the tag message and creates the tag
+func runTagRelease(cli *CLI) {
+	gitHandler, versionInfo := generateVersion(cli)
+
+	previousTag, err := previousReleaseTag(gitHandler, versionInfo)
+	if err != nil {
+		fatalf(ErrorGit, "Failed to find previous tag: %v", err)
+	}
+
+	release := releaseRequest{
+		Tag:         cli.TagRelease.Tag,
+		PreviousTag: previousTag,
+		Branch:      versionInfo.Branch,
+		Signed:      cli.TagRelease.Sign || cli.TagRelease.SigningKey != "",
+	}
+	if err := enforcePolicy(cli.TagRelease.Policy, cli.TagRelease.PolicyOutput, release, gitHandler); err != nil {
+		fatalf(ErrorPolicy, "Failed to tag release: %v", err)
+	}
+
+	message, err := renderTagMessage(cli.TagRelease.Tag, cli.TagRelease.Template, previousTag, issuePatternFor(cli), gitHandler, versionInfo)
+	if err != nil {
+		fatalf(ErrorUsage, "Failed to render tag message: %v", err)
+	}
+
+	if cli.TagRelease.DryRun {
+		fmt.Print(message)
+		return
+	}
+
+	var signing *gittype.SigningOptions
+	if release.Signed {
+		signing = &gittype.SigningOptions{
+			Key:        cli.TagRelease.SigningKey,
+			Passphrase: os.Getenv(signingPassphraseEnv),
+		}
+	}
+
+	if err := gitHandler.CreateTag(cli.TagRelease.Tag, message, signing); err != nil {
+		fatalf(ErrorGit, "Failed to tag release: %v", err)
+	}
+	if signing != nil {
+		fmt.Printf("Created signed tag %s at %s\n", cli.TagRelease.Tag, versionInfo.ShortHash)
+	} else {
+		fmt.Printf("Created tag %s at %s\n", cli.TagRelease.Tag, versionInfo.ShortHash)
+	}
+}
+
+// previousReleaseTag returns the last tag reachable from HEAD, or "" when there is none
+func previousReleaseTag(gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) (string, error) {
+	tag, found, err := gitHandler.GetLastTag(versionInfo.Branch)
+	if err != nil || !found {
+		return "", err
+	}
+	return tag, nil
+}
+
+// renderTagMessage executes the tag template (or the built-in one when templatePath is empty)
+func renderTagMessage(tag, templatePath, previousTag string, issuePattern *regexp.Regexp, gitHandler gittype.GitHandler, versionInfo *gittype.VersionInfo) (string, error) {
+	text := defaultTagTemplate
+	if templatePath != "" {
+		content, err := os.ReadFile(templatePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read template: %w", err)
+		}
+		text = string(content)
+	}
+
+	tmpl, err := template.New("tag").Funcs(template.FuncMap{
+		"short": func(hash string) string { return hash[:min(len(hash), 7)] },
+	}).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	commits, err := gitHandler.GetCommitLog(previousTag)
+	if err != nil {
+		return "", err
+	}
+
+	data := tagTemplateData{
+		Tag:          tag,
+		PreviousTag:  previousTag,
+		Version:      versionInfo.Version,
+		Branch:       versionInfo.Branch,
+		Commit:       versionInfo.Commit,
+		Date:         time.Now(),
+		Commits:      commits,
+		Issues:       issueKeys(issuePattern, commits),
+		Authors:      authorStats(commits),
+		CoAuthors:    coAuthorStats(commits),
+		Contributors: contributors(commits),
+		CI:           ciMetadataFromEnv(),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to execute template: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// authorStats counts commits per author email, most active first
+func authorStats(commits []gittype.CommitEntry) []authorStat {
+	index := make(map[string]int)
+	var stats []authorStat
+	for _, commit := range commits {
+		i, ok := index[commit.Email]
+		if !ok {
+			i = len(stats)
+			index[commit.Email] = i
+			stats = append(stats, authorStat{Name: commit.Author, Email: commit.Email})
+		}
+		stats[i].Commits++
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Commits > stats[j].Commits
+	})
+	return stats
+}
+
+// ciMetadataFromEnv detects common CI providers from their environment variables
+func ciMetadataFromEnv() ciMetadata {
+	switch {
+	case os.Getenv("GITHUB_ACTIONS") == "true":
+		meta := ciMetadata{Provider: "GitHub Actions", BuildID: os.Getenv("GITHUB_RUN_ID")}
+		if server, repo := os.Getenv("GITHUB_SERVER_URL"), os.Getenv("GITHUB_REPOSITORY"); server != "" && repo != "" && meta.BuildID != "" {
+			meta.BuildURL = fmt.Sprintf("%s/%s/actions/runs/%s", server, repo, meta.BuildID)
+		}
+		return meta
+	case os.Getenv("GITLAB_CI") != "":
+		return ciMetadata{Provider: "GitLab CI", BuildID: os.Getenv("CI_PIPELINE_ID"), BuildURL: os.Getenv("CI_PIPELINE_URL")}
+	case os.Getenv("JENKINS_URL") != "":
+		return ciMetadata{Provider: "Jenkins", BuildID: os.Getenv("BUILD_NUMBER"), BuildURL: os.Getenv("BUILD_URL")}
+	case os.Getenv("TEAMCITY_VERSION") != "":
+		return ciMetadata{Provider: "TeamCity", BuildID: os.Getenv("BUILD_NUMBER")}
+	case os.Getenv("CIRCLECI") != "":
+		return ciMetadata{Provider: "CircleCI", BuildID: os.Getenv("CIRCLE_BUILD_NUM"), BuildURL: os.Getenv("CIRCLE_BUILD_URL")}
+	case os.Getenv("CI") != "":
+		return ciMetadata{Provider: "CI"}
+	default:
+		return ciMetadata{}
+	}
+}
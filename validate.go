@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	filetype "version-generator/fileType"
+)
+
+// ValidateCmd checks the flags, config and repository state without writing anything
+type ValidateCmd struct {
+	VersionFlags `kong:"embed"`
+	OutputFlags  `kong:"embed"`
+}
+
+// runValidate generates the version, so invalid flags, config or policies fail
+// as they would in a build, and fails when a selected output file is missing
+// or differs from what the same flags would write
+func runValidate(cli *CLI) {
+	gitHandler, versionInfo := generateVersion(cli)
+	addVersionDetails(cli, gitHandler, versionInfo)
+
+	outputs := selectOutputs(cli)
+	var stale []string
+	for _, output := range outputs {
+		data, err := filetype.Prepare(output.fileType, output.path, versionInfo, writeOptionsFor(cli, versionInfo))
+		if err != nil {
+			fatalf(ErrorOutput, "Failed to render %s: %v", output.path, err)
+		}
+		existing, err := os.ReadFile(output.path)
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+			stale = append(stale, output.path+" (missing)")
+		case err != nil:
+			fatalf(ErrorOutput, "Failed to read %s: %v", output.path, err)
+		case !bytes.Equal(existing, data):
+			stale = append(stale, output.path+" (out of date)")
+		}
+	}
+	if len(stale) > 0 {
+		fatalf(ErrorStaleOutput, "%s: output files do not match: %s", versionInfo.Version, strings.Join(stale, ", "))
+	}
+	fmt.Printf("%s: valid, %d output files up to date\n", versionInfo.Version, len(outputs))
+}
@@ -0,0 +1,30 @@
+package versionSchemes
+
+// VersionInfo is the repository state a version is rendered from
+type VersionInfo struct {
+	LastTag      string
+	CommitsSince int
+	ShortHash    string
+	Branch       string
+	Clock        Clock // Time source of the calver scheme (default: SystemClock)
+}
+
+// SchemeNames lists the schemes rendered by GenerateAll, in display order
+var SchemeNames = []string{"default", "semver", "calver", "simple", "docker", "pep440"}
+
+// GenerateAll renders the same repository state under every scheme with its
+// default options, keyed by the names in SchemeNames
+func GenerateAll(info VersionInfo) map[string]string {
+	vg := NewVersionGenerator()
+	render := func(options VersioningOptions) string {
+		return vg.GenerateVersion(info.LastTag, info.CommitsSince, info.ShortHash, info.Branch, options)
+	}
+	return map[string]string{
+		"default": render(VersioningOptions{}),
+		"semver":  render(VersioningOptions{Scheme: SchemeSemVer}),
+		"calver":  render(VersioningOptions{Scheme: SchemeCalVer, Clock: info.Clock}),
+		"simple":  render(VersioningOptions{Scheme: SchemeSimple}),
+		"docker":  vg.GenerateLegacy(info.LastTag, info.CommitsSince, info.ShortHash, info.Branch, true),
+		"pep440":  vg.GeneratePEP440(info.LastTag, info.CommitsSince, info.Branch, false, info.ShortHash),
+	}
+}
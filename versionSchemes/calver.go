@@ -0,0 +1,79 @@
+package versionSchemes
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultCalVerFormat is the CalVer date layout used when none is configured
+const DefaultCalVerFormat = "YYYY.0M"
+
+// ValidateCalVerFormat checks that every dot-separated field of a CalVer format
+// is a known token: YYYY, YY, 0Y, MM, 0M, WW, 0W, DD, 0D or GGGG
+func ValidateCalVerFormat(format string) error {
+	for _, field := range strings.Split(format, ".") {
+		if _, ok := calVerField(field, time.Time{}, false); !ok {
+			return fmt.Errorf("unknown CalVer format field %q in %q", field, format)
+		}
+	}
+	return nil
+}
+
+// FormatCalVer renders the date fields of a CalVer version. Week fields are
+// ISO-8601 weeks, and a format containing one takes its year from the ISO
+// week-numbering year, so 2024-12-30 (week 1 of 2025) is 2025.01 rather than
+// 2024.01. Unknown fields are left as written; see ValidateCalVerFormat.
+func FormatCalVer(format string, t time.Time) string {
+	if format == "" {
+		format = DefaultCalVerFormat
+	}
+	fields := strings.Split(format, ".")
+	weekBased := false
+	for _, field := range fields {
+		if field == "WW" || field == "0W" {
+			weekBased = true
+		}
+	}
+
+	for i, field := range fields {
+		if value, ok := calVerField(field, t, weekBased); ok {
+			fields[i] = value
+		}
+	}
+	return strings.Join(fields, ".")
+}
+
+// calVerField renders one CalVer format field, reporting whether it is known
+func calVerField(field string, t time.Time, weekBased bool) (string, bool) {
+	isoYear, isoWeek := t.ISOWeek()
+	year := t.Year()
+	if weekBased {
+		year = isoYear
+	}
+
+	switch field {
+	case "YYYY":
+		return fmt.Sprintf("%d", year), true
+	case "YY":
+		return fmt.Sprintf("%d", year%100), true
+	case "0Y":
+		return fmt.Sprintf("%02d", year%100), true
+	case "GGGG":
+		return fmt.Sprintf("%d", isoYear), true
+	case "MM":
+		return fmt.Sprintf("%d", t.Month()), true
+	case "0M":
+		return fmt.Sprintf("%02d", t.Month()), true
+	case "WW":
+		return fmt.Sprintf("%d", isoWeek), true
+	case "0W":
+		return fmt.Sprintf("%02d", isoWeek), true
+	case "DD":
+		return fmt.Sprintf("%d", t.Day()), true
+	case "0D":
+		return fmt.Sprintf("%02d", t.Day()), true
+	default:
+		return field, false
+	}
+}
@@ -0,0 +1,84 @@
+package versionSchemes
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalVerPeriodStart(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no time zone database:", err)
+	}
+	date := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name  string
+		reset string
+		now   time.Time
+		want  time.Time
+	}{
+		{"week from midweek", CalVerResetWeek, date(2024, 5, 16, 15, 30), date(2024, 5, 13, 0, 0)},
+		{"week from sunday night", CalVerResetWeek, date(2024, 5, 19, 23, 59), date(2024, 5, 13, 0, 0)},
+		{"week on its monday", CalVerResetWeek, date(2024, 5, 13, 0, 0), date(2024, 5, 13, 0, 0)},
+		{"iso week across january 1", CalVerResetWeek, date(2025, 1, 1, 9, 0), date(2024, 12, 30, 0, 0)},
+		{"iso week across january 1 from sunday", CalVerResetWeek, date(2021, 1, 3, 9, 0), date(2020, 12, 28, 0, 0)},
+		{"month", CalVerResetMonth, date(2024, 2, 29, 18, 0), date(2024, 2, 1, 0, 0)},
+		{"month on its first", CalVerResetMonth, date(2024, 3, 1, 0, 0), date(2024, 3, 1, 0, 0)},
+		{"year", CalVerResetYear, date(2024, 12, 31, 23, 59), date(2024, 1, 1, 0, 0)},
+		{"year on january 1", CalVerResetYear, date(2025, 1, 1, 0, 0), date(2025, 1, 1, 0, 0)},
+		{"local midnight", CalVerResetMonth, time.Date(2024, 6, 1, 0, 30, 0, 0, berlin), time.Date(2024, 6, 1, 0, 0, 0, 0, berlin)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := VersioningOptions{Scheme: SchemeCalVer, CalVerReset: test.reset, Clock: FixedClock(test.now)}
+			start, ok := options.CalVerPeriodStart(options.Now())
+			if !ok || !start.Equal(test.want) {
+				t.Errorf("period start %v (%v), want %v", start, ok, test.want)
+			}
+			if start.Location() != test.now.Location() {
+				t.Errorf("period start in %v, want %v", start.Location(), test.now.Location())
+			}
+		})
+	}
+
+	for _, options := range []VersioningOptions{
+		{Scheme: SchemeCalVer, CalVerReset: CalVerResetNone},
+		{Scheme: SchemeCalVer},
+		{Scheme: SchemeSemVer, CalVerReset: CalVerResetMonth},
+	} {
+		if start, ok := options.CalVerPeriodStart(date(2024, 5, 16, 0, 0)); ok {
+			t.Errorf("%+v resets at %v, want no reset", options, start)
+		}
+	}
+}
+
+func TestCalVerResetVersion(t *testing.T) {
+	periodCommits := 2
+	tests := []struct {
+		name    string
+		format  string
+		now     time.Time
+		options VersioningOptions
+		want    string
+	}{
+		{"period commits cap the count", "YYYY.0M", time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC),
+			VersioningOptions{CalVerReset: CalVerResetMonth, PeriodCommits: &periodCommits}, "2024.05.2"},
+		{"iso week across january 1", "YYYY.0W", time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC),
+			VersioningOptions{CalVerReset: CalVerResetWeek, PeriodCommits: &periodCommits}, "2025.01.2"},
+		{"no reset", "YYYY.0M", time.Date(2024, 5, 16, 0, 0, 0, 0, time.UTC),
+			VersioningOptions{}, "2024.05.7"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			options := test.options
+			options.Scheme, options.CalVerFormat, options.Clock = SchemeCalVer, test.format, FixedClock(test.now)
+			if got := NewVersionGenerator().GenerateVersion("v1.0.0", 7, "abc1234", "main", options); got != test.want {
+				t.Errorf("version %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package versionSchemes
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Build channels a version can be published to
+const (
+	ChannelStable  = "stable"  // the last tag as is: v1.2.3
+	ChannelBeta    = "beta"    // a beta of the last tag numbered by commits since it: v1.2.3-beta.5
+	ChannelNightly = "nightly" // the build date and commit: 2024.08.15+abc1234
+	ChannelRC      = "rc"      // the next release numbered by the candidates already tagged: v1.3.0-rc.2
+)
+
+// NightlyCalVerFormat dates nightly builds to the day
+const NightlyCalVerFormat = "YYYY.0M.0D"
+
+// GenerateChannel renders the version of a build channel. Build metadata on
+// the tag is dropped; a beta of a prerelease tag extends its prerelease
+// (v2.0.0-rc.1.beta.3).
+func (vg *VersionGenerator) GenerateChannel(channel, lastTag string, commitsSince int, shortHash string, date time.Time) (string, error) {
+	switch channel {
+	case ChannelStable:
+		return lastTag, nil
+	case ChannelBeta:
+		tag, _, _ := strings.Cut(lastTag, "+")
+		separator := "-"
+		if strings.Contains(tag, "-") {
+			separator = "."
+		}
+		return fmt.Sprintf("%s%sbeta.%d", tag, separator, commitsSince), nil
+	case ChannelNightly:
+		version := FormatCalVer(NightlyCalVerFormat, date)
+		if shortHash != "" {
+			version += "+" + shortHash
+		}
+		return version, nil
+	case ChannelRC:
+		return "", fmt.Errorf("rc versions are numbered by the published tags: use GenerateReleaseCandidate")
+	default:
+		return "", fmt.Errorf("unknown channel %q: use stable, beta, rc or nightly", channel)
+	}
+}
+
+// releaseCandidate matches the prerelease of a release candidate tag
+var releaseCandidate = regexp.MustCompile(`^rc\.(\d+)$`)
+
+// GenerateReleaseCandidate renders the next release candidate of the release
+// after lastTag, numbered one above the highest rc.N tag of that release in
+// tags, so the number grows per published candidate instead of per commit.
+// A build of a candidate's own commit is that candidate.
+func (vg *VersionGenerator) GenerateReleaseCandidate(lastTag string, commitsSince int, bump string, tags []string) (string, error) {
+	last, err := ParseSemVer(lastTag)
+	if err != nil {
+		return "", fmt.Errorf("release candidates need a semantic version tag: %w", err)
+	}
+	next := last
+	next.Build = ""
+	if commitsSince > 0 || !releaseCandidate.MatchString(last.Prerelease) {
+		if next, err = nextRelease(last, bump); err != nil {
+			return "", err
+		}
+		number := 1
+		for _, tag := range tags {
+			candidate, err := ParseSemVer(tag)
+			if err != nil || candidate.Major != next.Major || candidate.Minor != next.Minor || candidate.Patch != next.Patch {
+				continue
+			}
+			if match := releaseCandidate.FindStringSubmatch(candidate.Prerelease); match != nil {
+				if n, err := strconv.Atoi(match[1]); err == nil && n >= number {
+					number = n + 1
+				}
+			}
+		}
+		next.Prerelease = fmt.Sprintf("rc.%d", number)
+	}
+
+	version := next.String()
+	if !strings.HasPrefix(lastTag, "v") {
+		version = strings.TrimPrefix(version, "v")
+	}
+	return version, nil
+}
@@ -0,0 +1,25 @@
+package versionSchemes
+
+import "time"
+
+// Clock tells the time that dated versions, such as CalVer, are generated
+// for. Library users pass a FixedClock to make those versions reproducible.
+type Clock interface {
+	Now() time.Time
+}
+
+// SystemClock reads the current time; it is used when no Clock is set
+type SystemClock struct{}
+
+// Now returns the current local time
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+// FixedClock always returns the same time, e.g. the commit or build date
+type FixedClock time.Time
+
+// Now returns the fixed time
+func (c FixedClock) Now() time.Time {
+	return time.Time(c)
+}
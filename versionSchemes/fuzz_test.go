@@ -0,0 +1,221 @@
+package versionSchemes
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// realWorldTags are tag styles seen in the wild, used to seed the fuzz targets
+var realWorldTags = []string{
+	"v1.2.3",
+	"1.2.3",
+	"v0.0.0",
+	"v2.0.0-rc.1",
+	"v1.0.0-beta.2+exp.sha.5114f85",
+	"v1.2.3+build.5",
+	"v10.20.30",
+	"1.0",
+	"v1",
+	"V1.2.3",
+	"release-2024.01",
+	"2024.08.1",
+	"api/v1.4.0",
+	"go1.22.0",
+	"v0.0.0-20240101120000-abcdef123456",
+	"v1.2.3-alpha",
+	"v01.2.3",
+	"pages-1",
+}
+
+// realWorldBranches are branch names seen in the wild, used to seed the fuzz targets
+var realWorldBranches = []string{
+	"main",
+	"master",
+	"detached",
+	"feature/new-api",
+	"release/1.x",
+	"user/jdoe/JIRA-123_fix",
+	"dependabot/npm_and_yarn/lodash-4.17.21",
+	"fix/ünïcödé-brånch",
+	"修复/登录",
+	"",
+	"-leading",
+	"a..b",
+}
+
+// gitRefInvalid matches characters git does not allow in ref names
+var gitRefInvalid = regexp.MustCompile(`[\x00-\x20\x7f~^:?*\[\\]`)
+
+// validRefName approximates git check-ref-format for fuzzed tag and branch names
+func validRefName(name string) bool {
+	return utf8.ValidString(name) && !gitRefInvalid.MatchString(name) && !strings.Contains(name, "..") &&
+		!strings.HasSuffix(name, ".lock") && !strings.HasSuffix(name, "/") && !strings.HasSuffix(name, ".")
+}
+
+// hexHash matches an abbreviated commit hash, or none
+var hexHash = regexp.MustCompile(`^[0-9a-f]*$`)
+
+// coreSemVer matches a tag that is exactly a release version
+var coreSemVer = regexp.MustCompile(`^v?(0|[1-9]\d{0,8})\.(0|[1-9]\d{0,8})\.(0|[1-9]\d{0,8})$`)
+
+// pep440 is the canonical PEP 440 version pattern (Appendix B of the specification),
+// with its groups named for parsePEP440
+var pep440 = regexp.MustCompile(`^(?:(?P<epoch>[1-9][0-9]*)!)?(?P<release>(?:0|[1-9][0-9]*)(?:\.(?:0|[1-9][0-9]*))*)(?:(?P<phase>a|b|rc)(?P<pre>0|[1-9][0-9]*))?(?:\.post(?P<post>0|[1-9][0-9]*))?(?:\.dev(?P<dev>0|[1-9][0-9]*))?(?:\+(?P<local>[a-z0-9]+(?:\.[a-z0-9]+)*))?$`)
+
+func FuzzParseSemVer(f *testing.F) {
+	for _, tag := range realWorldTags {
+		f.Add(tag)
+	}
+	f.Fuzz(func(t *testing.T, tag string) {
+		parsed, err := ParseSemVer(tag)
+		if err != nil {
+			return
+		}
+		if parsed.Major < 0 || parsed.Minor < 0 || parsed.Patch < 0 {
+			t.Fatalf("ParseSemVer(%q) = %+v: negative component", tag, parsed)
+		}
+		reparsed, err := ParseSemVer(parsed.String())
+		if err != nil {
+			t.Fatalf("ParseSemVer(%q) fails on its own String() %q: %v", tag, parsed.String(), err)
+		}
+		if reparsed != parsed {
+			t.Fatalf("ParseSemVer(%q) = %+v, but %q parses as %+v", tag, parsed, parsed.String(), reparsed)
+		}
+		if parsed.Compare(reparsed) != 0 {
+			t.Fatalf("%q does not compare equal to itself", tag)
+		}
+	})
+}
+
+func FuzzCleanBranchName(f *testing.F) {
+	for _, branch := range realWorldBranches {
+		f.Add(branch)
+	}
+	allowed := regexp.MustCompile(`^[A-Za-z0-9-]*$`)
+	vg := NewVersionGenerator()
+	f.Fuzz(func(t *testing.T, branch string) {
+		clean := vg.cleanBranchName(branch)
+		if !allowed.MatchString(clean) {
+			t.Fatalf("cleanBranchName(%q) = %q contains characters outside [A-Za-z0-9-]", branch, clean)
+		}
+		if utf8.ValidString(branch) && utf8.RuneCountInString(clean) != utf8.RuneCountInString(branch) {
+			t.Fatalf("cleanBranchName(%q) = %q: want one character per character", branch, clean)
+		}
+	})
+}
+
+func FuzzGenerateVersion(f *testing.F) {
+	for i, tag := range realWorldTags {
+		f.Add(tag, realWorldBranches[i%len(realWorldBranches)], uint16(i), "abc1234")
+	}
+	f.Add("v1.2.3", "main", uint16(0), "")
+	vg := NewVersionGenerator()
+	f.Fuzz(func(t *testing.T, tag, branch string, commits uint16, shortHash string) {
+		if !validRefName(tag) || !validRefName(branch) || !hexHash.MatchString(shortHash) {
+			t.Skip()
+		}
+		count := int(commits)
+
+		for _, options := range []VersioningOptions{
+			{},
+			{Semver: true},
+			{Semver: true, Hash: true},
+			{CalVer: true},
+			{CalVer: true, CalVerFormat: "YY.0W.0D", Hash: true},
+			{Simple: true, Hash: true},
+			{Hash: true, CountSeparator: "_", HashSeparator: "."},
+			{Variant: "debug"},
+		} {
+			version := vg.GenerateVersion(tag, count, shortHash, branch, options)
+			if strings.ContainsAny(version, " \t\n\r") {
+				t.Fatalf("GenerateVersion(%q, %d, %q, %q, %+v) = %q contains whitespace", tag, count, shortHash, branch, options, version)
+			}
+			if !options.Simple && count > 0 && !strings.Contains(version, strconv.Itoa(count)) {
+				t.Fatalf("GenerateVersion(%q, %d, %q, %q, %+v) = %q lost the commit count", tag, count, shortHash, branch, options, version)
+			}
+		}
+
+		// A release tag renders as a version that parses back to the same release
+		if coreSemVer.MatchString(tag) {
+			want, _ := ParseSemVer(tag)
+			version := vg.GenerateVersion(tag, count, shortHash, branch, VersioningOptions{})
+			got, err := ParseSemVer(version)
+			if err != nil {
+				t.Fatalf("release tag %q with %d commits on %q renders as %q, which does not parse: %v", tag, count, branch, version, err)
+			}
+			if got.Major != want.Major || got.Minor != want.Minor || got.Patch != want.Patch {
+				t.Fatalf("release tag %q renders as %q with a different release", tag, version)
+			}
+
+			pep := vg.GeneratePEP440(tag, count, branch, true, "abc1234")
+			if !pep440.MatchString(pep) {
+				t.Fatalf("GeneratePEP440(%q, %d, %q) = %q is not a valid PEP 440 version", tag, count, branch, pep)
+			}
+		}
+
+		// A fixed clock makes CalVer versions reproducible
+		date := time.Date(2024, time.December, 30, 12, 0, 0, 0, time.UTC)
+		calVer := vg.GenerateVersion(tag, count, shortHash, branch, VersioningOptions{CalVer: true, CalVerFormat: "YYYY.0W", Clock: FixedClock(date)})
+		if !strings.HasPrefix(calVer, "2025.01") {
+			t.Fatalf("CalVer at %s = %q, want the ISO week 2025.01", date, calVer)
+		}
+
+		all := GenerateAll(VersionInfo{LastTag: tag, CommitsSince: count, ShortHash: shortHash, Branch: branch, Clock: FixedClock(date)})
+		for _, scheme := range SchemeNames {
+			if _, ok := all[scheme]; !ok {
+				t.Fatalf("GenerateAll is missing scheme %s", scheme)
+			}
+		}
+		if all["calver"] != vg.GenerateVersion(tag, count, shortHash, branch, VersioningOptions{CalVer: true, Clock: FixedClock(date)}) {
+			t.Fatalf("GenerateAll ignores the clock: calver = %q", all["calver"])
+		}
+	})
+}
+
+func FuzzLimitLength(f *testing.F) {
+	for i, branch := range realWorldBranches {
+		f.Add("v1.2.3", branch, uint16(i*7), uint8(20+i))
+	}
+	vg := NewVersionGenerator()
+	f.Fuzz(func(t *testing.T, tag, branch string, commits uint16, maxLength uint8) {
+		if !validRefName(tag) || !validRefName(branch) {
+			t.Skip()
+		}
+		version := vg.GenerateVersion(tag, int(commits), "abc1234", branch, VersioningOptions{})
+		limited, err := LimitLength(version, branch, int(maxLength))
+		if err != nil {
+			return
+		}
+		if maxLength > 0 && len(limited) > int(maxLength) {
+			t.Fatalf("LimitLength(%q, %q, %d) = %q is too long", version, branch, maxLength, limited)
+		}
+		again, err := LimitLength(version, branch, int(maxLength))
+		if err != nil || again != limited {
+			t.Fatalf("LimitLength(%q, %q, %d) is not deterministic: %q then %q", version, branch, maxLength, limited, again)
+		}
+		if strings.Contains(limited, "--") && !strings.Contains(version, "--") {
+			t.Fatalf("LimitLength(%q, %q, %d) = %q introduced a doubled hyphen", version, branch, maxLength, limited)
+		}
+	})
+}
+
+func FuzzFormatCalVer(f *testing.F) {
+	for _, format := range []string{DefaultCalVerFormat, "YYYY.0M.0D", "YY.0W", "GGGG.WW", "0Y.MM.DD"} {
+		f.Add(format, int64(1723456789))
+		f.Add(format, int64(1735516800)) // 2024-12-30, in ISO week 1 of 2025
+	}
+	f.Fuzz(func(t *testing.T, format string, unix int64) {
+		if ValidateCalVerFormat(format) != nil {
+			t.Skip()
+		}
+		date := time.Unix(unix%(1<<40), 0).UTC()
+		version := FormatCalVer(format, date)
+		if got, want := strings.Count(version, "."), strings.Count(format, "."); got != want {
+			t.Fatalf("FormatCalVer(%q, %s) = %q has %d fields, want %d", format, date, version, got+1, want+1)
+		}
+	})
+}
@@ -0,0 +1,49 @@
+package versionSchemes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// branchDigestLength is the number of hex digits of the branch digest kept by LimitLength
+const branchDigestLength = 7
+
+// LimitLength shortens a version to at most maxLength characters by cutting the
+// branch component and appending a digest of the full branch name, so two long
+// branches sharing a prefix still get different versions:
+// v1.2.3-feature-a-very-long-branch-name+5 becomes v1.2.3-feature-a-3f9c2e1+5.
+// The result only depends on its inputs. Versions without a branch component,
+// or too long even with the branch reduced to its digest, are an error.
+func LimitLength(version, branchName string, maxLength int) (string, error) {
+	return Naming{}.LimitLength(version, branchName, maxLength)
+}
+
+// LimitLength is the package LimitLength for versions whose branch was cleaned with n
+func (n Naming) LimitLength(version, branchName string, maxLength int) (string, error) {
+	if maxLength <= 0 || len(version) <= maxLength {
+		return version, nil
+	}
+
+	clean := n.CleanBranch(branchName)
+	start := strings.Index(version, "-"+clean)
+	if clean == "" || start < 0 {
+		return "", fmt.Errorf("version %s is %d characters, longer than %d, and has no branch name to shorten", version, len(version), maxLength)
+	}
+	start++
+
+	sum := sha256.Sum256([]byte(branchName))
+	digest := hex.EncodeToString(sum[:])[:branchDigestLength]
+	keep := len(clean) - (len(version) - maxLength) - len(digest) - 1
+	if keep < 0 {
+		return "", fmt.Errorf("version %s cannot be shortened to %d characters", version, maxLength)
+	}
+
+	// Cut on a clean boundary so the branch never ends in a doubled or trailing hyphen
+	short := strings.TrimRight(clean[:keep], "-")
+	if short != "" {
+		short += "-"
+	}
+	return version[:start] + short + digest + version[start+len(clean):], nil
+}
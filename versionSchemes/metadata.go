@@ -0,0 +1,69 @@
+package versionSchemes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// invalidIdentifierChars matches characters not allowed in SemVer identifiers
+var invalidIdentifierChars = regexp.MustCompile(`[^0-9A-Za-z-]+`)
+
+// BuildMetadata is a key/value pair appended to the + build-metadata section of a version
+type BuildMetadata struct {
+	Key   string
+	Value string
+}
+
+// ParseBuildMetadata parses key=value (or a bare key). Values are kept as given;
+// they are sanitized only when appended to a version.
+func ParseBuildMetadata(entry string) (BuildMetadata, error) {
+	key, value, _ := strings.Cut(entry, "=")
+	meta := BuildMetadata{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value)}
+	if SanitizeIdentifier(meta.Key) == "" {
+		return BuildMetadata{}, fmt.Errorf("invalid build metadata %q: want key=value", entry)
+	}
+	return meta, nil
+}
+
+// SanitizeIdentifier replaces characters SemVer does not allow in identifiers with hyphens
+func SanitizeIdentifier(s string) string {
+	return strings.Trim(invalidIdentifierChars.ReplaceAllString(s, "-"), "-")
+}
+
+// AddPrereleaseLabel adds label to the pre-release part of version, before any
+// build metadata: v1.2.3+4 becomes v1.2.3-label+4 and v1.2.3-rc.1 v1.2.3-rc.1.label
+func AddPrereleaseLabel(version, label string) string {
+	core, build, hasBuild := strings.Cut(version, "+")
+	if strings.Contains(strings.TrimPrefix(core, "-"), "-") {
+		core += "." + label
+	} else {
+		core += "-" + label
+	}
+	if hasBuild {
+		return core + "+" + build
+	}
+	return core
+}
+
+// AppendBuildMetadata adds key.value identifiers to the + section of version,
+// extending an existing + section rather than starting a second one
+func AppendBuildMetadata(version string, metadata []BuildMetadata) string {
+	var identifiers []string
+	for _, meta := range metadata {
+		for _, identifier := range []string{SanitizeIdentifier(meta.Key), SanitizeIdentifier(meta.Value)} {
+			if identifier != "" {
+				identifiers = append(identifiers, identifier)
+			}
+		}
+	}
+	if len(identifiers) == 0 {
+		return version
+	}
+
+	separator := "+"
+	if strings.Contains(version, "+") {
+		separator = "."
+	}
+	return version + separator + strings.Join(identifiers, ".")
+}
@@ -0,0 +1,64 @@
+package versionSchemes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SanitizeProfile selects how branch names are cleaned for versions
+type SanitizeProfile string
+
+// Sanitization profiles
+const (
+	SanitizeSemVer SanitizeProfile = "semver" // letters, digits and hyphens, as SemVer identifiers allow (default)
+	SanitizeLower  SanitizeProfile = "lower"  // lower-case letters, digits and hyphens, e.g. for DNS labels and Kubernetes names
+)
+
+// ParseSanitizeProfile returns the sanitization profile with the given name
+func ParseSanitizeProfile(name string) (SanitizeProfile, error) {
+	switch profile := SanitizeProfile(name); profile {
+	case "", SanitizeSemVer:
+		return SanitizeSemVer, nil
+	case SanitizeLower:
+		return profile, nil
+	}
+	return "", fmt.Errorf("unknown sanitization profile %q: use semver or lower", name)
+}
+
+// Naming is how tag names and branch names map to versions. VersioningOptions
+// and gittype.GitOptions both embed it, so the command line, the git handlers
+// and the schemes share one definition.
+type Naming struct {
+	TagPrefix string          // Prefix of release tags, left out of versions (e.g. release-)
+	Sanitize  SanitizeProfile // How branch names are cleaned (default: SanitizeSemVer)
+}
+
+// Tag returns the name of the tag of a version, with TagPrefix
+func (n Naming) Tag(version string) string {
+	return n.TagPrefix + version
+}
+
+// HasTagPrefix reports whether a tag name starts with TagPrefix
+func (n Naming) HasTagPrefix(tag string) bool {
+	return strings.HasPrefix(tag, n.TagPrefix)
+}
+
+// TagVersion returns a tag name without TagPrefix, as versions carry it
+func (n Naming) TagVersion(tag string) string {
+	return strings.TrimPrefix(tag, n.TagPrefix)
+}
+
+// invalidBranchChars matches the characters cleaned out of branch names
+var invalidBranchChars = regexp.MustCompile(`[^a-zA-Z0-9\-]`)
+
+// CleanBranch returns a branch name as versions carry it, with characters
+// other than letters, digits and hyphens replaced by hyphens, lower-cased
+// with SanitizeLower
+func (n Naming) CleanBranch(branchName string) string {
+	clean := invalidBranchChars.ReplaceAllString(branchName, "-")
+	if n.Sanitize == SanitizeLower {
+		clean = strings.ToLower(clean)
+	}
+	return clean
+}
@@ -0,0 +1,76 @@
+package versionSchemes
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Release a nightly build leads up to, relative to the last tag
+const (
+	BumpPatch = "patch" // v1.3.2 leads to v1.3.3
+	BumpMinor = "minor" // v1.3.2 leads to v1.4.0
+	BumpMajor = "major" // v1.3.2 leads to v2.0.0
+)
+
+// GenerateNightly renders a date-stamped prerelease of the release after the
+// last tag, e.g. v1.4.0-nightly.20240815+abc1234, so nightlies sort above the
+// last release and below the next one. A prerelease tag such as v2.0.0-rc.1
+// already names the next release, which is used as is.
+func (vg *VersionGenerator) GenerateNightly(lastTag, bump, shortHash string, date time.Time) (string, error) {
+	last, err := ParseSemVer(lastTag)
+	if err != nil {
+		return "", fmt.Errorf("nightly versions need a semantic version tag: %w", err)
+	}
+	next, err := nextRelease(last, bump)
+	if err != nil {
+		return "", err
+	}
+	next.Prerelease = "nightly." + date.UTC().Format("20060102")
+	next.Build = shortHash
+
+	version := next.String()
+	if !strings.HasPrefix(lastTag, "v") {
+		version = strings.TrimPrefix(version, "v")
+	}
+	return version, nil
+}
+
+// NextRelease returns the release after lastTag for a bump, keeping the tag's
+// v prefix or lack of it: v1.3.2 becomes v1.4.0 for BumpMinor. A prerelease
+// tag such as v2.0.0-rc.1 already names the next release, v2.0.0.
+func NextRelease(lastTag, bump string) (string, error) {
+	last, err := ParseSemVer(lastTag)
+	if err != nil {
+		return "", fmt.Errorf("the last tag must be a semantic version: %w", err)
+	}
+	next, err := nextRelease(last, bump)
+	if err != nil {
+		return "", err
+	}
+	version := next.String()
+	if !strings.HasPrefix(lastTag, "v") {
+		version = strings.TrimPrefix(version, "v")
+	}
+	return version, nil
+}
+
+// nextRelease returns the release after a tag without prerelease or build
+// metadata. A prerelease tag already names it; a release tag is bumped.
+func nextRelease(tag SemVer, bump string) (SemVer, error) {
+	next := SemVer{Major: tag.Major, Minor: tag.Minor, Patch: tag.Patch}
+	if tag.Prerelease != "" {
+		return next, nil
+	}
+	switch bump {
+	case BumpPatch:
+		next.Patch++
+	case BumpMinor:
+		next.Minor, next.Patch = next.Minor+1, 0
+	case BumpMajor:
+		next.Major, next.Minor, next.Patch = next.Major+1, 0, 0
+	default:
+		return SemVer{}, fmt.Errorf("unknown bump %q: use patch, minor or major", bump)
+	}
+	return next, nil
+}
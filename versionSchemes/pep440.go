@@ -0,0 +1,54 @@
+package versionSchemes
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// pep440PreRelease matches SemVer prereleases that PEP 440 has a spelling for
+var pep440PreRelease = regexp.MustCompile(`^(alpha|a|beta|b|rc|c)\.?(\d*)$`)
+
+// invalidLocalChars matches characters not allowed in a PEP 440 local version label
+var invalidLocalChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// GeneratePEP440 generates a Python package version: 1.2.3 on a tag, 1.2.3.post5
+// after it, with the branch and hash in the local label (1.2.3.post5+feature.x.gabc1234).
+// alpha, beta and rc prereleases become a, b and rc; other prereleases move to the local label.
+func (vg *VersionGenerator) GeneratePEP440(lastTag string, commitsSince int, branchName string, includeHash bool, shortHash string) string {
+	release, _, _ := strings.Cut(strings.TrimPrefix(lastTag, "v"), "+")
+	release, prerelease, _ := strings.Cut(release, "-")
+
+	var local []string
+	version := release
+	if prerelease != "" {
+		if match := pep440PreRelease.FindStringSubmatch(strings.ToLower(prerelease)); match != nil {
+			number := match[2]
+			if number == "" {
+				number = "0"
+			}
+			version += map[string]string{"alpha": "a", "a": "a", "beta": "b", "b": "b", "rc": "rc", "c": "rc"}[match[1]] + number
+		} else {
+			local = append(local, localLabel(prerelease))
+		}
+	}
+	if commitsSince > 0 {
+		version = fmt.Sprintf("%s.post%d", version, commitsSince)
+	}
+
+	if !vg.isMainBranch(branchName) {
+		local = append(local, localLabel(branchName))
+	}
+	if includeHash && shortHash != "" {
+		local = append(local, "g"+shortHash)
+	}
+	if label := strings.Join(local, "."); label != "" {
+		version += "+" + label
+	}
+	return version
+}
+
+// localLabel turns text into dot-separated PEP 440 local version segments
+func localLabel(s string) string {
+	return strings.Trim(invalidLocalChars.ReplaceAllString(strings.ToLower(s), "."), ".")
+}
@@ -0,0 +1,378 @@
+package versionSchemes
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/quick"
+
+	"golang.org/x/mod/semver"
+)
+
+// repoState is a random repository state: a release tag, a branch and two commit counts
+type repoState struct {
+	Tag     string
+	Branch  string
+	Commits [2]int // Commits[0] <= Commits[1]
+}
+
+// branchWords build realistic branch names; cleanBranchName is fuzzed separately
+var branchWords = []string{"feature", "fix", "release", "user", "api", "JIRA-123", "new_parser", "v2", "ci.cd", "x"}
+
+// Generate implements quick.Generator
+func (repoState) Generate(r *rand.Rand, _ int) reflect.Value {
+	tag := fmt.Sprintf("v%d.%d.%d", r.Intn(20), r.Intn(20), r.Intn(20))
+	switch r.Intn(4) {
+	case 0:
+		tag += []string{"-alpha", "-beta.2", "-rc.1", "-rc.10"}[r.Intn(4)]
+	case 1:
+		tag = strings.TrimPrefix(tag, "v")
+	}
+
+	branch := []string{"main", "master"}[r.Intn(2)]
+	if r.Intn(2) == 0 {
+		parts := make([]string, 1+r.Intn(3))
+		for i := range parts {
+			parts[i] = branchWords[r.Intn(len(branchWords))]
+		}
+		branch = strings.Join(parts, []string{"/", "-"}[r.Intn(2)])
+	}
+
+	a, b := r.Intn(500), r.Intn(500)
+	return reflect.ValueOf(repoState{Tag: tag, Branch: branch, Commits: [2]int{min(a, b), max(a, b)}})
+}
+
+// checkProperty runs a property over many random repository states
+func checkProperty(t *testing.T, property func(state repoState) bool) {
+	t.Helper()
+	if err := quick.Check(property, &quick.Config{MaxCount: 2000}); err != nil {
+		t.Error(err)
+	}
+}
+
+// canonicalSemVer adds the v prefix golang.org/x/mod/semver requires
+func canonicalSemVer(version string) string {
+	return "v" + strings.TrimPrefix(version, "v")
+}
+
+// buildCount returns the commit count leading the build metadata of version, 0 without any
+func buildCount(version string) (int, error) {
+	_, build, found := strings.Cut(version, "+")
+	if !found {
+		return 0, nil
+	}
+	count, _, _ := strings.Cut(build, ".")
+	return strconv.Atoi(count)
+}
+
+func TestSchemesAreValidSemVer(t *testing.T) {
+	vg := NewVersionGenerator()
+	for _, options := range []VersioningOptions{{}, {Hash: true}, {Scheme: SchemeSemVer}, {Scheme: SchemeSemVer, Hash: true}} {
+		checkProperty(t, func(state repoState) bool {
+			for _, commits := range state.Commits {
+				// On main the semver scheme appends the count as a fourth release
+				// field (v1.2.3.5), its documented format, which SemVer has no room for
+				if options.Scheme == SchemeSemVer && vg.isMainBranch(state.Branch) && commits > 0 {
+					continue
+				}
+				version := vg.GenerateVersion(state.Tag, commits, "abc1234", state.Branch, options)
+				if !semver.IsValid(canonicalSemVer(version)) {
+					t.Logf("%+v with %d commits and %+v renders as %q, which is not valid SemVer", state, commits, options, version)
+					return false
+				}
+			}
+			return true
+		})
+	}
+}
+
+func TestDefaultSchemeNeverSortsLower(t *testing.T) {
+	vg := NewVersionGenerator()
+	checkProperty(t, func(state repoState) bool {
+		// A branch build is a prerelease of the tag it started from, so it sorts
+		// below the tagged commit itself; the property holds from its first commit on
+		if !vg.isMainBranch(state.Branch) {
+			state.Commits[0], state.Commits[1] = max(state.Commits[0], 1), max(state.Commits[1], 1)
+		}
+		if state.Commits[0] == state.Commits[1] {
+			state.Commits[1]++
+		}
+		before := vg.GenerateVersion(state.Tag, state.Commits[0], "abc1234", state.Branch, VersioningOptions{})
+		after := vg.GenerateVersion(state.Tag, state.Commits[1], "abc1234", state.Branch, VersioningOptions{})
+
+		// The count is build metadata, which SemVer precedence ignores, so the
+		// versions tie and the counts themselves must grow
+		if c := semver.Compare(canonicalSemVer(after), canonicalSemVer(before)); c != 0 {
+			t.Logf("%+v: %q and %q differ in precedence (%d)", state, after, before, c)
+			return false
+		}
+		countBefore, errBefore := buildCount(before)
+		countAfter, errAfter := buildCount(after)
+		if errBefore != nil || errAfter != nil || countAfter <= countBefore {
+			t.Logf("%+v: build count of %q does not follow %q", state, after, before)
+			return false
+		}
+		return true
+	})
+}
+
+func TestSemVerSchemeSortsHigherOnBranches(t *testing.T) {
+	vg := NewVersionGenerator()
+	options := VersioningOptions{Scheme: SchemeSemVer}
+	checkProperty(t, func(state repoState) bool {
+		if vg.isMainBranch(state.Branch) {
+			return true // Not SemVer there; see TestSchemesAreValidSemVer
+		}
+		// The count is the last prerelease identifier, so every commit raises precedence
+		before := vg.GenerateVersion(state.Tag, max(state.Commits[0], 1), "abc1234", state.Branch, options)
+		after := vg.GenerateVersion(state.Tag, max(state.Commits[1], 1)+1, "abc1234", state.Branch, options)
+		if semver.Compare(canonicalSemVer(after), canonicalSemVer(before)) <= 0 {
+			t.Logf("%+v: %q does not sort above %q", state, after, before)
+			return false
+		}
+		return true
+	})
+}
+
+func TestTemplateReproducesDefaultScheme(t *testing.T) {
+	vg := NewVersionGenerator()
+	options := VersioningOptions{
+		Scheme:   SchemeTemplate,
+		Template: `{{.Tag}}{{if not .Main}}-{{.Branch}}{{end}}{{if .CommitsSince}}+{{.CommitsSince}}{{end}}`,
+	}
+	if err := ValidateTemplate(options.Template); err != nil {
+		t.Fatal(err)
+	}
+	checkProperty(t, func(state repoState) bool {
+		for _, commits := range state.Commits {
+			if commits == 0 && !vg.isMainBranch(state.Branch) {
+				continue // The default scheme prints the bare tag here
+			}
+			want := vg.GenerateVersion(state.Tag, commits, "abc1234", state.Branch, VersioningOptions{})
+			if got := vg.GenerateVersion(state.Tag, commits, "abc1234", state.Branch, options); got != want {
+				t.Logf("%+v with %d commits: template renders %q, default scheme %q", state, commits, got, want)
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestCompareMatchesReference(t *testing.T) {
+	vg := NewVersionGenerator()
+	checkProperty(t, func(state repoState) bool {
+		a := vg.GenerateVersion(state.Tag, state.Commits[0], "abc1234", state.Branch, VersioningOptions{})
+		b := vg.GenerateVersion(state.Tag, state.Commits[1], "abc1234", "main", VersioningOptions{})
+		parsedA, errA := ParseSemVer(a)
+		parsedB, errB := ParseSemVer(b)
+		if errA != nil || errB != nil {
+			t.Logf("%+v: cannot parse %q or %q", state, a, b)
+			return false
+		}
+		if got, want := parsedA.Compare(parsedB), semver.Compare(canonicalSemVer(a), canonicalSemVer(b)); got != want {
+			t.Logf("Compare(%q, %q) = %d, golang.org/x/mod/semver says %d", a, b, got, want)
+			return false
+		}
+		return true
+	})
+}
+
+func TestReleaseCandidateSortsAbovePublished(t *testing.T) {
+	vg := NewVersionGenerator()
+	checkProperty(t, func(state repoState) bool {
+		next, err := vg.GenerateReleaseCandidate(state.Tag, state.Commits[1], BumpPatch, nil)
+		if err != nil {
+			t.Logf("%+v: %v", state, err)
+			return false
+		}
+		// Publish up to three candidates of that release, then build the next one
+		tags := []string{state.Tag}
+		base, _, _ := strings.Cut(next, "-")
+		for n := 1; n <= state.Commits[0]%4; n++ {
+			tags = append(tags, fmt.Sprintf("%s-rc.%d", base, n))
+		}
+		version, err := vg.GenerateReleaseCandidate(state.Tag, max(state.Commits[1], 1), BumpPatch, tags)
+		if err != nil {
+			t.Logf("%+v: %v", state, err)
+			return false
+		}
+		for _, tag := range tags {
+			if semver.Compare(canonicalSemVer(version), canonicalSemVer(tag)) <= 0 {
+				t.Logf("%+v: candidate %q does not sort above published %q", state, version, tag)
+				return false
+			}
+		}
+		return true
+	})
+}
+
+func TestPEP440IsValid(t *testing.T) {
+	vg := NewVersionGenerator()
+	checkProperty(t, func(state repoState) bool {
+		for _, commits := range state.Commits {
+			for _, includeHash := range []bool{false, true} {
+				version := vg.GeneratePEP440(state.Tag, commits, state.Branch, includeHash, "abc1234")
+				if !pep440.MatchString(version) {
+					t.Logf("%+v with %d commits renders as %q, which is not a valid PEP 440 version", state, commits, version)
+					return false
+				}
+			}
+		}
+		return true
+	})
+}
+
+func TestPEP440NeverSortsLower(t *testing.T) {
+	vg := NewVersionGenerator()
+	checkProperty(t, func(state repoState) bool {
+		before := vg.GeneratePEP440(state.Tag, state.Commits[0], state.Branch, false, "")
+		after := vg.GeneratePEP440(state.Tag, state.Commits[1], state.Branch, false, "")
+		if comparePEP440(after, before) < 0 {
+			t.Logf("%+v: %q sorts below %q", state, after, before)
+			return false
+		}
+		return true
+	})
+}
+
+// pep440Ordering is the ordering example of the PEP 440 specification
+// ("Summary of permitted suffixes and relative ordering"), from lowest to highest
+var pep440Ordering = []string{
+	"1.0.dev456",
+	"1.0a1",
+	"1.0a2.dev456",
+	"1.0a12.dev456",
+	"1.0a12",
+	"1.0b1.dev456",
+	"1.0b2",
+	"1.0b2.post345.dev456",
+	"1.0b2.post345",
+	"1.0rc1.dev456",
+	"1.0rc1",
+	"1.0",
+	"1.0+abc.5",
+	"1.0+abc.7",
+	"1.0+5",
+	"1.0.post456.dev34",
+	"1.0.post456",
+	"1.0.15",
+	"1.1.dev1",
+}
+
+func TestComparePEP440MatchesSpecification(t *testing.T) {
+	for i, a := range pep440Ordering {
+		if !pep440.MatchString(a) {
+			t.Errorf("%q from the specification does not match the canonical pattern", a)
+		}
+		for j, b := range pep440Ordering {
+			if got, want := comparePEP440(a, b), cmp.Compare(i, j); got != want {
+				t.Errorf("comparePEP440(%q, %q) = %d, the specification orders them %d", a, b, got, want)
+			}
+		}
+	}
+	if comparePEP440("1.2", "1.2.0") != 0 || comparePEP440("1!0.1", "2.0") <= 0 {
+		t.Error("comparePEP440 ignores trailing zeros or epochs")
+	}
+}
+
+// comparePEP440 orders two canonical PEP 440 versions following the sort key of
+// the specification's reference implementation, packaging.version
+func comparePEP440(a, b string) int {
+	pa, pb := parsePEP440(a), parsePEP440(b)
+	if c := cmp.Compare(pa.epoch, pb.epoch); c != 0 {
+		return c
+	}
+	for i := 0; i < max(len(pa.release), len(pb.release)); i++ {
+		if c := cmp.Compare(at(pa.release, i), at(pb.release, i)); c != 0 {
+			return c
+		}
+	}
+	for _, pair := range [][2]int{{pa.pre, pb.pre}, {pa.post, pb.post}, {pa.dev, pb.dev}} {
+		if c := cmp.Compare(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+	return compareLocal(pa.local, pb.local)
+}
+
+// pep440Parts are the comparable parts of a PEP 440 version
+type pep440Parts struct {
+	epoch   int
+	release []int
+	pre     int // phase*1e6 + number; -1 for a bare dev release, which sorts before every prerelease
+	post    int // -1 without a post-release
+	dev     int // math.MaxInt without a dev release, which sorts after it
+	local   []string
+}
+
+// parsePEP440 splits a version matched by pep440 into comparable parts
+func parsePEP440(version string) pep440Parts {
+	match := pep440.FindStringSubmatch(version)
+	if match == nil {
+		return pep440Parts{}
+	}
+	group := func(name string) string { return match[pep440.SubexpIndex(name)] }
+	number := func(name string, missing int) int {
+		if n, err := strconv.Atoi(group(name)); err == nil {
+			return n
+		}
+		return missing
+	}
+
+	parts := pep440Parts{
+		epoch: number("epoch", 0),
+		pre:   4e6,
+		post:  number("post", -1),
+		dev:   number("dev", math.MaxInt),
+	}
+	if phase := slices.Index([]string{"a", "b", "rc"}, group("phase")); phase >= 0 {
+		parts.pre = phase*1e6 + number("pre", 0)
+	} else if group("post") == "" && group("dev") != "" {
+		parts.pre = -1
+	}
+	for _, field := range strings.Split(group("release"), ".") {
+		n, _ := strconv.Atoi(field)
+		parts.release = append(parts.release, n)
+	}
+	if local := group("local"); local != "" {
+		parts.local = strings.Split(local, ".")
+	}
+	return parts
+}
+
+// compareLocal orders local labels: none sorts first, numeric segments sort after alphanumeric ones
+func compareLocal(a, b []string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		na, errA := strconv.Atoi(a[i])
+		nb, errB := strconv.Atoi(b[i])
+		var c int
+		switch {
+		case errA == nil && errB == nil:
+			c = cmp.Compare(na, nb)
+		case errA == nil:
+			c = 1
+		case errB == nil:
+			c = -1
+		default:
+			c = strings.Compare(a[i], b[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+// at returns s[i], or 0 past the end, so 1.2 and 1.2.0 compare equal
+func at(s []int, i int) int {
+	if i < len(s) {
+		return s[i]
+	}
+	return 0
+}
@@ -0,0 +1,72 @@
+package versionSchemes
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateFields are the values a SchemeTemplate version is rendered from,
+// e.g. {{.Tag}}{{if not .Main}}-{{.Branch}}{{end}}+{{.CommitsSince}}
+type TemplateFields struct {
+	Tag          string // Last tag, as written
+	CommitsSince int    // Commits since the last tag
+	Branch       string // Branch name cleaned for versions: feature/x becomes feature-x
+	Main         bool   // The branch is main or master
+	ShortHash    string // Abbreviated commit hash
+
+	now time.Time
+}
+
+// Date formats the version date, the Clock time of VersioningOptions, with a
+// Go time layout: {{.Date "2006.01"}}
+func (f TemplateFields) Date(layout string) string {
+	return f.now.Format(layout)
+}
+
+// parseTemplate parses a SchemeTemplate format; referring to a field that
+// does not exist fails when the template is executed
+func parseTemplate(text string) (*template.Template, error) {
+	return template.New("format").Option("missingkey=error").Parse(text)
+}
+
+// ValidateTemplate checks that a SchemeTemplate format parses and renders a
+// non-empty version without line breaks for a sample repository state
+func ValidateTemplate(text string) error {
+	tmpl, err := parseTemplate(text)
+	if err != nil {
+		return fmt.Errorf("invalid version template: %w", err)
+	}
+	sample := TemplateFields{Tag: "v1.2.3", CommitsSince: 5, Branch: "feature-x", ShortHash: "abc1234", now: time.Now()}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, sample); err != nil {
+		return fmt.Errorf("invalid version template: %w", err)
+	}
+	if version := sb.String(); strings.TrimSpace(version) == "" || strings.ContainsAny(version, "\r\n") {
+		return fmt.Errorf("invalid version template %q: it must render a non-empty version on one line", text)
+	}
+	return nil
+}
+
+// generateTemplate renders the Template of options. Templates that passed
+// ValidateTemplate do not fail; the last tag is returned if one does.
+func (vg *VersionGenerator) generateTemplate(lastTag string, commitsSince int, shortHash, branchName string, options VersioningOptions) string {
+	tmpl, err := parseTemplate(options.Template)
+	if err != nil {
+		return lastTag
+	}
+	fields := TemplateFields{
+		Tag:          lastTag,
+		CommitsSince: commitsSince,
+		Branch:       vg.cleanBranchName(branchName),
+		Main:         vg.isMainBranch(branchName),
+		ShortHash:    shortHash,
+		now:          options.Now(),
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, fields); err != nil {
+		return lastTag
+	}
+	return strings.TrimSpace(sb.String())
+}
@@ -2,50 +2,212 @@ package versionSchemes
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 	"time"
 )
 
-// VersioningOptions defines different versioning scheme options
+// Scheme is a version format selected with VersioningOptions.Scheme
+type Scheme string
+
+// Version schemes
+const (
+	SchemeDefault Scheme = "default" // v1.2.3+5 or v1.2.3-branch+5
+	SchemeSemVer  Scheme = "semver"  // Semantic Versioning: v1.2.3-alpha.4 or v1.2.3-beta.4+branch
+	SchemeCalVer  Scheme = "calver"  // Calendar Versioning: 2024.08.4 or 2024.08.4-branch
+	SchemeSimple  Scheme = "simple"  // The tag only: v1.2.3 (no branch/commit info)
+
+	// SchemeTemplate renders VersioningOptions.Template; it is selected by
+	// setting a template rather than by name
+	SchemeTemplate Scheme = "template"
+)
+
+// ParseScheme returns the scheme with the given name
+func ParseScheme(name string) (Scheme, error) {
+	switch scheme := Scheme(name); scheme {
+	case SchemeDefault, SchemeSemVer, SchemeCalVer, SchemeSimple:
+		return scheme, nil
+	}
+	return "", fmt.Errorf("unknown scheme %q: use default, semver, calver or simple", name)
+}
+
+// VersioningOptions is the one definition of how versions are rendered, shared
+// by the command line, the git handlers and the schemes
 type VersioningOptions struct {
-	Semver bool // Use Semantic Versioning: v1.2.3-alpha.4 or v1.2.3-beta.4+branch
-	CalVer bool // Use Calendar Versioning: 2024.08.4 or 2024.08.4-branch
-	Simple bool // Use simple format: v1.2.3 (no branch/commit info)
-	Hash   bool // Include short hash in version
+	Scheme Scheme // Version format (default: SchemeDefault)
+	Hash   bool   // Include short hash in version
+
+	Semver bool // Deprecated: use Scheme SchemeSemVer
+	CalVer bool // Deprecated: use Scheme SchemeCalVer
+	Simple bool // Deprecated: use Scheme SchemeSimple
+
+	CalVerDirty string         // How uncommitted changes affect CalVer: CalVerDirtyNone, CalVerDirtyBump or CalVerDirtyDev
+	Worktree    *WorktreeState // Working tree state, filled in by the git handler when CalVerDirty needs it
+
+	CalVerFormat  string // Date fields of CalVer versions (default: DefaultCalVerFormat)
+	CalVerReset   string // Period after which the CalVer counter restarts: CalVerResetNone, CalVerResetWeek, CalVerResetMonth or CalVerResetYear
+	PeriodCommits *int   // Commits since the tag within the current period, filled in by the git handler when CalVerReset is set
+
+	Template string // text/template of SchemeTemplate versions, executed with TemplateFields
+
+	Variant string // Build flavor (debug, asan, enterprise) added as a build-metadata identifier
+
+	CountSeparator string // Separator before the commit count, one of Separators (default: the scheme's own)
+	HashSeparator  string // Separator before the short hash, one of Separators (default: +)
+
+	Clock Clock // Time source of CalVer dates and counter resets (default: SystemClock)
+
+	Naming // Tag prefix and branch name sanitization, filled in by the git handler from its GitOptions
+}
+
+// Now returns the time of the configured Clock, or the current time
+func (o VersioningOptions) Now() time.Time {
+	if o.Clock == nil {
+		return SystemClock{}.Now()
+	}
+	return o.Clock.Now()
+}
+
+// Separators are the characters allowed between version components
+const Separators = "+.-_"
+
+// ValidateSeparator checks that a configured separator is empty (scheme default) or one of Separators
+func ValidateSeparator(separator string) error {
+	if separator != "" && (len(separator) != 1 || !strings.Contains(Separators, separator)) {
+		return fmt.Errorf("invalid separator %q: use one of + . - _", separator)
+	}
+	return nil
+}
+
+// SelectedScheme returns Scheme or, when it is empty, the scheme chosen with the
+// deprecated Semver, CalVer and Simple fields
+func (o VersioningOptions) SelectedScheme() Scheme {
+	switch {
+	case o.Scheme != "":
+		return o.Scheme
+	case o.CalVer:
+		return SchemeCalVer
+	case o.Semver:
+		return SchemeSemVer
+	case o.Simple:
+		return SchemeSimple
+	}
+	return SchemeDefault
+}
+
+// separators returns the count and hash separators, falling back to the scheme defaults
+func (o VersioningOptions) separators(countDefault string) (string, string) {
+	count, hash := o.CountSeparator, o.HashSeparator
+	if count == "" {
+		count = countDefault
+	}
+	if hash == "" {
+		hash = "+"
+	}
+	return count, hash
+}
+
+// CalVer handling of uncommitted changes
+const (
+	CalVerDirtyNone = "none" // ignore uncommitted changes (default)
+	CalVerDirtyBump = "bump" // bump the micro field: 2024.08.4 becomes 2024.08.5
+	CalVerDirtyDev  = "dev"  // append .devN, N being the index modification time: 2024.08.4.dev1723456789
+)
+
+// CalVer counter reset periods
+const (
+	CalVerResetNone  = "none"  // count every commit since the last tag (default)
+	CalVerResetWeek  = "week"  // count commits since the start of the ISO week (Monday)
+	CalVerResetMonth = "month" // count commits since the first day of the month
+	CalVerResetYear  = "year"  // count commits since January 1st
+)
+
+// WorktreeState describes uncommitted changes in the working tree
+type WorktreeState struct {
+	Dirty     bool      // Tracked files differ from HEAD
+	IndexTime time.Time // Last modification of the git index
+}
+
+// NeedsWorktreeState reports whether generating a version requires WorktreeState
+func (o VersioningOptions) NeedsWorktreeState() bool {
+	return o.SelectedScheme() == SchemeCalVer && o.CalVerDirty != "" && o.CalVerDirty != CalVerDirtyNone
+}
+
+// CalVerPeriodStart returns the start of the reset period containing now, in
+// now's location, and false when the CalVer counter does not reset
+func (o VersioningOptions) CalVerPeriodStart(now time.Time) (time.Time, bool) {
+	if o.SelectedScheme() != SchemeCalVer {
+		return time.Time{}, false
+	}
+	year, month, day := now.Date()
+	switch o.CalVerReset {
+	case CalVerResetWeek:
+		// time.Weekday counts from Sunday; ISO weeks start on Monday
+		offset := (int(now.Weekday()) + 6) % 7
+		return time.Date(year, month, day-offset, 0, 0, 0, 0, now.Location()), true
+	case CalVerResetMonth:
+		return time.Date(year, month, 1, 0, 0, 0, 0, now.Location()), true
+	case CalVerResetYear:
+		return time.Date(year, time.January, 1, 0, 0, 0, 0, now.Location()), true
+	default:
+		return time.Time{}, false
+	}
 }
 
 // VersionGenerator provides methods to generate version strings using different schemes
-type VersionGenerator struct{}
+type VersionGenerator struct {
+	naming Naming // Cleans branch names in the versions of the methods without VersioningOptions
+}
 
 // NewVersionGenerator creates a new version generator
 func NewVersionGenerator() *VersionGenerator {
 	return &VersionGenerator{}
 }
 
-// GenerateVersion generates version string based on the provided options
+// NewVersionGeneratorWith creates a version generator that cleans branch names with naming
+func NewVersionGeneratorWith(naming Naming) *VersionGenerator {
+	return &VersionGenerator{naming: naming}
+}
+
+// GenerateVersion generates version string based on the provided options.
+// A variant never changes precedence, so every scheme carries it as build
+// metadata: v1.2.3+5.debug, v1.2.3.5+asan or 2024.08.4+enterprise.
 func (vg *VersionGenerator) GenerateVersion(lastTag string, commitsSince int, shortHash, branchName string, options VersioningOptions) string {
+	vg = NewVersionGeneratorWith(options.Naming)
+	version := vg.generateVersion(lastTag, commitsSince, shortHash, branchName, options)
+	if options.Variant != "" {
+		version = AppendBuildMetadata(version, []BuildMetadata{{Key: options.Variant}})
+	}
+	return version
+}
+
+// generateVersion generates the version string of the selected scheme
+func (vg *VersionGenerator) generateVersion(lastTag string, commitsSince int, shortHash, branchName string, options VersioningOptions) string {
+	scheme := options.SelectedScheme()
+	if scheme == SchemeTemplate {
+		// The template decides what a tagged commit looks like
+		return vg.generateTemplate(lastTag, commitsSince, shortHash, branchName, options)
+	}
 	if commitsSince == 0 && !options.Hash {
 		// We're exactly on a tag and no hash requested
-		if options.Simple {
-			return lastTag
-		}
-		if options.CalVer {
-			return vg.GenerateCalVer(lastTag, 0, branchName, false, shortHash)
+		if scheme == SchemeCalVer {
+			return vg.generateCalVerWithOptions(lastTag, 0, branchName, shortHash, options)
 		}
 		return lastTag
 	}
 
 	// Handle different versioning schemes
-	switch {
-	case options.CalVer:
-		return vg.GenerateCalVer(lastTag, commitsSince, branchName, options.Hash, shortHash)
-	case options.Semver:
-		return vg.GenerateSemVer(lastTag, commitsSince, branchName, options.Hash, shortHash)
-	case options.Simple:
-		return vg.GenerateSimple(lastTag, shortHash, options.Hash)
+	switch scheme {
+	case SchemeCalVer:
+		return vg.generateCalVerWithOptions(lastTag, commitsSince, branchName, shortHash, options)
+	case SchemeSemVer:
+		countSep, hashSep := options.separators(".")
+		return vg.generateSemVer(lastTag, commitsSince, branchName, options.Hash, shortHash, countSep, hashSep)
+	case SchemeSimple:
+		_, hashSep := options.separators("")
+		return vg.generateSimple(lastTag, shortHash, options.Hash, hashSep)
 	default:
-		return vg.GenerateDefault(lastTag, commitsSince, shortHash, branchName, options.Hash)
+		countSep, hashSep := options.separators("+")
+		return vg.generateDefault(lastTag, commitsSince, shortHash, branchName, options.Hash, countSep, hashSep)
 	}
 }
 
@@ -82,12 +244,36 @@ func (vg *VersionGenerator) GenerateLegacy(lastTag string, commitsSince int, sho
 
 // GenerateCalVer generates Calendar Versioning format
 func (vg *VersionGenerator) GenerateCalVer(lastTag string, commitsSince int, branchName string, includeHash bool, shortHash string) string {
-	now := time.Now()
-	calVer := fmt.Sprintf("%d.%02d", now.Year(), now.Month())
+	return vg.generateCalVer(DefaultCalVerFormat, SystemClock{}.Now(), commitsSince, "", branchName, includeHash, shortHash, ".", "+")
+}
+
+// generateCalVerWithOptions generates CalVer, applying the CalVerDirty mode to a dirty worktree
+func (vg *VersionGenerator) generateCalVerWithOptions(lastTag string, commitsSince int, branchName, shortHash string, options VersioningOptions) string {
+	// Commits from before the current period do not count once the counter resets
+	if options.PeriodCommits != nil {
+		commitsSince = min(commitsSince, *options.PeriodCommits)
+	}
+	dev := ""
+	if options.Worktree != nil && options.Worktree.Dirty {
+		switch options.CalVerDirty {
+		case CalVerDirtyBump:
+			commitsSince++
+		case CalVerDirtyDev:
+			dev = fmt.Sprintf(".dev%d", max(options.Worktree.IndexTime.Unix(), 0))
+		}
+	}
+	countSep, hashSep := options.separators(".")
+	return vg.generateCalVer(options.CalVerFormat, options.Now(), commitsSince, dev, branchName, options.Hash, shortHash, countSep, hashSep)
+}
+
+// generateCalVer builds the CalVer string for date, placing dev right after the numeric fields
+func (vg *VersionGenerator) generateCalVer(format string, date time.Time, commitsSince int, dev, branchName string, includeHash bool, shortHash, countSep, hashSep string) string {
+	calVer := FormatCalVer(format, date)
 
 	if commitsSince > 0 {
-		calVer = fmt.Sprintf("%s.%d", calVer, commitsSince)
+		calVer = fmt.Sprintf("%s%s%d", calVer, countSep, commitsSince)
 	}
+	calVer += dev
 
 	if !vg.isMainBranch(branchName) {
 		cleanBranch := vg.cleanBranchName(branchName)
@@ -95,7 +281,7 @@ func (vg *VersionGenerator) GenerateCalVer(lastTag string, commitsSince int, bra
 	}
 
 	if includeHash && shortHash != "" {
-		calVer = fmt.Sprintf("%s+%s", calVer, shortHash)
+		calVer = appendHash(calVer, hashSep, shortHash)
 	}
 
 	return calVer
@@ -103,6 +289,11 @@ func (vg *VersionGenerator) GenerateCalVer(lastTag string, commitsSince int, bra
 
 // GenerateSemVer generates Semantic Versioning format
 func (vg *VersionGenerator) GenerateSemVer(lastTag string, commitsSince int, branchName string, includeHash bool, shortHash string) string {
+	return vg.generateSemVer(lastTag, commitsSince, branchName, includeHash, shortHash, ".", "+")
+}
+
+// generateSemVer generates Semantic Versioning format with the given separators
+func (vg *VersionGenerator) generateSemVer(lastTag string, commitsSince int, branchName string, includeHash bool, shortHash, countSep, hashSep string) string {
 	if commitsSince == 0 && !includeHash {
 		return lastTag
 	}
@@ -115,19 +306,19 @@ func (vg *VersionGenerator) GenerateSemVer(lastTag string, commitsSince int, bra
 
 	if vg.isMainBranch(branchName) {
 		if commitsSince > 0 {
-			version = fmt.Sprintf("%s.%d", version, commitsSince)
+			version = fmt.Sprintf("%s%s%d", version, countSep, commitsSince)
 		}
 	} else {
 		cleanBranch := vg.cleanBranchName(branchName)
 		if commitsSince > 0 {
-			version = fmt.Sprintf("%s-%s.%d", version, cleanBranch, commitsSince)
+			version = fmt.Sprintf("%s-%s%s%d", version, cleanBranch, countSep, commitsSince)
 		} else {
 			version = fmt.Sprintf("%s-%s", version, cleanBranch)
 		}
 	}
 
 	if includeHash && shortHash != "" {
-		version = fmt.Sprintf("%s+%s", version, shortHash)
+		version = appendHash(version, hashSep, shortHash)
 	}
 
 	return ensureVersionPrefix(version)
@@ -135,14 +326,24 @@ func (vg *VersionGenerator) GenerateSemVer(lastTag string, commitsSince int, bra
 
 // GenerateSimple generates simple version format
 func (vg *VersionGenerator) GenerateSimple(lastTag string, shortHash string, includeHash bool) string {
+	return vg.generateSimple(lastTag, shortHash, includeHash, "+")
+}
+
+// generateSimple generates simple version format with the given hash separator
+func (vg *VersionGenerator) generateSimple(lastTag string, shortHash string, includeHash bool, hashSep string) string {
 	if includeHash {
-		return fmt.Sprintf("%s+%s", lastTag, shortHash)
+		return appendHash(lastTag, hashSep, shortHash)
 	}
 	return lastTag
 }
 
 // GenerateDefault generates default format
 func (vg *VersionGenerator) GenerateDefault(lastTag string, commitsSince int, shortHash, branchName string, includeHash bool) string {
+	return vg.generateDefault(lastTag, commitsSince, shortHash, branchName, includeHash, "+", "+")
+}
+
+// generateDefault generates default format with the given separators
+func (vg *VersionGenerator) generateDefault(lastTag string, commitsSince int, shortHash, branchName string, includeHash bool, countSep, hashSep string) string {
 	if commitsSince == 0 && !includeHash {
 		return lastTag
 	}
@@ -150,19 +351,19 @@ func (vg *VersionGenerator) GenerateDefault(lastTag string, commitsSince int, sh
 	version := lastTag
 	if vg.isMainBranch(branchName) {
 		if commitsSince > 0 {
-			version = fmt.Sprintf("%s+%d", lastTag, commitsSince)
+			version = fmt.Sprintf("%s%s%d", lastTag, countSep, commitsSince)
 		}
 	} else {
 		cleanBranch := vg.cleanBranchName(branchName)
 		if commitsSince > 0 {
-			version = fmt.Sprintf("%s-%s+%d", lastTag, cleanBranch, commitsSince)
+			version = fmt.Sprintf("%s-%s%s%d", lastTag, cleanBranch, countSep, commitsSince)
 		} else {
 			version = fmt.Sprintf("%s-%s", lastTag, cleanBranch)
 		}
 	}
 
 	if includeHash {
-		version = fmt.Sprintf("%s+%s", version, shortHash)
+		version = appendHash(version, hashSep, shortHash)
 	}
 
 	return version
@@ -170,12 +371,28 @@ func (vg *VersionGenerator) GenerateDefault(lastTag string, commitsSince int, sh
 
 // Helper functions
 
+// appendHash appends the short hash after hashSep. A version carries one build
+// metadata section, so a + hash separator after a + count joins it with a dot
+// instead: v1.2.3+5.abc1234 rather than v1.2.3+5+abc1234.
+func appendHash(version, hashSep, shortHash string) string {
+	if hashSep == "+" && strings.Contains(version, "+") {
+		hashSep = "."
+	}
+	return version + hashSep + shortHash
+}
+
 func (vg *VersionGenerator) isMainBranch(branchName string) bool {
 	return branchName == "main" || branchName == "master" || branchName == "detached"
 }
 
+// CleanBranchName returns a branch name as versions carry it with the default
+// SanitizeSemVer profile; see Naming.CleanBranch
+func CleanBranchName(branchName string) string {
+	return Naming{}.CleanBranch(branchName)
+}
+
 func (vg *VersionGenerator) cleanBranchName(branchName string) string {
-	return regexp.MustCompile(`[^a-zA-Z0-9\-]`).ReplaceAllString(branchName, "-")
+	return vg.naming.CleanBranch(branchName)
 }
 
 func hasVersionPrefix(version string) bool {